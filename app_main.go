@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
@@ -76,7 +77,23 @@ type Scene interface {
 }
 
 var firstSceneConstructor func() Scene = func() Scene {
-	return NewGameUI()
+	gu := NewGameUI()
+	if err := gu.ApplyLaunchOptions(TheLaunchOptions); err != nil {
+		launchOptionsError(err)
+	}
+	return gu
+}
+
+// bad launch options on desktop are fatal
+// so that scripts don't silently start a wrong board
+//
+// but on web we just keep going with defaults
+func launchOptionsError(err error) {
+	if runtime.GOOS == "js" {
+		ErrLogger.Printf("invalid launch options: %v", err)
+	} else {
+		ErrLogger.Fatalf("invalid launch options: %v", err)
+	}
 }
 
 func OverrideFirstScene(sceneConstructor func() Scene) {
@@ -131,11 +148,11 @@ func (a *App) Update() error {
 	// ==========================
 	// asset loading and saving
 	// ==========================
-	if IsKeyJustPressed(ReloadAssetsKey) && IsDevVersion {
+	if IsHotkeyJustPressed(ReloadAssetsKey) && IsDevVersion {
 		LoadAssets()
 	}
 
-	if IsKeyJustPressed(SaveAssetsKey) && IsDevVersion {
+	if IsHotkeyJustPressed(SaveAssetsKey) && IsDevVersion {
 		SaveColorTable()
 		SaveBezierTable()
 		SaveHSVmodTable()
@@ -144,14 +161,14 @@ func (a *App) Update() error {
 	// ==========================
 	// debug showing
 	// ==========================
-	if IsKeyJustPressed(ShowDebugConsoleKey) && IsDevVersion {
+	if IsHotkeyJustPressed(ShowDebugConsoleKey) && IsDevVersion {
 		a.ShowDebugConsole = !a.ShowDebugConsole
 	}
 
 	// ==========================
 	// screenshot
 	// ==========================
-	if ScreenshotEnabled && IsKeyJustPressed(ScreenshotKey) {
+	if ScreenshotEnabled && IsHotkeyJustPressed(ScreenshotKey) {
 		a.ScreenshotQueued = true
 	}
 
@@ -225,6 +242,21 @@ func AppMain() {
 
 	flag.Parse()
 
+	if err := ParsePlatformLaunchOptions(); err != nil {
+		launchOptionsError(err)
+	}
+
+	// window size has to be known before the first scene is created
+	// since board size depends on screen ratio
+	if TheLaunchOptions.WindowSize != "" {
+		if w, h, err := ParseWindowSize(TheLaunchOptions.WindowSize); err == nil {
+			ScreenWidth = f64(w)
+			ScreenHeight = f64(h)
+		} else {
+			launchOptionsError(err)
+		}
+	}
+
 	InitInputManager()
 
 	InitClipboardManager()
@@ -241,6 +273,7 @@ func AppMain() {
 	eb.SetWindowSize(int(ScreenWidth), int(ScreenHeight))
 	eb.SetWindowResizingMode(eb.WindowResizingModeEnabled)
 	eb.SetWindowTitle("Minesweeper")
+	eb.SetFullscreen(TheLaunchOptions.Fullscreen)
 	eb.SetScreenClearedEveryFrame(false)
 	eb.SetTPS(120)
 
@@ -0,0 +1,162 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"minesweeper/engine"
+)
+
+type PlayOptions struct {
+	// how long bot can think for each command, 0 means no limit
+	MoveTimeout time.Duration
+
+	// game is counted as lost after this many commands,
+	// 0 means width * height * 4
+	MaxMoves int
+
+	// game is counted as lost after this many rejected commands in a row,
+	// 0 means 10
+	MaxInvalidCommands int
+}
+
+type GameResult struct {
+	Code  engine.GameCode
+	State engine.GameState
+
+	Moves int
+
+	// wall clock time from start message to end message
+	Duration time.Duration
+	// time spent waiting for bot's commands
+	ThinkTime time.Duration
+
+	Replay engine.Replay
+
+	// set when game ended because of bot's misbehavior
+	// (time out, too many moves, crash and so on).
+	// Replay of such game is aborted instead of lost, since it's events don't lose it
+	Err error
+}
+
+// Play plays one game with given code against bot.
+//
+// Bot's misbehavior loses the game and is reported in GameResult.Err.
+// If Play returns an error, the game is also lost
+// but the process is unusable and should be closed.
+func Play(p *Process, gameIndex int, code engine.GameCode, opts PlayOptions) (GameResult, error) {
+	if opts.MaxMoves <= 0 {
+		opts.MaxMoves = code.Width * code.Height * 4
+	}
+	if opts.MaxInvalidCommands <= 0 {
+		opts.MaxInvalidCommands = 10
+	}
+
+	result := GameResult{Code: code}
+
+	board := engine.NewBoard(code.Width, code.Height)
+	state := engine.GameStatePlaying
+
+	recorder := engine.NewReplayRecorder(code)
+
+	newMessage := func(msgType MessageType) Message {
+		return Message{
+			Type:   msgType,
+			Game:   gameIndex,
+			Width:  code.Width,
+			Height: code.Height,
+			Mines:  code.MineCount,
+			State:  state,
+			Tiles:  VisibleTiles(board, state),
+			Moves:  result.Moves,
+		}
+	}
+
+	startTime := time.Now()
+
+	if err := p.Send(newMessage(MessageTypeStart)); err != nil {
+		return result, err
+	}
+
+	invalidCount := 0
+	lastError := ""
+
+	for state == engine.GameStatePlaying {
+		if result.Moves >= opts.MaxMoves {
+			result.Err = fmt.Errorf("bot exceeded %d moves", opts.MaxMoves)
+			state = engine.GameStateLost
+			break
+		}
+		if invalidCount >= opts.MaxInvalidCommands {
+			result.Err = fmt.Errorf("bot sent %d invalid commands in a row: %s", invalidCount, lastError)
+			state = engine.GameStateLost
+			break
+		}
+
+		msg := newMessage(MessageTypeBoard)
+		msg.Error = lastError
+		if err := p.Send(msg); err != nil {
+			return result, err
+		}
+
+		thinkStart := time.Now()
+		cmd, err := p.ReadCommand(opts.MoveTimeout)
+		result.ThinkTime += time.Since(thinkStart)
+
+		// late answer of a timed out bot would be read as the next command,
+		// so the process can't be used anymore
+		if errors.Is(err, ErrBotExited) || errors.Is(err, ErrBotTimeout) {
+			recorder.Abort(err.Error(), board)
+
+			result.State = engine.GameStateLost
+			result.Duration = time.Since(startTime)
+			result.Replay = recorder.Replay
+			result.Err = err
+			return result, err
+		}
+		if err != nil {
+			// bad json, let the bot know and try again
+			invalidCount++
+			lastError = err.Error()
+			continue
+		}
+
+		if cmd.Action < 0 || cmd.Action >= ActionSize {
+			invalidCount++
+			lastError = fmt.Sprintf("invalid action %d", int(cmd.Action))
+			continue
+		}
+		if !board.IsPosInBoard(cmd.X, cmd.Y) {
+			invalidCount++
+			lastError = fmt.Sprintf("position %d, %d is out of board", cmd.X, cmd.Y)
+			continue
+		}
+
+		invalidCount = 0
+		lastError = ""
+
+		interaction := cmd.Action.Interaction()
+
+		state = board.InteractWithCode(cmd.X, cmd.Y, interaction, state, code, nil)
+		recorder.Record(interaction, cmd.X, cmd.Y)
+
+		result.Moves++
+	}
+
+	if result.Err != nil {
+		recorder.Abort(result.Err.Error(), board)
+	} else {
+		recorder.Finish(state, board)
+	}
+
+	result.State = state
+	result.Duration = time.Since(startTime)
+	result.Replay = recorder.Replay
+
+	if err := p.Send(newMessage(MessageTypeEnd)); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+var (
+	ErrBotTimeout = errors.New("bot did not answer in time")
+	ErrBotExited  = errors.New("bot has exited")
+)
+
+type commandOrErr struct {
+	Command Command
+	Err     error
+}
+
+// Process is a running bot executable
+type Process struct {
+	cmd *exec.Cmd
+
+	stdin   io.WriteCloser
+	encoder *json.Encoder
+
+	commands chan commandOrErr
+}
+
+// StartProcess starts bot executable with given arguments.
+func StartProcess(name string, args ...string) (*Process, error) {
+	p := new(Process)
+
+	p.cmd = exec.Command(name, args...)
+	p.cmd.Stderr = os.Stderr
+
+	var err error
+
+	if p.stdin, err = p.cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	stdout, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start bot: %w", err)
+	}
+
+	p.encoder = json.NewEncoder(p.stdin)
+	p.commands = make(chan commandOrErr)
+
+	go func() {
+		defer close(p.commands)
+
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+
+			var cmd Command
+			if err := json.Unmarshal(line, &cmd); err != nil {
+				p.commands <- commandOrErr{Err: fmt.Errorf("bot sent invalid command %q: %w", line, err)}
+				continue
+			}
+			p.commands <- commandOrErr{Command: cmd}
+		}
+
+		err := ErrBotExited
+		if scanErr := scanner.Err(); scanErr != nil {
+			err = fmt.Errorf("%w: %w", ErrBotExited, scanErr)
+		}
+		p.commands <- commandOrErr{Err: err}
+	}()
+
+	return p, nil
+}
+
+// Send writes msg as a single line to bot's stdin.
+func (p *Process) Send(msg Message) error {
+	msg.Version = ProtocolVersion
+	return p.encoder.Encode(msg)
+}
+
+// ReadCommand waits for next command from bot.
+//
+// If timeout is 0, it waits forever.
+func (p *Process) ReadCommand(timeout time.Duration) (Command, error) {
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+
+	select {
+	case c, ok := <-p.commands:
+		if !ok {
+			return Command{}, ErrBotExited
+		}
+		return c.Command, c.Err
+	case <-timeoutC:
+		return Command{}, ErrBotTimeout
+	}
+}
+
+// Close closes bot's stdin and waits for it to exit.
+//
+// Bots are expected to exit when their stdin is closed,
+// bots that don't are killed after a second.
+func (p *Process) Close() error {
+	p.stdin.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		// drain remaining output so that bot isn't blocked on writing
+		for range p.commands {
+		}
+		done <- p.cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		p.cmd.Process.Kill()
+		return <-done
+	}
+}
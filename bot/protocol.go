@@ -0,0 +1,201 @@
+// Package bot lets an external program play minesweeper
+// through line delimited json on it's stdin and stdout.
+//
+// Game sends one Message per line to bot's stdin
+// and bot answers every "board" message with one Command per line on it's stdout.
+// Anything bot writes to stderr is passed through, so bots can log there.
+package bot
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"minesweeper/engine"
+)
+
+const ProtocolVersion = 1
+
+// ==============================================
+// game -> bot
+// ==============================================
+
+type MessageType int
+
+const (
+	// sent once when a new game starts
+	MessageTypeStart MessageType = iota
+	// sent when game wants a command
+	MessageTypeBoard
+	// sent once when game is over, tiles have every mine revealed
+	MessageTypeEnd
+	MessageTypeSize
+)
+
+var MessageTypeStrs = [MessageTypeSize]string{
+	"start",
+	"board",
+	"end",
+}
+
+func (mt MessageType) String() string {
+	if mt < 0 || mt >= MessageTypeSize {
+		return fmt.Sprintf("MessageType(%d)", int(mt))
+	}
+	return MessageTypeStrs[mt]
+}
+
+func (mt MessageType) MarshalText() ([]byte, error) {
+	if mt < 0 || mt >= MessageTypeSize {
+		return nil, fmt.Errorf("invalid message type %d", int(mt))
+	}
+	return []byte(MessageTypeStrs[mt]), nil
+}
+
+func (mt *MessageType) UnmarshalText(text []byte) error {
+	for i := MessageType(0); i < MessageTypeSize; i++ {
+		if string(text) == MessageTypeStrs[i] {
+			*mt = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown message type %q", string(text))
+}
+
+// characters used in Message.Tiles
+const (
+	TileHidden = '#'
+	TileFlag   = 'F'
+	TileMine   = '*'
+	// revealed tiles are '0' to '8', number of neighboring mines
+)
+
+type Message struct {
+	Type    MessageType `json:"type"`
+	Version int         `json:"version"`
+
+	// index of the game, starts from 0
+	Game int `json:"game"`
+
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	Mines  int `json:"mines"`
+
+	State engine.GameState `json:"state"`
+
+	// one string per row, Tiles[y][x] is the tile at x, y
+	Tiles []string `json:"tiles,omitempty"`
+
+	// number of commands game has received in this game
+	Moves int `json:"moves"`
+
+	// set when previous command was rejected
+	Error string `json:"error,omitempty"`
+}
+
+// VisibleTiles returns the board as bot is allowed to see it.
+//
+// Mines are only shown when game is over, or when they were hit with lives.
+func VisibleTiles(board engine.Board, gameState engine.GameState) []string {
+	showMines := gameState != engine.GameStatePlaying
+
+	rows := make([]string, board.Height)
+	sb := &strings.Builder{}
+
+	for y := range board.Height {
+		sb.Reset()
+		for x := range board.Width {
+			switch {
+			case board.Revealed.Get(x, y) && board.Mines.Get(x, y):
+				sb.WriteByte(TileMine)
+			case board.Revealed.Get(x, y):
+				sb.WriteByte(byte('0' + board.GetNeighborMineCount(x, y)))
+			case showMines && board.Mines.Get(x, y):
+				sb.WriteByte(TileMine)
+			case board.Flags.Get(x, y):
+				sb.WriteByte(TileFlag)
+			default:
+				sb.WriteByte(TileHidden)
+			}
+		}
+		rows[y] = sb.String()
+	}
+
+	return rows
+}
+
+// ==============================================
+// bot -> game
+// ==============================================
+
+type Action int
+
+const (
+	ActionStep Action = iota
+	ActionFlag
+	ActionChord
+	ActionSize
+)
+
+var ActionStrs = [ActionSize]string{
+	"step",
+	"flag",
+	"chord",
+}
+
+func (a Action) String() string {
+	if a < 0 || a >= ActionSize {
+		return fmt.Sprintf("Action(%d)", int(a))
+	}
+	return ActionStrs[a]
+}
+
+func (a Action) MarshalText() ([]byte, error) {
+	if a < 0 || a >= ActionSize {
+		return nil, fmt.Errorf("invalid action %d", int(a))
+	}
+	return []byte(ActionStrs[a]), nil
+}
+
+func (a *Action) UnmarshalText(text []byte) error {
+	for i := Action(0); i < ActionSize; i++ {
+		if string(text) == ActionStrs[i] {
+			*a = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown action %q", string(text))
+}
+
+func (a Action) Interaction() engine.BoardInteractionType {
+	switch a {
+	case ActionStep:
+		return engine.InteractionTypeStep
+	case ActionFlag:
+		return engine.InteractionTypeFlag
+	case ActionChord:
+		return engine.InteractionTypeCheck
+	}
+	return engine.InteractionTypeNone
+}
+
+type Command struct {
+	Action Action `json:"action"`
+
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// ==============================================
+// misc
+// ==============================================
+
+// SeedForGame returns seed of n'th game derived from base seed,
+// so that a set of games can be reproduced from one seed.
+func SeedForGame(base [32]byte, n int) [32]byte {
+	var buf [40]byte
+	copy(buf[:], base[:])
+	binary.BigEndian.PutUint64(buf[32:], uint64(n))
+	return sha256.Sum256(buf[:])
+}
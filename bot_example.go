@@ -0,0 +1,116 @@
+//go:build ignore
+
+// ====================================================
+// example bot for the bot protocol
+//
+// it flags and chords tiles that are obvious from a single number
+// and steps on a random hidden tile when there is nothing obvious
+//
+// usage :
+// 	go run bot_runner.go -- go run bot_example.go
+// ====================================================
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+
+	"minesweeper/bot"
+)
+
+func main() {
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(nil, 1<<20)
+
+	encoder := json.NewEncoder(os.Stdout)
+
+	for scanner.Scan() {
+		var msg bot.Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			fmt.Fprintf(os.Stderr, "example bot: %v\n", err)
+			os.Exit(1)
+		}
+
+		if msg.Type != bot.MessageTypeBoard {
+			continue
+		}
+		if msg.Error != "" {
+			fmt.Fprintf(os.Stderr, "example bot: game rejected command: %s\n", msg.Error)
+		}
+
+		if err := encoder.Encode(decide(msg)); err != nil {
+			os.Exit(1)
+		}
+	}
+}
+
+func decide(msg bot.Message) bot.Command {
+	tileAt := func(x, y int) byte {
+		return msg.Tiles[y][x]
+	}
+
+	forNeighbors := func(x, y int, f func(nx, ny int)) {
+		for ny := y - 1; ny <= y+1; ny++ {
+			for nx := x - 1; nx <= x+1; nx++ {
+				if nx == x && ny == y {
+					continue
+				}
+				if nx < 0 || ny < 0 || nx >= msg.Width || ny >= msg.Height {
+					continue
+				}
+				f(nx, ny)
+			}
+		}
+	}
+
+	var hiddens [][2]int
+
+	for y := range msg.Height {
+		for x := range msg.Width {
+			tile := tileAt(x, y)
+
+			if tile == bot.TileHidden {
+				hiddens = append(hiddens, [2]int{x, y})
+				continue
+			}
+			if tile < '1' || tile > '8' {
+				continue
+			}
+
+			number := int(tile - '0')
+			hiddenCount, flagCount := 0, 0
+			hiddenX, hiddenY := 0, 0
+
+			forNeighbors(x, y, func(nx, ny int) {
+				switch tileAt(nx, ny) {
+				case bot.TileHidden:
+					hiddenCount++
+					hiddenX, hiddenY = nx, ny
+				case bot.TileFlag:
+					flagCount++
+				}
+			})
+
+			if hiddenCount <= 0 {
+				continue
+			}
+
+			// every hidden neighbor is a mine
+			if hiddenCount+flagCount == number {
+				return bot.Command{Action: bot.ActionFlag, X: hiddenX, Y: hiddenY}
+			}
+			// every hidden neighbor is safe
+			if flagCount == number {
+				return bot.Command{Action: bot.ActionChord, X: x, Y: y}
+			}
+		}
+	}
+
+	// guess
+	pos := hiddens[rand.IntN(len(hiddens))]
+	return bot.Command{Action: bot.ActionStep, X: pos[0], Y: pos[1]}
+}
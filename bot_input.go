@@ -0,0 +1,189 @@
+package minesweeper
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"minesweeper/bot"
+	"minesweeper/engine"
+)
+
+type botRequest struct {
+	Message bot.Message
+	// true if bot should answer to this message
+	WantCommand bool
+}
+
+type botAnswer struct {
+	Game    int
+	Command bot.Command
+	Err     error
+}
+
+// BotInputSource is a GameInputSource that plays with commands
+// from a bot executable so that we can watch it play.
+//
+// Bot is talked to in a separate goroutine
+// so that a slow bot doesn't block the game.
+type BotInputSource struct {
+	// minimum time between commands
+	MoveDelay time.Duration
+
+	proc *bot.Process
+
+	requests chan botRequest
+	answers  chan botAnswer
+
+	gameIndex int
+	moves     int
+
+	sentStart bool
+	sentEnd   bool
+	waiting   bool
+	failed    bool
+
+	lastMoveTime time.Time
+}
+
+func NewBotInputSource(args []string, moveDelay time.Duration) (*BotInputSource, error) {
+	bs := new(BotInputSource)
+
+	bs.MoveDelay = moveDelay
+
+	var err error
+	if bs.proc, err = bot.StartProcess(args[0], args[1:]...); err != nil {
+		return nil, err
+	}
+
+	bs.requests = make(chan botRequest, 8)
+	bs.answers = make(chan botAnswer, 1)
+
+	go func() {
+		for req := range bs.requests {
+			if err := bs.proc.Send(req.Message); err != nil {
+				bs.answers <- botAnswer{Game: req.Message.Game, Err: fmt.Errorf("%w: %w", bot.ErrBotExited, err)}
+				return
+			}
+			if !req.WantCommand {
+				continue
+			}
+
+			cmd, err := bs.proc.ReadCommand(0)
+			bs.answers <- botAnswer{Game: req.Message.Game, Command: cmd, Err: err}
+
+			if errors.Is(err, bot.ErrBotExited) {
+				return
+			}
+		}
+	}()
+
+	return bs, nil
+}
+
+func (bs *BotInputSource) OnBoardReset() {
+	bs.gameIndex++
+	bs.moves = 0
+	bs.sentStart = false
+	bs.sentEnd = false
+	// answer for previous board will be ignored
+	bs.waiting = false
+}
+
+func (bs *BotInputSource) GetGameInput(
+	board engine.Board, gameState engine.GameState, mineCount int,
+) GameInput {
+	input := GameInput{Type: InputTypeNone}
+
+	if bs.failed {
+		return input
+	}
+
+	newMessage := func(msgType bot.MessageType) bot.Message {
+		return bot.Message{
+			Type:   msgType,
+			Game:   bs.gameIndex,
+			Width:  board.Width,
+			Height: board.Height,
+			Mines:  mineCount,
+			State:  gameState,
+			Tiles:  bot.VisibleTiles(board, gameState),
+			Moves:  bs.moves,
+		}
+	}
+
+	// ==========================
+	// receive answer
+	// ==========================
+	select {
+	case answer := <-bs.answers:
+		if answer.Err != nil {
+			ErrLogger.Printf("bot: %v", answer.Err)
+			// bot is gone, stop sending it anything
+			if errors.Is(answer.Err, bot.ErrBotExited) {
+				bs.failed = true
+			}
+		}
+
+		if answer.Game != bs.gameIndex {
+			break
+		}
+		bs.waiting = false
+
+		if answer.Err != nil {
+			break
+		}
+
+		cmd := answer.Command
+		if !board.IsPosInBoard(cmd.X, cmd.Y) {
+			WarnLogger.Printf("bot: position %d, %d is out of board", cmd.X, cmd.Y)
+			break
+		}
+
+		input.BoardX, input.BoardY = cmd.X, cmd.Y
+
+		switch cmd.Action {
+		case bot.ActionStep:
+			input.Type = InputTypeStep
+		case bot.ActionFlag:
+			input.Type = InputTypeFlag
+		case bot.ActionChord:
+			input.Type = InputTypeCheck
+		}
+
+		bs.moves++
+		bs.lastMoveTime = time.Now()
+	default:
+	}
+
+	// ==========================
+	// send messages
+	// ==========================
+	if !bs.sentStart {
+		bs.sentStart = true
+		bs.requests <- botRequest{Message: newMessage(bot.MessageTypeStart)}
+	}
+
+	if gameState != engine.GameStatePlaying {
+		if !bs.sentEnd && !bs.waiting {
+			bs.sentEnd = true
+			bs.requests <- botRequest{Message: newMessage(bot.MessageTypeEnd)}
+		}
+		return input
+	}
+
+	// command we just received is not applied yet,
+	// so wait for next update before asking for another one
+	if input.Type == InputTypeNone && !bs.waiting && time.Since(bs.lastMoveTime) >= bs.MoveDelay {
+		bs.waiting = true
+		bs.requests <- botRequest{Message: newMessage(bot.MessageTypeBoard), WantCommand: true}
+	}
+
+	return input
+}
+
+// Close closes the bot process.
+func (bs *BotInputSource) Close() error {
+	close(bs.requests)
+	return bs.proc.Close()
+}
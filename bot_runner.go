@@ -0,0 +1,231 @@
+//go:build ignore
+
+// ====================================================
+// plays seeded games against a bot executable
+// and reports how well it did
+//
+// usage :
+// 	go run bot_runner.go [flags] -- <bot executable> [bot arguments]
+//
+// examples :
+// 	go run bot_runner.go -games 100 -- python3 my_bot.py
+// 	go run bot_runner.go -difficulty hard -seed <hex> -- ./my_bot
+// 	go run bot_runner.go -code MS-... -games 1 -record ./replays -- ./my_bot
+//
+// Same seed and same flags always give same set of boards,
+// so results of different bots can be compared.
+//
+// See "Bot protocol" section of README.md for the protocol.
+// ====================================================
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"minesweeper/bot"
+	"minesweeper/engine"
+)
+
+var (
+	FlagGames      int
+	FlagDifficulty string
+	FlagWidth      int
+	FlagHeight     int
+	FlagMines      int
+	FlagSeed       string
+	FlagCode       string
+	FlagFirstClick string
+	FlagTimeout    time.Duration
+	FlagReplayDir  string
+	FlagVerbose    bool
+)
+
+var BotArgs []string
+
+func init() {
+	// minesweeper package registers it's own flags on flag.CommandLine
+	// so we use our own flag set
+	flags := flag.NewFlagSet("bot_runner", flag.ExitOnError)
+
+	flags.IntVar(&FlagGames, "games", 100, "number of games to play")
+	flags.StringVar(&FlagDifficulty, "difficulty", "", "difficulty (easy, medium, hard), defaults to easy")
+	flags.IntVar(&FlagWidth, "width", 0, "board width, overrides difficulty")
+	flags.IntVar(&FlagHeight, "height", 0, "board height, overrides difficulty")
+	flags.IntVar(&FlagMines, "mines", 0, "mine count, overrides difficulty")
+	flags.StringVar(&FlagSeed, "seed", "", "seed that seeds of every game are derived from (64 hex digits)")
+	flags.StringVar(&FlagCode, "code", "", "play this game code for every game")
+	flags.StringVar(&FlagFirstClick, "first-click", "", "first click policy (safe-area, safe-tile), defaults to safe-area")
+	flags.DurationVar(&FlagTimeout, "timeout", time.Second*5, "time limit for each command, 0 for no limit")
+	flags.StringVar(&FlagReplayDir, "record", "", "save replays of games in this directory")
+	flags.BoolVar(&FlagVerbose, "v", false, "print result of every game")
+
+	flags.Parse(os.Args[1:])
+
+	BotArgs = flags.Args()
+}
+
+func main() {
+	if len(BotArgs) <= 0 {
+		fmt.Fprintf(os.Stderr, "usage: go run bot_runner.go [flags] -- <bot executable> [bot arguments]\n")
+		os.Exit(1)
+	}
+	if FlagGames <= 0 {
+		fmt.Fprintf(os.Stderr, "-games must be positive\n")
+		os.Exit(1)
+	}
+
+	baseCode, err := codeFromFlags()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("board : %dx%d, %d mines\n", baseCode.Width, baseCode.Height, baseCode.MineCount)
+	if FlagCode == "" {
+		fmt.Printf("seed  : %s\n", engine.SeedToString(baseCode.Seed))
+	}
+
+	var proc *bot.Process
+
+	defer func() {
+		if proc != nil {
+			proc.Close()
+		}
+	}()
+
+	opts := bot.PlayOptions{
+		MoveTimeout: FlagTimeout,
+	}
+
+	results := make([]bot.GameResult, 0, FlagGames)
+
+	for i := range FlagGames {
+		if proc == nil {
+			if proc, err = bot.StartProcess(BotArgs[0], BotArgs[1:]...); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		code := baseCode
+		if FlagCode == "" {
+			code.Seed = bot.SeedForGame(baseCode.Seed, i)
+		}
+
+		result, err := bot.Play(proc, i, code, opts)
+		if err != nil {
+			// bot crashed or timed out, start a new one for next game
+			proc.Close()
+			proc = nil
+		}
+
+		results = append(results, result)
+
+		if FlagVerbose || result.Err != nil {
+			printResult(i, result)
+		}
+
+		saveReplay(i, result.Replay)
+
+		// bot that crashes on the very first game is probably just broken
+		if errors.Is(err, bot.ErrBotExited) && i == 0 {
+			break
+		}
+	}
+
+	printSummary(results)
+}
+
+func codeFromFlags() (engine.GameCode, error) {
+	return engine.BoardOptions{
+		Code:       FlagCode,
+		Difficulty: FlagDifficulty,
+		Width:      FlagWidth,
+		Height:     FlagHeight,
+		Mines:      FlagMines,
+		Seed:       FlagSeed,
+		FirstClick: FlagFirstClick,
+	}.GameCode()
+}
+
+func saveReplay(index int, replay engine.Replay) {
+	if FlagReplayDir == "" {
+		return
+	}
+
+	replay.Rate()
+
+	// many games end in the same second
+	name := fmt.Sprintf("game-%04d-%s", index, engine.ReplayFileName(replay, time.Now()))
+	path := filepath.Join(FlagReplayDir, name)
+	if err := engine.SaveReplay(path, replay); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to save replay: %v\n", err)
+	}
+}
+
+func printResult(index int, result bot.GameResult) {
+	fmt.Printf(
+		"game %4d : %-5s %4d moves %10s think %10s  %s",
+		index, result.State, result.Moves,
+		result.Duration.Round(time.Microsecond), result.ThinkTime.Round(time.Microsecond),
+		result.Code.String(),
+	)
+	if result.Err != nil {
+		fmt.Printf("  (%v)", result.Err)
+	}
+	fmt.Printf("\n")
+}
+
+func printSummary(results []bot.GameResult) {
+	if len(results) <= 0 {
+		return
+	}
+
+	won := 0
+	errored := 0
+
+	var totalThink time.Duration
+	var totalMoves int
+
+	durations := make([]time.Duration, 0, len(results))
+
+	for _, r := range results {
+		if r.State == engine.GameStateWon {
+			won++
+		}
+		if r.Err != nil {
+			errored++
+		}
+		totalThink += r.ThinkTime
+		totalMoves += r.Moves
+		durations = append(durations, r.Duration)
+	}
+
+	slices.Sort(durations)
+
+	var totalDuration time.Duration
+	for _, d := range durations {
+		totalDuration += d
+	}
+
+	n := len(results)
+
+	fmt.Printf("\n")
+	fmt.Printf("games        : %d\n", n)
+	fmt.Printf("won          : %d (%.2f%%)\n", won, float64(won)/float64(n)*100)
+	fmt.Printf("bot errors   : %d\n", errored)
+	fmt.Printf("avg moves    : %.1f\n", float64(totalMoves)/float64(n))
+	fmt.Printf("avg time     : %s\n", (totalDuration / time.Duration(n)).Round(time.Microsecond))
+	fmt.Printf("median time  : %s\n", durations[n/2].Round(time.Microsecond))
+	fmt.Printf("max time     : %s\n", durations[n-1].Round(time.Microsecond))
+	if totalMoves > 0 {
+		fmt.Printf("avg per move : %s\n", (totalThink / time.Duration(totalMoves)).Round(time.Microsecond))
+	}
+}
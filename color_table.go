@@ -45,6 +45,8 @@ const (
 	ColorMineBg1
 	ColorMineBg2
 	ColorMine
+	// around mines hit with lives left
+	ColorMineHit
 
 	ColorBgHighLight
 	ColorTileHighLight
@@ -73,6 +75,35 @@ const (
 	ColorFlagTutorialFill
 	ColorFlagTutorialStroke
 
+	ColorPopupDim
+	ColorPopupBg
+	ColorPopupStroke
+	ColorPopupText
+	ColorPopupError
+
+	ColorCoopPlayer1
+	ColorCoopPlayer2
+	ColorCoopPlayer3
+	ColorCoopPlayer4
+	ColorCoopPlayer5
+	ColorCoopPlayer6
+	ColorCoopCursorStroke
+
+	ColorRaceBg
+	ColorRaceText
+	ColorRaceBarBg
+	ColorRaceBar
+	ColorRaceBarSelf
+	ColorRaceOut
+
+	ColorFlagsPlayer1
+	ColorFlagsPlayer2
+
+	// time attack
+	ColorTimeBonus
+	ColorTimePenalty
+	ColorTimeUp
+
 	ColorTableSize
 )
 
@@ -120,6 +151,7 @@ func init() {
 	setColor(ColorMineBg1, color.NRGBA{49, 7, 7, 255})
 	setColor(ColorMineBg2, color.NRGBA{229, 61, 61, 255})
 	setColor(ColorMine, color.NRGBA{255, 255, 255, 255})
+	setColor(ColorMineHit, color.NRGBA{255, 150, 30, 255})
 
 	setColor(ColorBgHighLight, color.NRGBA{255, 255, 255, 255})
 	setColor(ColorTileHighLight, color.NRGBA{255, 255, 255, 255})
@@ -148,6 +180,34 @@ func init() {
 	setColor(ColorFlagTutorialFill, color.NRGBA{0, 0, 0, 0xFF})
 	setColor(ColorFlagTutorialStroke, color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF})
 
+	setColor(ColorPopupDim, color.NRGBA{0, 0, 0, 150})
+	setColor(ColorPopupBg, color.NRGBA{40, 40, 40, 255})
+	setColor(ColorPopupStroke, color.NRGBA{188, 188, 188, 255})
+	setColor(ColorPopupText, color.NRGBA{255, 255, 255, 255})
+	setColor(ColorPopupError, color.NRGBA{255, 110, 110, 255})
+
+	setColor(ColorCoopPlayer1, color.NRGBA{0xFF, 0x5C, 0x5C, 0xFF})
+	setColor(ColorCoopPlayer2, color.NRGBA{0x4F, 0x9D, 0xFF, 0xFF})
+	setColor(ColorCoopPlayer3, color.NRGBA{0x5C, 0xE0, 0x6E, 0xFF})
+	setColor(ColorCoopPlayer4, color.NRGBA{0xFF, 0xC8, 0x3D, 0xFF})
+	setColor(ColorCoopPlayer5, color.NRGBA{0xC0, 0x6C, 0xFF, 0xFF})
+	setColor(ColorCoopPlayer6, color.NRGBA{0x3D, 0xE8, 0xE0, 0xFF})
+	setColor(ColorCoopCursorStroke, color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF})
+
+	setColor(ColorRaceBg, color.NRGBA{30, 30, 30, 255})
+	setColor(ColorRaceText, color.NRGBA{255, 255, 255, 255})
+	setColor(ColorRaceBarBg, color.NRGBA{60, 60, 60, 255})
+	setColor(ColorRaceBar, color.NRGBA{0x4F, 0x9D, 0xFF, 0xFF})
+	setColor(ColorRaceBarSelf, color.NRGBA{0x5C, 0xE0, 0x6E, 0xFF})
+	setColor(ColorRaceOut, color.NRGBA{255, 110, 110, 255})
+
+	setColor(ColorFlagsPlayer1, color.NRGBA{0x4F, 0x9D, 0xFF, 0xFF})
+	setColor(ColorFlagsPlayer2, color.NRGBA{0xFF, 0x5C, 0x5C, 0xFF})
+
+	setColor(ColorTimeBonus, color.NRGBA{0x5C, 0xE0, 0x6E, 0xFF})
+	setColor(ColorTimePenalty, color.NRGBA{0xFF, 0x5C, 0x5C, 0xFF})
+	setColor(ColorTimeUp, color.NRGBA{110, 150, 210, 255})
+
 	for i := ColorTableIndex(0); i < ColorTableSize; i++ {
 		if !colorSet[i] {
 			ErrLogger.Fatalf("color for %s has no default value", i.String())
@@ -161,6 +221,17 @@ func ColorTableGetNumber(i int) ColorTableIndex {
 	return ColorNumber1 + ColorTableIndex(i-1)
 }
 
+// i starts from 0, wraps around
+func ColorTableGetCoopPlayer(i int) ColorTableIndex {
+	const count = int(ColorCoopPlayer6-ColorCoopPlayer1) + 1
+	return ColorCoopPlayer1 + ColorTableIndex(((i%count)+count)%count)
+}
+
+// i is the player index of engine.FlagsMatch
+func ColorTableGetFlagsPlayer(i int) ColorTableIndex {
+	return ColorFlagsPlayer1 + ColorTableIndex(i)
+}
+
 func ColorTableToJson(table [ColorTableSize]color.NRGBA) ([]byte, error) {
 	tableMap := make(map[string]color.NRGBA)
 
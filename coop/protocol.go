@@ -0,0 +1,188 @@
+// Package coop lets several players clear the same board together
+// over a websocket connection.
+//
+// Server owns the board. Clients send interactions and cursor positions,
+// server applies interactions and sends the whole board back to everyone.
+//
+// Players are on the same team, so unlike package server,
+// mines are not hidden from clients (game needs them to draw numbers).
+package coop
+
+import (
+	"fmt"
+	"strings"
+
+	"minesweeper/engine"
+)
+
+type MessageType int
+
+const (
+	// client -> server
+	MessageTypeInteract MessageType = iota
+	MessageTypeCursor
+	MessageTypeReset
+
+	// server -> client
+	MessageTypeWelcome
+	MessageTypeBoard
+	MessageTypeCursors
+
+	MessageTypeSize
+)
+
+var MessageTypeStrs = [MessageTypeSize]string{
+	"interact",
+	"cursor",
+	"reset",
+
+	"welcome",
+	"board",
+	"cursors",
+}
+
+func (mt MessageType) String() string {
+	if mt < 0 || mt >= MessageTypeSize {
+		return fmt.Sprintf("MessageType(%d)", int(mt))
+	}
+	return MessageTypeStrs[mt]
+}
+
+func (mt MessageType) MarshalText() ([]byte, error) {
+	if mt < 0 || mt >= MessageTypeSize {
+		return nil, fmt.Errorf("invalid message type %d", int(mt))
+	}
+	return []byte(MessageTypeStrs[mt]), nil
+}
+
+func (mt *MessageType) UnmarshalText(text []byte) error {
+	for i := MessageType(0); i < MessageTypeSize; i++ {
+		if string(text) == MessageTypeStrs[i] {
+			*mt = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown message type %q", string(text))
+}
+
+// Message is used for both directions, fields that are used depend on Type
+//
+//	interact : Interaction, X, Y
+//	cursor   : CursorX, CursorY
+//	reset    : Round (round client wants to replace), Code (board client wants, seed is ignored)
+//	welcome  : Player, Color
+//	board    : Round, Code, State, Board, and Player, Interaction, X, Y that caused it
+//	cursors  : Cursors
+type Message struct {
+	Type MessageType `json:"type"`
+
+	Player int `json:"player,omitempty"`
+	Color  int `json:"color,omitempty"`
+
+	// increases every time board is reset
+	Round int `json:"round,omitempty"`
+
+	Interaction engine.BoardInteractionType `json:"interaction,omitempty"`
+	X           int                         `json:"x,omitempty"`
+	Y           int                         `json:"y,omitempty"`
+
+	// in tile units, 0, 0 is top left corner of the board
+	CursorX float64 `json:"cx,omitempty"`
+	CursorY float64 `json:"cy,omitempty"`
+
+	Code  string           `json:"code,omitempty"`
+	State engine.GameState `json:"state,omitempty"`
+	Board *BoardData       `json:"board,omitempty"`
+
+	Cursors []Cursor `json:"cursors,omitempty"`
+}
+
+type Cursor struct {
+	Player int     `json:"player"`
+	Color  int     `json:"color"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+}
+
+// number of distinct player colors
+const PlayerColorCount = 6
+
+// ==============================================
+// board encoding
+// ==============================================
+
+// BoardData is a Board with each layer as a string of '0' and '1',
+// row by row from top left
+type BoardData struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	Mines    string `json:"mines"`
+	Revealed string `json:"revealed"`
+	Flags    string `json:"flags"`
+}
+
+func encodeLayer(arr engine.Array2D[bool]) string {
+	sb := &strings.Builder{}
+	sb.Grow(len(arr.Data))
+
+	for _, b := range arr.Data {
+		if b {
+			sb.WriteByte('1')
+		} else {
+			sb.WriteByte('0')
+		}
+	}
+
+	return sb.String()
+}
+
+func decodeLayer(str string, arr engine.Array2D[bool]) error {
+	if len(str) != len(arr.Data) {
+		return fmt.Errorf("layer has %d tiles, expected %d", len(str), len(arr.Data))
+	}
+
+	for i := range len(str) {
+		switch str[i] {
+		case '0':
+			arr.Data[i] = false
+		case '1':
+			arr.Data[i] = true
+		default:
+			return fmt.Errorf("invalid character %q in layer", str[i])
+		}
+	}
+
+	return nil
+}
+
+func EncodeBoard(board engine.Board) *BoardData {
+	return &BoardData{
+		Width:    board.Width,
+		Height:   board.Height,
+		Mines:    encodeLayer(board.Mines),
+		Revealed: encodeLayer(board.Revealed),
+		Flags:    encodeLayer(board.Flags),
+	}
+}
+
+func (bd *BoardData) Decode() (engine.Board, error) {
+	if bd.Width <= 0 || bd.Height <= 0 ||
+		bd.Width > engine.GameCodeMaxBoardSize || bd.Height > engine.GameCodeMaxBoardSize {
+		return engine.Board{}, fmt.Errorf("invalid board size %dx%d", bd.Width, bd.Height)
+	}
+
+	board := engine.NewBoard(bd.Width, bd.Height)
+
+	if err := decodeLayer(bd.Mines, board.Mines); err != nil {
+		return board, err
+	}
+	if err := decodeLayer(bd.Revealed, board.Revealed); err != nil {
+		return board, err
+	}
+	if err := decodeLayer(bd.Flags, board.Flags); err != nil {
+		return board, err
+	}
+
+	return board, nil
+}
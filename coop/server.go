@@ -0,0 +1,322 @@
+package coop
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"minesweeper/engine"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+const (
+	// messages from clients are small
+	maxMessageSize = 1 << 12
+
+	// player whose send queue fills up is disconnected
+	sendQueueSize = 256
+	writeTimeout  = time.Second * 10
+)
+
+// ==============================================
+// room
+// ==============================================
+
+type player struct {
+	id    int
+	color int
+
+	send chan Message
+	// closes the connection
+	kick func()
+
+	cursorX, cursorY float64
+	hasCursor        bool
+}
+
+// Room is a board shared by players connected to it
+type Room struct {
+	mu sync.Mutex
+
+	code  engine.GameCode
+	board engine.Board
+	state engine.GameState
+	round int
+
+	players map[int]*player
+	nextID  int
+}
+
+func newRoom(code engine.GameCode) *Room {
+	r := &Room{
+		players: make(map[int]*player),
+		nextID:  1,
+	}
+	r.resetLocked(code)
+	return r
+}
+
+func (r *Room) resetLocked(code engine.GameCode) {
+	r.code = code
+	r.board = engine.NewBoard(code.Width, code.Height)
+	r.state = engine.GameStatePlaying
+	r.round++
+}
+
+func (r *Room) boardMessageLocked() Message {
+	return Message{
+		Type:  MessageTypeBoard,
+		Round: r.round,
+		Code:  r.code.String(),
+		State: r.state,
+		Board: EncodeBoard(r.board),
+	}
+}
+
+func (r *Room) cursorsMessageLocked() Message {
+	msg := Message{Type: MessageTypeCursors}
+	for _, p := range r.players {
+		if p.hasCursor {
+			msg.Cursors = append(msg.Cursors, Cursor{
+				Player: p.id, Color: p.color,
+				X: p.cursorX, Y: p.cursorY,
+			})
+		}
+	}
+	// empty list still has to reach the clients
+	if msg.Cursors == nil {
+		msg.Cursors = []Cursor{}
+	}
+	return msg
+}
+
+func (r *Room) broadcastLocked(msg Message) {
+	for _, p := range r.players {
+		select {
+		case p.send <- msg:
+		default:
+			// too slow to keep up, client will have to reconnect
+			p.kick()
+		}
+	}
+}
+
+func (r *Room) join(kick func()) *player {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// pick a color nobody is using
+	var used [PlayerColorCount]bool
+	for _, p := range r.players {
+		used[p.color%PlayerColorCount] = true
+	}
+	color := len(r.players) % PlayerColorCount
+	for i := range PlayerColorCount {
+		if !used[i] {
+			color = i
+			break
+		}
+	}
+
+	p := &player{
+		id:    r.nextID,
+		color: color,
+		send:  make(chan Message, sendQueueSize),
+		kick:  kick,
+	}
+	r.nextID++
+	r.players[p.id] = p
+
+	p.send <- Message{Type: MessageTypeWelcome, Player: p.id, Color: p.color}
+	p.send <- r.boardMessageLocked()
+	p.send <- r.cursorsMessageLocked()
+
+	return p
+}
+
+// returns true if room is empty after player left
+func (r *Room) leave(p *player) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.players, p.id)
+	r.broadcastLocked(r.cursorsMessageLocked())
+
+	return len(r.players) <= 0
+}
+
+func (r *Room) handle(p *player, msg Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch msg.Type {
+	case MessageTypeInteract:
+		if r.state != engine.GameStatePlaying {
+			return
+		}
+		if msg.Interaction <= engine.InteractionTypeNone || msg.Interaction >= engine.InteractionTypeSize {
+			return
+		}
+		if !r.board.IsPosInBoard(msg.X, msg.Y) {
+			return
+		}
+
+		prevBoard := r.board.Copy()
+		prevState := r.state
+
+		r.state = r.board.InteractWithCode(msg.X, msg.Y, msg.Interaction, r.state, r.code, nil)
+
+		// don't send the whole board if nothing happened
+		if r.state == prevState &&
+			slices.Equal(r.board.Revealed.Data, prevBoard.Revealed.Data) &&
+			slices.Equal(r.board.Flags.Data, prevBoard.Flags.Data) &&
+			slices.Equal(r.board.Mines.Data, prevBoard.Mines.Data) {
+			return
+		}
+
+		boardMsg := r.boardMessageLocked()
+		boardMsg.Player = p.id
+		boardMsg.Interaction = msg.Interaction
+		boardMsg.X, boardMsg.Y = msg.X, msg.Y
+
+		r.broadcastLocked(boardMsg)
+
+	case MessageTypeCursor:
+		p.cursorX, p.cursorY = msg.CursorX, msg.CursorY
+		p.hasCursor = true
+
+		r.broadcastLocked(r.cursorsMessageLocked())
+
+	case MessageTypeReset:
+		// when several players press retry at the same time,
+		// only the first one resets the board
+		if msg.Round != r.round {
+			return
+		}
+
+		code, err := engine.ParseGameCode(msg.Code)
+		if err != nil {
+			return
+		}
+		// players take turns in flags, it doesn't work with everyone clicking at once
+		if code.Variants&engine.GameVariantFlags != 0 {
+			return
+		}
+		// seed is always picked by the server, so that nobody can pick a board they know
+		rand.Read(code.Seed[:])
+
+		r.resetLocked(code)
+		r.broadcastLocked(r.boardMessageLocked())
+	}
+}
+
+// ==============================================
+// server
+// ==============================================
+
+type Server struct {
+	// board of a newly created room
+	NewRoomCode func() engine.GameCode
+
+	// origins other than the server's own that may connect,
+	// see websocket.AcceptOptions.OriginPatterns
+	OriginPatterns []string
+
+	mu    sync.Mutex
+	rooms map[string]*Room
+}
+
+func NewServer() *Server {
+	return &Server{
+		NewRoomCode: func() engine.GameCode {
+			code := engine.GameCode{
+				Width:     engine.DifficultyBoardSizesNormal[engine.DifficultyEasy].X,
+				Height:    engine.DifficultyBoardSizesNormal[engine.DifficultyEasy].Y,
+				MineCount: engine.DifficultyMineCounts[engine.DifficultyEasy],
+			}
+			rand.Read(code.Seed[:])
+			return code
+		},
+		rooms: make(map[string]*Room),
+	}
+}
+
+func (s *Server) joinRoom(name string, kick func()) (*Room, *player) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.rooms[name]
+	if !ok {
+		room = newRoom(s.NewRoomCode())
+		s.rooms[name] = room
+	}
+
+	return room, room.join(kick)
+}
+
+func (s *Server) leaveRoom(name string, room *Room, p *player) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if room.leave(p) && s.rooms[name] == room {
+		delete(s.rooms, name)
+	}
+}
+
+// ServeHTTP accepts a websocket connection and joins the room
+// given by "room" query parameter.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: s.OriginPatterns,
+	})
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+
+	conn.SetReadLimit(maxMessageSize)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	roomName := r.URL.Query().Get("room")
+
+	room, p := s.joinRoom(roomName, cancel)
+	defer s.leaveRoom(roomName, room, p)
+
+	// writer
+	go func() {
+		for {
+			select {
+			case msg := <-p.send:
+				writeCtx, writeCancel := context.WithTimeout(ctx, writeTimeout)
+				err := wsjson.Write(writeCtx, conn, msg)
+				writeCancel()
+				if err != nil {
+					cancel()
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// reader
+	for {
+		var msg Message
+		if err := wsjson.Read(ctx, conn, &msg); err != nil {
+			if websocket.CloseStatus(err) != websocket.StatusNormalClosure && !errors.Is(err, context.Canceled) {
+				conn.Close(websocket.StatusPolicyViolation, "bad message")
+			}
+			return
+		}
+		room.handle(p, msg)
+	}
+}
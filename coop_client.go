@@ -0,0 +1,202 @@
+package minesweeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"minesweeper/coop"
+	"minesweeper/engine"
+)
+
+const (
+	// how often cursor position is sent at most
+	coopCursorInterval = time.Millisecond * 50
+)
+
+type coopUpdate struct {
+	Round  int
+	Update RemoteUpdate
+}
+
+// CoopClient is a GameRemote that plays on a board of a coop.Server.
+//
+// Connection is handled in separate goroutines,
+// game only talks to it through channels.
+type CoopClient struct {
+	URL string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	sends   chan coop.Message
+	updates chan coopUpdate
+
+	// accessed only by game
+	round          int
+	cursorPending  bool
+	cursorX        float64
+	cursorY        float64
+	lastCursorSend time.Time
+
+	mu       sync.Mutex
+	playerID int
+	cursors  []RemoteCursor
+}
+
+// NewCoopClient connects to url in background.
+//
+// url is a websocket url of a coop.Server (for example "ws://localhost:6970/coop?room=lunch").
+func NewCoopClient(url string) *CoopClient {
+	c := new(CoopClient)
+
+	c.URL = url
+
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	c.sends = make(chan coop.Message, 64)
+	c.updates = make(chan coopUpdate, 256)
+
+	go runWebsocket(c.ctx, c.cancel, "coop", url, c.sends, c.handle)
+
+	return c
+}
+
+func (c *CoopClient) handle(msg coop.Message) error {
+	switch msg.Type {
+	case coop.MessageTypeWelcome:
+		c.mu.Lock()
+		c.playerID = msg.Player
+		c.mu.Unlock()
+
+	case coop.MessageTypeBoard:
+		if msg.Board == nil {
+			return errors.New("board message without a board")
+		}
+
+		code, err := engine.ParseGameCode(msg.Code)
+		if err != nil {
+			return err
+		}
+		board, err := msg.Board.Decode()
+		if err != nil {
+			return err
+		}
+		if board.Width != code.Width || board.Height != code.Height {
+			return fmt.Errorf(
+				"board is %dx%d but game code is %dx%d",
+				board.Width, board.Height, code.Width, code.Height,
+			)
+		}
+
+		update := coopUpdate{
+			Round: msg.Round,
+			Update: RemoteUpdate{
+				Code:        code,
+				Board:       board,
+				GameState:   msg.State,
+				Interaction: msg.Interaction,
+				X:           msg.X,
+				Y:           msg.Y,
+			},
+		}
+
+		select {
+		case c.updates <- update:
+		case <-c.ctx.Done():
+		}
+
+	case coop.MessageTypeCursors:
+		c.mu.Lock()
+		c.cursors = c.cursors[:0]
+		for _, cursor := range msg.Cursors {
+			if cursor.Player == c.playerID {
+				continue
+			}
+			c.cursors = append(c.cursors, RemoteCursor{
+				X: cursor.X, Y: cursor.Y,
+				Color: ColorTableGetCoopPlayer(cursor.Color),
+			})
+		}
+		c.mu.Unlock()
+	}
+
+	return nil
+}
+
+func (c *CoopClient) send(msg coop.Message) {
+	select {
+	case c.sends <- msg:
+	default:
+		WarnLogger.Printf("coop: dropping %s message, not connected", msg.Type)
+	}
+}
+
+func (c *CoopClient) SendInteraction(interaction engine.BoardInteractionType, x, y int) {
+	c.send(coop.Message{
+		Type:        coop.MessageTypeInteract,
+		Interaction: interaction,
+		X:           x,
+		Y:           y,
+	})
+}
+
+func (c *CoopClient) SendCursor(x, y float64) {
+	c.cursorX, c.cursorY = x, y
+	c.cursorPending = true
+	c.flushCursor()
+}
+
+func (c *CoopClient) flushCursor() {
+	if !c.cursorPending || time.Since(c.lastCursorSend) < coopCursorInterval {
+		return
+	}
+
+	// cursor is not that important, just drop it when queue is full
+	select {
+	case c.sends <- coop.Message{Type: coop.MessageTypeCursor, CursorX: c.cursorX, CursorY: c.cursorY}:
+	default:
+	}
+
+	c.cursorPending = false
+	c.lastCursorSend = time.Now()
+}
+
+func (c *CoopClient) RequestReset(code engine.GameCode) {
+	c.send(coop.Message{
+		Type:  coop.MessageTypeReset,
+		Round: c.round,
+		Code:  code.String(),
+	})
+}
+
+func (c *CoopClient) PollUpdate() (RemoteUpdate, bool) {
+	// called every frame, good time to send throttled cursor
+	c.flushCursor()
+
+	select {
+	case u := <-c.updates:
+		u.Update.NewBoard = u.Round != c.round
+		c.round = u.Round
+		return u.Update, true
+	default:
+		return RemoteUpdate{}, false
+	}
+}
+
+func (c *CoopClient) Cursors() []RemoteCursor {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cursors := make([]RemoteCursor, len(c.cursors))
+	copy(cursors, c.cursors)
+
+	return cursors
+}
+
+// Close disconnects from the server.
+func (c *CoopClient) Close() {
+	c.cancel()
+}
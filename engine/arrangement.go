@@ -0,0 +1,599 @@
+package engine
+
+import (
+	"math/rand/v2"
+	"slices"
+)
+
+// ==============================================
+// lazy mine assignment
+// ==============================================
+//
+// Forgiving and evil mode treat mines as not really placed.
+// Any arrangement of mines that agrees with what player can see
+// (revealed numbers and mine count) is as good as the one on the board,
+// and mines are moved to another one when that decides a guess.
+//
+// arrangements describes all of those arrangements without listing them.
+// Hidden tiles next to numbers are split into components that share no number,
+// and each component is walked one tile at a time, remembering only
+// how many mines the numbers that are partly walked have so far.
+// So the work grows with how wide a component is, not with how many arrangements it has.
+// Hidden tiles away from numbers only matter by how many mines they have.
+//
+// A component that has more than arrangementMaxStates of those partial states
+// at one tile is left unresolved. Its tiles count as maybe a mine and maybe safe,
+// and mines are never moved into or out of it.
+// It takes a long front of numbers with many loose ends at once,
+// random boards up to 30x30 don't come close.
+
+const arrangementMaxStates = 1 << 14
+
+type arrangements struct {
+	components []*arrangementComponent
+
+	// component of every tile, -1 for tiles that are not next to a number
+	componentOf []int
+	// position of every tile in its component's walk
+	positionOf []int
+
+	// hidden tiles that are not next to any number
+	interior []int
+
+	// mines on hidden tiles
+	total int
+}
+
+// hidden tiles that share numbers, see arrangements
+type arrangementComponent struct {
+	// tiles in the order they are walked
+	tiles []int
+
+	constraints []constraint
+
+	// for tile at each position, constraints it's in
+	// and how many tiles of that constraint come after it
+	tileConstraints [][]int
+	tileRemaining   [][]int
+
+	// position of the first tile of every constraint
+	first []int
+
+	// constraints that are partly walked before each position,
+	// state before a position is mine count of each of them
+	open [][]int
+
+	// forward[i] maps state before position i to mine counts tiles before it can have,
+	// backward[i] maps it to mine counts tiles from it to the end can have
+	forward  []map[string][]bool
+	backward []map[string][]bool
+
+	// false if walk had too many states, see arrangementMaxStates
+	resolved bool
+
+	// position of the tile that walk was forced to be mine or not, -1 if none
+	force     int
+	forceMine bool
+
+	// mines in it on the board
+	current int
+
+	scratch []int
+}
+
+func (board *Board) arrangements() *arrangements {
+	a := &arrangements{
+		componentOf: make([]int, len(board.Mines.Data)),
+		positionOf:  make([]int, len(board.Mines.Data)),
+	}
+
+	constraints := board.visibleConstraints()
+
+	// ==========================
+	// split into components
+	// ==========================
+	parent := make([]int, len(board.Mines.Data))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(t int) int
+	find = func(t int) int {
+		if parent[t] != t {
+			parent[t] = find(parent[t])
+		}
+		return parent[t]
+	}
+	for _, c := range constraints {
+		for _, t := range c.tiles[1:] {
+			parent[find(t)] = find(c.tiles[0])
+		}
+	}
+
+	byTile := make(map[int][]int)
+	for ci, c := range constraints {
+		for _, t := range c.tiles {
+			byTile[t] = append(byTile[t], ci)
+		}
+	}
+
+	for i := range a.componentOf {
+		a.componentOf[i] = -1
+	}
+
+	for i := range board.Mines.Data {
+		if board.Revealed.Data[i] {
+			continue
+		}
+		if board.Mines.Data[i] {
+			a.total++
+		}
+		if _, ok := byTile[i]; !ok {
+			a.interior = append(a.interior, i)
+			continue
+		}
+		if a.componentOf[i] >= 0 {
+			continue
+		}
+
+		// tiles are visited in index order,
+		// so i is the first tile of a new component
+		root := find(i)
+		ac := new(arrangementComponent)
+
+		// walk in breadth first order so that only a few numbers are open at a time
+		a.componentOf[i] = len(a.components)
+		queue := []int{i}
+		for len(queue) > 0 {
+			t := queue[0]
+			queue = queue[1:]
+
+			a.positionOf[t] = len(ac.tiles)
+			ac.tiles = append(ac.tiles, t)
+
+			for _, ci := range byTile[t] {
+				for _, nt := range constraints[ci].tiles {
+					if a.componentOf[nt] < 0 {
+						a.componentOf[nt] = len(a.components)
+						queue = append(queue, nt)
+					}
+				}
+			}
+		}
+
+		for ci, c := range constraints {
+			if find(c.tiles[0]) == root {
+				ac.constraints = append(ac.constraints, constraints[ci])
+			}
+		}
+
+		for _, t := range ac.tiles {
+			if board.Mines.Data[t] {
+				ac.current++
+			}
+		}
+
+		ac.init(a.positionOf)
+		ac.resolved = ac.walk(-1, false)
+
+		a.components = append(a.components, ac)
+	}
+
+	return a
+}
+
+func (ac *arrangementComponent) init(positionOf []int) {
+	n := len(ac.tiles)
+
+	ac.tileConstraints = make([][]int, n)
+	ac.tileRemaining = make([][]int, n)
+	ac.first = make([]int, len(ac.constraints))
+	ac.open = make([][]int, n+1)
+	ac.scratch = make([]int, len(ac.constraints))
+
+	last := make([]int, len(ac.constraints))
+
+	for ci, c := range ac.constraints {
+		positions := make([]int, len(c.tiles))
+		for j, t := range c.tiles {
+			positions[j] = positionOf[t]
+		}
+		slices.Sort(positions)
+
+		ac.first[ci] = positions[0]
+		last[ci] = positions[len(positions)-1]
+
+		for j, p := range positions {
+			ac.tileConstraints[p] = append(ac.tileConstraints[p], ci)
+			ac.tileRemaining[p] = append(ac.tileRemaining[p], len(positions)-1-j)
+		}
+	}
+
+	for i := range n + 1 {
+		for ci := range ac.constraints {
+			if ac.first[ci] < i && i <= last[ci] {
+				ac.open[i] = append(ac.open[i], ci)
+			}
+		}
+	}
+}
+
+// next returns state after tile at position i is set to mine (1) or not (0),
+// false if no arrangement can have it
+func (ac *arrangementComponent) next(i int, state string, mine int) (string, bool) {
+	counts := ac.scratch
+
+	for j, ci := range ac.open[i] {
+		counts[ci] = int(state[j])
+	}
+
+	for j, ci := range ac.tileConstraints[i] {
+		if ac.first[ci] == i {
+			counts[ci] = 0
+		}
+		counts[ci] += mine
+
+		c := ac.constraints[ci]
+		if counts[ci] > c.count || counts[ci]+ac.tileRemaining[i][j] < c.count {
+			return "", false
+		}
+	}
+
+	next := make([]byte, len(ac.open[i+1]))
+	for j, ci := range ac.open[i+1] {
+		next[j] = byte(counts[ci])
+	}
+
+	return string(next), true
+}
+
+// values tile at position i can be set to
+func (ac *arrangementComponent) values(i int) []int {
+	if i == ac.force {
+		if ac.forceMine {
+			return []int{1}
+		}
+		return []int{0}
+	}
+	return []int{0, 1}
+}
+
+// walk fills forward and backward, with tile at position force
+// set to forceMine if force is not -1.
+// Returns false if there were too many states
+func (ac *arrangementComponent) walk(force int, forceMine bool) bool {
+	n := len(ac.tiles)
+
+	ac.force, ac.forceMine = force, forceMine
+
+	ac.forward = make([]map[string][]bool, n+1)
+	ac.forward[0] = map[string][]bool{"": {true}}
+
+	for i := range n {
+		ac.forward[i+1] = make(map[string][]bool)
+		for state, counts := range ac.forward[i] {
+			for _, mine := range ac.values(i) {
+				if next, ok := ac.next(i, state, mine); ok {
+					ac.forward[i+1][next] = orShifted(ac.forward[i+1][next], counts, mine)
+				}
+			}
+		}
+		if len(ac.forward[i+1]) > arrangementMaxStates {
+			ac.forward, ac.backward = nil, nil
+			return false
+		}
+	}
+
+	ac.backward = make([]map[string][]bool, n+1)
+	ac.backward[n] = map[string][]bool{"": {true}}
+
+	for i := n - 1; i >= 0; i-- {
+		ac.backward[i] = make(map[string][]bool)
+		for state := range ac.forward[i] {
+			for _, mine := range ac.values(i) {
+				next, ok := ac.next(i, state, mine)
+				if !ok {
+					continue
+				}
+				if counts, ok := ac.backward[i+1][next]; ok {
+					ac.backward[i][state] = orShifted(ac.backward[i][state], counts, mine)
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+// mine counts the component can have
+func (ac *arrangementComponent) counts() []bool {
+	if !ac.resolved {
+		counts := make([]bool, len(ac.tiles)+1)
+		for i := range counts {
+			counts[i] = true
+		}
+		return counts
+	}
+	return ac.backward[0][""]
+}
+
+// mine counts the component can have with tile at position i being mine or not
+func (ac *arrangementComponent) countsWith(i int, mine int) []bool {
+	var counts []bool
+
+	for state, before := range ac.forward[i] {
+		next, ok := ac.next(i, state, mine)
+		if !ok {
+			continue
+		}
+		after, ok := ac.backward[i+1][next]
+		if !ok {
+			continue
+		}
+		for b, okB := range before {
+			if okB {
+				counts = orShifted(counts, after, b+mine)
+			}
+		}
+	}
+
+	return counts
+}
+
+// assign sets mines of the component to an arrangement with count mines,
+// keeping tiles as they are on the board where it can
+func (ac *arrangementComponent) assign(mines []bool, count int) {
+	state := ""
+	sofar := 0
+
+	for i, t := range ac.tiles {
+		tries := ac.values(i)
+		if len(tries) > 1 && mines[t] {
+			tries = []int{1, 0}
+		}
+
+		for _, mine := range tries {
+			next, ok := ac.next(i, state, mine)
+			if !ok {
+				continue
+			}
+			after, ok := ac.backward[i+1][next]
+			need := count - sofar - mine
+			if !ok || need < 0 || need >= len(after) || !after[need] {
+				continue
+			}
+
+			mines[t] = mine == 1
+			state = next
+			sofar += mine
+			break
+		}
+	}
+}
+
+// possible returns for every tile whether some arrangement has a mine on it
+// and whether some arrangement doesn't. Revealed tiles are neither
+func (a *arrangements) possible(board *Board) ([]bool, []bool) {
+	canMine := make([]bool, len(board.Mines.Data))
+	canSafe := make([]bool, len(board.Mines.Data))
+
+	n := len(a.components)
+	interior := len(a.interior)
+
+	// mine counts of components before and after each one
+	prefix := make([][]bool, n+1)
+	suffix := make([][]bool, n+1)
+	prefix[0] = []bool{true}
+	suffix[n] = []bool{true}
+	for c := range n {
+		prefix[c+1] = sumCounts(prefix[c], a.components[c].counts())
+	}
+	for c := n - 1; c >= 0; c-- {
+		suffix[c] = sumCounts(suffix[c+1], a.components[c].counts())
+	}
+
+	interiorCounts := make([]bool, interior+1)
+	for i := range interiorCounts {
+		interiorCounts[i] = true
+	}
+
+	for c, ac := range a.components {
+		if !ac.resolved {
+			for _, t := range ac.tiles {
+				canMine[t] = true
+				canSafe[t] = true
+			}
+			continue
+		}
+
+		others := sumCounts(sumCounts(prefix[c], suffix[c+1]), interiorCounts)
+		fits := func(counts []bool) bool {
+			for k, ok := range counts {
+				if ok && a.total-k >= 0 && a.total-k < len(others) && others[a.total-k] {
+					return true
+				}
+			}
+			return false
+		}
+
+		for i, t := range ac.tiles {
+			canMine[t] = fits(ac.countsWith(i, 1))
+			canSafe[t] = fits(ac.countsWith(i, 0))
+		}
+	}
+
+	for s, ok := range prefix[n] {
+		if !ok {
+			continue
+		}
+		left := a.total - s
+		for _, t := range a.interior {
+			canMine[t] = canMine[t] || (1 <= left && left <= interior)
+			canSafe[t] = canSafe[t] || (0 <= left && left <= interior-1)
+		}
+	}
+
+	return canMine, canSafe
+}
+
+func (a *arrangements) resolved() bool {
+	for _, ac := range a.components {
+		if !ac.resolved {
+			return false
+		}
+	}
+	return true
+}
+
+func (board *Board) relocateMines(targetX, targetY int, wantMine bool, seed [32]byte) bool {
+	target := targetX + targetY*board.Width
+	if board.Revealed.Data[target] {
+		return false
+	}
+	if board.Mines.Data[target] == wantMine {
+		return true
+	}
+
+	a := board.arrangements()
+
+	targetComponent := a.componentOf[target]
+	if targetComponent >= 0 {
+		ac := a.components[targetComponent]
+		if !ac.resolved || !ac.walk(a.positionOf[target], wantMine) {
+			return false
+		}
+	}
+
+	// target is not next to any number, it just takes one of the mines
+	interior := a.interior
+	total := a.total
+	if targetComponent < 0 {
+		interior = slices.DeleteFunc(slices.Clone(interior), func(t int) bool { return t == target })
+		if wantMine {
+			total--
+		}
+	}
+
+	// ==========================
+	// mine count of each component
+	// ==========================
+	n := len(a.components)
+
+	allowed := make([][]bool, n)
+	for c, ac := range a.components {
+		if ac.resolved {
+			allowed[c] = ac.backward[0][""]
+		} else {
+			// unresolved components keep their mines
+			allowed[c] = make([]bool, ac.current+1)
+			allowed[c][ac.current] = true
+		}
+	}
+
+	suffix := make([][]bool, n+1)
+	suffix[n] = []bool{true}
+	for c := n - 1; c >= 0; c-- {
+		suffix[c] = sumCounts(suffix[c+1], allowed[c])
+	}
+
+	// if components from c on can take left mines, with the rest in interior
+	canTake := func(c int, left int) bool {
+		for s, ok := range suffix[c] {
+			if ok && left-s >= 0 && left-s <= len(interior) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !canTake(0, total) {
+		return false
+	}
+
+	chosen := make([]int, n)
+	left := total
+
+	for c, ac := range a.components {
+		best := -1
+		for k, ok := range allowed[c] {
+			if !ok || !canTake(c+1, left-k) {
+				continue
+			}
+			// move as few mines as possible
+			if best < 0 || abs(k-ac.current) < abs(best-ac.current) {
+				best = k
+			}
+		}
+		chosen[c] = best
+		left -= best
+	}
+
+	// ==========================
+	// apply
+	// ==========================
+	for c, ac := range a.components {
+		if c == targetComponent || (ac.resolved && chosen[c] != ac.current) {
+			ac.assign(board.Mines.Data, chosen[c])
+		}
+	}
+
+	board.Mines.Data[target] = wantMine
+
+	var withMine, withoutMine []int
+	for _, t := range interior {
+		if board.Mines.Data[t] {
+			withMine = append(withMine, t)
+		} else {
+			withoutMine = append(withoutMine, t)
+		}
+	}
+
+	rng := rand.New(rand.NewChaCha8(seed))
+
+	if len(withMine) > left {
+		rng.Shuffle(len(withMine), func(i, j int) {
+			withMine[i], withMine[j] = withMine[j], withMine[i]
+		})
+		for _, t := range withMine[left:] {
+			board.Mines.Data[t] = false
+		}
+	} else if len(withMine) < left {
+		rng.Shuffle(len(withoutMine), func(i, j int) {
+			withoutMine[i], withoutMine[j] = withoutMine[j], withoutMine[i]
+		})
+		for _, t := range withoutMine[:left-len(withMine)] {
+			board.Mines.Data[t] = true
+		}
+	}
+
+	return true
+}
+
+// ==========================
+// mine count sets
+// ==========================
+//
+// set of mine counts is a []bool where counts[k] is true if k mines is possible
+
+// orShifted adds every count in from plus shift to to
+func orShifted(to []bool, from []bool, shift int) []bool {
+	if need := len(from) + shift; len(to) < need {
+		to = append(to, make([]bool, need-len(to))...)
+	}
+	for k, ok := range from {
+		if ok {
+			to[k+shift] = true
+		}
+	}
+	return to
+}
+
+// sumCounts returns every a + b
+func sumCounts(a []bool, b []bool) []bool {
+	var sum []bool
+	for k, ok := range a {
+		if ok {
+			sum = orShifted(sum, b, k)
+		}
+	}
+	return sum
+}
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// small boards with some tiles revealed, small enough to list every arrangement
+func randomPosition(rng *rand.Rand) Board {
+	width, height := 3+rng.IntN(3), 3+rng.IntN(2)
+
+	board := NewBoard(width, height)
+	board.PlaceMines(rng.IntN(width*height/3)+1, rng.IntN(width), rng.IntN(height), [32]byte{byte(rng.Uint32())})
+
+	for range rng.IntN(4) + 1 {
+		x, y := rng.IntN(width), rng.IntN(height)
+		if !board.Mines.Get(x, y) {
+			board.SpreadSafeArea(x, y)
+		}
+	}
+
+	return board
+}
+
+// every arrangement of mines on hidden tiles that agrees with board
+func bruteForceArrangements(board Board) [][]bool {
+	var hidden []int
+	total := 0
+	for i := range board.Mines.Data {
+		if !board.Revealed.Data[i] {
+			hidden = append(hidden, i)
+			if board.Mines.Data[i] {
+				total++
+			}
+		}
+	}
+
+	var result [][]bool
+
+	test := NewBoard(board.Width, board.Height)
+	copy(test.Revealed.Data, board.Revealed.Data)
+
+	for bits := 0; bits < 1<<len(hidden); bits++ {
+		count := 0
+		for j, t := range hidden {
+			test.Mines.Data[t] = bits&(1<<j) != 0
+			if test.Mines.Data[t] {
+				count++
+			}
+		}
+		if count != total {
+			continue
+		}
+
+		agrees := true
+		for i := range board.Mines.Data {
+			x, y := i%board.Width, i/board.Width
+			if board.Revealed.Data[i] && test.GetNeighborMineCount(x, y) != board.GetNeighborMineCount(x, y) {
+				agrees = false
+				break
+			}
+		}
+		if agrees {
+			result = append(result, append([]bool(nil), test.Mines.Data...))
+		}
+	}
+
+	return result
+}
+
+func TestArrangementsMatchBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for range 500 {
+		board := randomPosition(rng)
+		all := bruteForceArrangements(board)
+
+		canMine, canSafe := board.arrangements().possible(&board)
+
+		for i := range board.Mines.Data {
+			if board.Revealed.Data[i] {
+				continue
+			}
+
+			wantMine, wantSafe := false, false
+			for _, mines := range all {
+				wantMine = wantMine || mines[i]
+				wantSafe = wantSafe || !mines[i]
+			}
+
+			if canMine[i] != wantMine || canSafe[i] != wantSafe {
+				t.Fatalf(
+					"tile %d, %d of\n%v\ncan be mine %v and safe %v, want %v and %v",
+					i%board.Width, i/board.Width, drawBoard(board), canMine[i], canSafe[i], wantMine, wantSafe,
+				)
+			}
+
+			// relocating works exactly when brute force says it's possible
+			for _, mine := range []bool{true, false} {
+				moved := board.Copy()
+				ok := moved.relocateMines(i%board.Width, i/board.Width, mine, [32]byte{})
+
+				want := (mine && wantMine) || (!mine && wantSafe)
+				if ok != want {
+					t.Fatalf("relocating mine %v to %d, %d returned %v, want %v", mine, i%board.Width, i/board.Width, ok, want)
+				}
+				if ok && !agreesWith(moved, board) {
+					t.Fatalf("relocating mine %v to %d, %d changed what player can see\n%v\n%v", mine, i%board.Width, i/board.Width, drawBoard(board), drawBoard(moved))
+				}
+				if ok && moved.Mines.Data[i] != mine {
+					t.Fatalf("relocating mine %v to %d, %d didn't move it", mine, i%board.Width, i/board.Width)
+				}
+			}
+		}
+	}
+}
+
+// whether b has the same numbers and mine count as board
+func agreesWith(b Board, board Board) bool {
+	count, bCount := 0, 0
+	for i := range board.Mines.Data {
+		x, y := i%board.Width, i/board.Width
+		if board.Mines.Data[i] {
+			count++
+		}
+		if b.Mines.Data[i] {
+			bCount++
+		}
+		if board.Revealed.Data[i] &&
+			(b.Mines.Data[i] || b.GetNeighborMineCount(x, y) != board.GetNeighborMineCount(x, y)) {
+			return false
+		}
+	}
+	return count == bCount
+}
+
+// draws board like ParseBoard takes it
+func drawBoard(board Board) string {
+	var sb strings.Builder
+	for y := range board.Height {
+		for x := range board.Width {
+			switch {
+			case board.Revealed.Get(x, y):
+				sb.WriteByte(byte('0' + board.GetNeighborMineCount(x, y)))
+			case board.Mines.Get(x, y):
+				sb.WriteByte('*')
+			default:
+				sb.WriteByte('#')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,697 @@
+// Package engine has the rules of minesweeper without any drawing code.
+//
+// It must not import ebiten, so that headless tools and servers
+// can be built on machines without a display.
+// Same goes for packages and programs built on it (bot, server, tui.go and so on),
+// see TestHeadlessPackages.
+package engine
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+)
+
+//==============================================
+// BOARD STUFFS
+//==============================================
+
+type Board struct {
+	Width  int
+	Height int
+
+	Mines Array2D[bool]
+
+	Revealed Array2D[bool]
+	Flags    Array2D[bool]
+}
+
+func NewBoard(width int, height int) Board {
+	var board Board
+
+	board.Width = width
+	board.Height = height
+
+	board.Mines = NewArray2D[bool](width, height)
+	board.Revealed = NewArray2D[bool](width, height)
+	board.Flags = NewArray2D[bool](width, height)
+
+	return board
+}
+
+// FirstClickPolicy decides which tiles are kept free of mines
+// when mines are placed on the first step
+type FirstClickPolicy uint8
+
+const (
+	// first clicked tile and it's neighbors are free of mines
+	// (neighbors can still get mines if there are too many mines)
+	FirstClickSafeArea FirstClickPolicy = iota
+
+	// only the first clicked tile is free of mines
+	FirstClickSafeTile
+
+	FirstClickPolicySize
+)
+
+var FirstClickPolicyStrs = [FirstClickPolicySize]string{
+	"safe-area",
+	"safe-tile",
+}
+
+func ParseFirstClickPolicy(str string) (FirstClickPolicy, error) {
+	for p := FirstClickPolicy(0); p < FirstClickPolicySize; p++ {
+		if str == FirstClickPolicyStrs[p] {
+			return p, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown first click policy %q", str)
+}
+
+func (board *Board) PlaceMines(count, exceptX, exceptY int, seed [32]byte) {
+	board.PlaceMinesEx(count, exceptX, exceptY, FirstClickSafeArea, seed)
+}
+
+func (board *Board) PlaceMinesEx(
+	count, exceptX, exceptY int,
+	policy FirstClickPolicy,
+	seed [32]byte,
+) {
+	board.placeMines(count, exceptX, exceptY, policy, UniformPlacer{}, BoardTarget{}, seed)
+}
+
+// see MinePlacer and BoardTarget for how placer and target are used
+func (board *Board) placeMines(
+	count, exceptX, exceptY int,
+	policy FirstClickPolicy,
+	placer MinePlacer,
+	target BoardTarget,
+	seed [32]byte,
+) {
+	tilesTotal := board.Width * board.Height
+
+	maxCount := tilesTotal - 1
+
+	count = min(count, maxCount)
+
+	rng := rand.New(rand.NewChaCha8(seed))
+
+	place := func() {
+		clear(board.Mines.Data)
+		placer.PlaceMines(board, count, exceptX, exceptY, policy, rng)
+	}
+
+	place()
+
+	if target.Kind == TargetNone || count <= 0 {
+		return
+	}
+
+	best := slices.Clone(board.Mines.Data)
+	bestDistance := target.distance(board, exceptX, exceptY)
+
+	for i := 1; i < target.maxCandidates(board, placer) && bestDistance > 0; i++ {
+		place()
+
+		if distance := target.distance(board, exceptX, exceptY); distance < bestDistance {
+			copy(best, board.Mines.Data)
+			bestDistance = distance
+		}
+	}
+
+	copy(board.Mines.Data, best)
+}
+
+// RelocateMines moves mines so that safeX, safeY is not a mine,
+// while every revealed tile keeps its number and mine count stays the same.
+// Revealed tiles never get a mine.
+//
+// Mines that don't have to move stay where they are.
+// Returns false and leaves board as it is if there's no such arrangement,
+// or if safeX, safeY is in a component too big to work out (see arrangements).
+func (board *Board) RelocateMines(safeX, safeY int, seed [32]byte) bool {
+	return board.relocateMines(safeX, safeY, false, seed)
+}
+
+// RelocateMinesOnto is RelocateMines that makes mineX, mineY a mine instead.
+func (board *Board) RelocateMinesOnto(mineX, mineY int, seed [32]byte) bool {
+	return board.relocateMines(mineX, mineY, true, seed)
+}
+
+func (board *Board) Copy() Board {
+	copy := NewBoard(board.Width, board.Height)
+
+	iterator := NewBoardIterator(0, 0, board.Width-1, board.Height-1)
+
+	for iterator.HasNext() {
+		x, y := iterator.GetNext()
+
+		copy.Mines.Set(x, y, board.Mines.Get(x, y))
+		copy.Revealed.Set(x, y, board.Revealed.Get(x, y))
+		copy.Flags.Set(x, y, board.Flags.Get(x, y))
+	}
+
+	return copy
+}
+
+func (board *Board) SaveTo(targetBoard Board) {
+	if !(board.Width == targetBoard.Width && board.Height == targetBoard.Height) {
+		panic("targetBoard dimmensions is not equal to board")
+	}
+
+	iterator := NewBoardIterator(0, 0, board.Width-1, board.Height-1)
+
+	for iterator.HasNext() {
+		x, y := iterator.GetNext()
+
+		targetBoard.Mines.Set(x, y, board.Mines.Get(x, y))
+		targetBoard.Revealed.Set(x, y, board.Revealed.Get(x, y))
+		targetBoard.Flags.Set(x, y, board.Flags.Get(x, y))
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func (board *Board) IsPosInBoard(posX int, posY int) bool {
+	return posX >= 0 && posX < board.Width && posY >= 0 && posY < board.Height
+}
+
+func (board *Board) SpreadSafeArea(posX int, posY int) {
+	if !board.IsPosInBoard(posX, posY) {
+		return
+	}
+
+	if board.Revealed.Get(posX, posY) {
+		return
+	}
+
+	if board.Mines.Get(posX, posY) {
+		return
+	}
+
+	board.Revealed.Set(posX, posY, true)
+
+	if board.GetNeighborMineCount(posX, posY) > 0 {
+		return
+	}
+
+	board.Flags.Set(posX, posY, false)
+
+	iterator := NewBoardIterator(posX-1, posY-1, posX+1, posY+1)
+	for iterator.HasNext() {
+		x, y := iterator.GetNext()
+		if board.IsPosInBoard(x, y) {
+			board.SpreadSafeArea(x, y)
+		}
+	}
+}
+
+func (board *Board) GetNeighborMineCount(posX int, posY int) int {
+	var mineCount int = 0
+	for x := posX - 1; x <= posX+1; x++ {
+		for y := posY - 1; y <= posY+1; y++ {
+			if board.IsPosInBoard(x, y) && board.Mines.Get(x, y) {
+				mineCount += 1
+			}
+		}
+	}
+
+	return mineCount
+}
+
+func (board *Board) GetNeighborFlagCount(posX int, posY int) int {
+	var flagCount int = 0
+	for x := max(posX-1, 0); x < min(posX+2, board.Width); x++ {
+		for y := max(posY-1, 0); y < min(posY+2, board.Height); y++ {
+			if board.Flags.Get(x, y) {
+				flagCount += 1
+			}
+		}
+	}
+
+	return flagCount
+}
+
+func (board *Board) HasNoMines() bool {
+	for x := range board.Width {
+		for y := range board.Height {
+			if board.Mines.Get(x, y) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// win condition
+func (board *Board) IsAllSafeTileRevealed() bool {
+	var iter BoardIterator = NewBoardIterator(0, 0, board.Width-1, board.Height-1)
+
+	for iter.HasNext() {
+		x, y := iter.GetNext()
+		if !board.Revealed.Get(x, y) && !board.Mines.Get(x, y) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Get3BV returns Bechtel's Board Benchmark Value,
+// minimum number of steps needed to reveal every safe tile.
+//
+// Each opening (connected area of tiles with no neighboring mines) counts as 1
+// and each safe tile that doesn't get revealed by an opening counts as 1.
+//
+// Returns 0 if mines are not placed yet.
+func (board *Board) Get3BV() int {
+	if board.HasNoMines() {
+		return 0
+	}
+
+	counted := NewArray2D[bool](board.Width, board.Height)
+
+	isOpening := func(x, y int) bool {
+		return !board.Mines.Get(x, y) && board.GetNeighborMineCount(x, y) == 0
+	}
+
+	bbbv := 0
+
+	// count openings
+	stack := make([][2]int, 0, 64)
+
+	for x := range board.Width {
+		for y := range board.Height {
+			if counted.Get(x, y) || !isOpening(x, y) {
+				continue
+			}
+
+			bbbv++
+
+			counted.Set(x, y, true)
+			stack = append(stack[:0], [2]int{x, y})
+
+			for len(stack) > 0 {
+				pos := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+
+				iter := NewBoardIterator(pos[0]-1, pos[1]-1, pos[0]+1, pos[1]+1)
+				for iter.HasNext() {
+					nx, ny := iter.GetNext()
+					if !board.IsPosInBoard(nx, ny) || counted.Get(nx, ny) {
+						continue
+					}
+					counted.Set(nx, ny, true)
+					if isOpening(nx, ny) {
+						stack = append(stack, [2]int{nx, ny})
+					}
+				}
+			}
+		}
+	}
+
+	// count rest of the safe tiles
+	for x := range board.Width {
+		for y := range board.Height {
+			if !counted.Get(x, y) && !board.Mines.Get(x, y) {
+				bbbv++
+			}
+		}
+	}
+
+	return bbbv
+}
+
+//==============================================
+// board iterator
+//==============================================
+
+type BoardIterator struct {
+	MinX int
+	MinY int
+	MaxX int
+	MaxY int
+
+	CurrentX int
+	CurrentY int
+}
+
+// inclusive
+func NewBoardIterator(x1 int, y1 int, x2 int, y2 int) BoardIterator {
+	iterator := BoardIterator{
+		MinX: min(x1, x2),
+		MinY: min(y1, y2),
+
+		MaxX: max(x1, x2),
+		MaxY: max(y1, y2),
+	}
+
+	iterator.CurrentX = iterator.MinX
+	iterator.CurrentY = iterator.MinY
+
+	return iterator
+}
+
+func (bi *BoardIterator) HasNext() bool {
+	return bi.CurrentY <= bi.MaxY
+}
+
+func (bi *BoardIterator) GetNext() (int, int) {
+	x := bi.CurrentX
+	y := bi.CurrentY
+
+	bi.CurrentX++
+	if bi.CurrentX > bi.MaxX {
+		bi.CurrentX = bi.MinX
+		bi.CurrentY++
+	}
+
+	return x, y
+}
+
+func (bi *BoardIterator) Reset() {
+	bi.CurrentX = bi.MinX
+	bi.CurrentY = bi.MinY
+}
+
+// ==============================================
+// board iteraction
+// ==============================================
+
+type BoardInteractionType int
+
+const (
+	InteractionTypeNone BoardInteractionType = iota
+	InteractionTypeStep
+	InteractionTypeFlag
+	InteractionTypeCheck
+	InteractionTypeSize
+)
+
+var InteractionTypeStrs = [InteractionTypeSize]string{
+	"none",
+	"step",
+	"flag",
+	"check",
+}
+
+func (it BoardInteractionType) String() string {
+	if it < 0 || it >= InteractionTypeSize {
+		return fmt.Sprintf("BoardInteractionType(%d)", int(it))
+	}
+	return InteractionTypeStrs[it]
+}
+
+func (it BoardInteractionType) MarshalText() ([]byte, error) {
+	if it < 0 || it >= InteractionTypeSize {
+		return nil, fmt.Errorf("invalid interaction type %d", int(it))
+	}
+	return []byte(InteractionTypeStrs[it]), nil
+}
+
+func (it *BoardInteractionType) UnmarshalText(text []byte) error {
+	for i := BoardInteractionType(0); i < InteractionTypeSize; i++ {
+		if string(text) == InteractionTypeStrs[i] {
+			*it = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown interaction type %q", string(text))
+}
+
+type GameState int
+
+const (
+	GameStatePlaying GameState = iota
+	GameStateWon
+	GameStateLost
+	GameStateSize
+)
+
+var GameStateStrs = [GameStateSize]string{
+	"playing",
+	"won",
+	"lost",
+}
+
+func (gs GameState) String() string {
+	if gs < 0 || gs >= GameStateSize {
+		return fmt.Sprintf("GameState(%d)", int(gs))
+	}
+	return GameStateStrs[gs]
+}
+
+func (gs GameState) MarshalText() ([]byte, error) {
+	if gs < 0 || gs >= GameStateSize {
+		return nil, fmt.Errorf("invalid game state %d", int(gs))
+	}
+	return []byte(GameStateStrs[gs]), nil
+}
+
+func (gs *GameState) UnmarshalText(text []byte) error {
+	for i := GameState(0); i < GameStateSize; i++ {
+		if string(text) == GameStateStrs[i] {
+			*gs = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown game state %q", string(text))
+}
+
+// InteractWithCode is InteractAt with variants of code applied.
+//
+// flagsMatch is used when code has GameVariantFlags,
+// if it's nil board is played with normal rules.
+func (board *Board) InteractWithCode(
+	posX int, posY int,
+	interaction BoardInteractionType,
+	gameState GameState,
+	code GameCode,
+	flagsMatch *FlagsMatch,
+) GameState {
+	if gameState != GameStatePlaying {
+		return gameState
+	}
+
+	// InteractAt doesn't know about placers and targets
+	placed := interaction == InteractionTypeStep && board.IsPosInBoard(posX, posY) && board.HasNoMines()
+	if placed {
+		board.PlaceMinesForCode(code, posX, posY)
+	}
+
+	// first click is kept safe by first click policy,
+	// nothing on a fresh board is proven safe so it would count as a guess
+	if !placed {
+		if code.Variants&GameVariantForgiving != 0 && interaction == InteractionTypeStep {
+			board.ForgiveGuess(posX, posY, code.Seed)
+		}
+		if code.Variants&GameVariantEvil != 0 {
+			board.PunishGuess(posX, posY, interaction, code.Seed)
+		}
+	}
+
+	if flagsMatch != nil && code.Variants&GameVariantFlags != 0 {
+		return flagsMatch.InteractAt(
+			board, posX, posY, interaction, gameState,
+			code.MineCount, code.FirstClick, code.Seed,
+		)
+	}
+
+	return board.InteractAtEx(
+		posX, posY, interaction, gameState,
+		code.MineCount, code.FirstClick, code.Seed,
+		code.Lives,
+	)
+}
+
+func (board *Board) InteractAt(
+	posX int, posY int,
+	interaction BoardInteractionType,
+	gameState GameState,
+
+	// information needed to spawn mines
+	minesToSpawn int, firstClick FirstClickPolicy, seed [32]byte,
+) GameState {
+	return board.InteractAtEx(posX, posY, interaction, gameState, minesToSpawn, firstClick, seed, 0)
+}
+
+// InteractAtEx is InteractAt with lives.
+//
+// Until lives mines are hit, stepping on a mine reveals it (see HitMineCount)
+// and game goes on. Hit mines count as flags when chording,
+// and chording around a wrong flag steps on the mines around it that aren't flagged.
+// Game is lost on the mine after that, 0 lives is a normal game.
+func (board *Board) InteractAtEx(
+	posX int, posY int,
+	interaction BoardInteractionType,
+	gameState GameState,
+
+	// information needed to spawn mines
+	minesToSpawn int, firstClick FirstClickPolicy, seed [32]byte,
+
+	lives int,
+) GameState {
+	if gameState != GameStatePlaying {
+		return gameState
+	}
+
+	if interaction == InteractionTypeNone {
+		return GameStatePlaying
+	}
+	if !board.IsPosInBoard(posX, posY) {
+		return GameStatePlaying
+	}
+
+	defer func() {
+		// remove flags where it's revealed
+		for x := 0; x < board.Width; x++ {
+			for y := 0; y < board.Height; y++ {
+				if board.Revealed.Get(x, y) {
+					board.Flags.Set(x, y, false)
+				}
+			}
+		}
+	}()
+
+	switch interaction {
+	case InteractionTypeStep:
+		{
+			if board.HasNoMines() {
+				board.PlaceMinesEx(minesToSpawn, posX, posY, firstClick, seed)
+			}
+			if !board.Revealed.Get(posX, posY) {
+				if board.Flags.Get(posX, posY) { // if flag is up, ignore step
+					return GameStatePlaying
+				}
+				if board.Mines.Get(posX, posY) {
+					// user stepped on a mine
+					if !board.hitMine(posX, posY, lives) {
+						return GameStateLost
+					}
+				} else {
+					//we have to spread out
+					board.SpreadSafeArea(posX, posY)
+				}
+			}
+			if board.IsAllSafeTileRevealed() {
+				return GameStateWon
+			} else {
+				return GameStatePlaying
+			}
+		}
+	case InteractionTypeFlag:
+		{
+			if !board.Revealed.Get(posX, posY) {
+				//board.Flags[posX][posY] = !board.Flags[posX][posY]
+				board.Flags.Set(posX, posY, !board.Flags.Get(posX, posY))
+			}
+			return GameStatePlaying
+		}
+	case InteractionTypeCheck:
+		{
+			if board.Revealed.Get(posX, posY) && !board.Mines.Get(posX, posY) &&
+				board.GetNeighborMineCount(posX, posY) > 0 {
+				var flagCount int = board.GetNeighborFlagCount(posX, posY)
+				if lives > 0 {
+					// hit mines are as good as flags
+					flagCount += board.getNeighborHitMineCount(posX, posY)
+				}
+				if board.GetNeighborMineCount(posX, posY) == flagCount {
+					//check if user flagged it correctly
+					iterator := NewBoardIterator(posX-1, posY-1, posX+1, posY+1)
+
+					wrongFlag := false
+
+					for iterator.HasNext() {
+						x, y := iterator.GetNext()
+						if board.IsPosInBoard(x, y) {
+							if board.Flags.Get(x, y) && !board.Mines.Get(x, y) {
+								if lives <= 0 {
+									return GameStateLost
+								}
+								wrongFlag = true
+							}
+						}
+					}
+
+					if wrongFlag {
+						// step on mines that should have been flagged instead
+						iterator = NewBoardIterator(posX-1, posY-1, posX+1, posY+1)
+
+						for iterator.HasNext() {
+							x, y := iterator.GetNext()
+							if board.IsPosInBoard(x, y) && board.Mines.Get(x, y) &&
+								!board.Revealed.Get(x, y) && !board.Flags.Get(x, y) {
+								if !board.hitMine(x, y, lives) {
+									return GameStateLost
+								}
+							}
+						}
+
+						return GameStatePlaying
+					}
+
+					//reset iterator
+					iterator = NewBoardIterator(posX-1, posY-1, posX+1, posY+1)
+
+					for iterator.HasNext() {
+						x, y := iterator.GetNext()
+						if board.IsPosInBoard(x, y) {
+							board.SpreadSafeArea(x, y)
+						}
+					}
+
+				}
+			}
+
+			if board.IsAllSafeTileRevealed() {
+				return GameStateWon
+			} else {
+				return GameStatePlaying
+			}
+		}
+
+	}
+	panic("UNREACHABLE")
+}
+
+// HitMineCount returns number of mines that were hit without losing,
+// which are the revealed mines (claimed mines in flags variant)
+func (board *Board) HitMineCount() int {
+	count := 0
+	for i, mine := range board.Mines.Data {
+		if mine && board.Revealed.Data[i] {
+			count++
+		}
+	}
+	return count
+}
+
+func (board *Board) getNeighborHitMineCount(posX int, posY int) int {
+	count := 0
+	iter := NewBoardIterator(posX-1, posY-1, posX+1, posY+1)
+	for iter.HasNext() {
+		x, y := iter.GetNext()
+		if board.IsPosInBoard(x, y) && board.Mines.Get(x, y) && board.Revealed.Get(x, y) {
+			count++
+		}
+	}
+	return count
+}
+
+// reveals mine at posX, posY if there's a life left for it,
+// returns false if there isn't
+func (board *Board) hitMine(posX, posY int, lives int) bool {
+	if board.HitMineCount() >= lives {
+		return false
+	}
+	board.Revealed.Set(posX, posY, true)
+	return true
+}
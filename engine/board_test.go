@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func testSeed(i int) [32]byte {
+	var seed [32]byte
+	seed[0], seed[1] = byte(i), byte(i>>8)
+	return seed
+}
+
+func TestEvilFirstClickIsSafe(t *testing.T) {
+	targets := []BoardTarget{
+		{},
+		{Kind: Target3BV, Min: 0, Max: 1000},
+	}
+
+	for d := Difficulty(0); d < DifficultySize; d++ {
+		for _, target := range targets {
+			for i := range 30 {
+				code := GameCode{
+					Seed:      testSeed(i),
+					Width:     DifficultyBoardSizesNormal[d].X,
+					Height:    DifficultyBoardSizesNormal[d].Y,
+					MineCount: DifficultyMineCounts[d],
+					Variants:  GameVariantEvil,
+					Target:    target,
+				}
+
+				x, y := i%code.Width, (i*7)%code.Height
+
+				board := NewBoard(code.Width, code.Height)
+				state := board.InteractWithCode(x, y, InteractionTypeStep, GameStatePlaying, code, nil)
+
+				if state == GameStateLost || board.Mines.Get(x, y) {
+					t.Fatalf("%s : first click at %d, %d hit a mine", code.String(), x, y)
+				}
+			}
+		}
+	}
+}
+
+// plays games that step on proven safe tiles and otherwise guess,
+// calls onGuess with state after each guess
+func playGuessing(t *testing.T, variant GameVariant, onGuess func(state GameState)) {
+	t.Helper()
+
+	for g := range 20 {
+		code := GameCode{
+			Seed:      testSeed(g),
+			Width:     DifficultyBoardSizesNormal[DifficultyMedium].X,
+			Height:    DifficultyBoardSizesNormal[DifficultyMedium].Y,
+			MineCount: DifficultyMineCounts[DifficultyMedium],
+			Variants:  variant,
+		}
+		rng := rand.New(rand.NewPCG(uint64(g), 0))
+
+		board := NewBoard(code.Width, code.Height)
+		state := board.InteractWithCode(code.Width/2, code.Height/2, InteractionTypeStep, GameStatePlaying, code, nil)
+
+		for state == GameStatePlaying {
+			if safe := board.FindSafeTiles(); len(safe) > 0 {
+				state = board.InteractWithCode(safe[0][0], safe[0][1], InteractionTypeStep, state, code, nil)
+				continue
+			}
+
+			// tiles that can be a mine and can be safe
+			canMine, canSafe := board.arrangements().possible(&board)
+			var guesses []int
+			for i := range canMine {
+				if canMine[i] && canSafe[i] {
+					guesses = append(guesses, i)
+				}
+			}
+			if len(guesses) <= 0 {
+				t.Fatalf("%s : nothing is safe and nothing is a guess", code.String())
+			}
+
+			guess := guesses[rng.IntN(len(guesses))]
+			state = board.InteractWithCode(guess%code.Width, guess/code.Width, InteractionTypeStep, state, code, nil)
+			onGuess(state)
+		}
+	}
+}
+
+func TestEvilGuessIsMine(t *testing.T) {
+	playGuessing(t, GameVariantEvil, func(state GameState) {
+		if state != GameStateLost {
+			t.Fatalf("guess didn't hit a mine")
+		}
+	})
+}
+
+func TestForgivingForcedGuessIsSafe(t *testing.T) {
+	playGuessing(t, GameVariantForgiving, func(state GameState) {
+		if state == GameStateLost {
+			t.Fatalf("forced guess hit a mine")
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+type Difficulty int
+
+const (
+	DifficultyEasy Difficulty = iota
+	DifficultyMedium
+	DifficultyHard
+	DifficultySize
+)
+
+var DifficultyStrs = [DifficultySize]string{
+	"Easy",
+	"Medium",
+	"Hard",
+}
+
+// board presets for each difficulty
+var (
+	DifficultyMineCounts = [DifficultySize]int{10, 40, 99}
+
+	DifficultyBoardSizesNormal = [DifficultySize]image.Point{
+		image.Pt(10, 10), image.Pt(16, 16), image.Pt(22, 22),
+	}
+	DifficultyBoardSizesMobile = [DifficultySize]image.Point{
+		image.Pt(10, 10), image.Pt(13, 20), image.Pt(18, 27),
+	}
+)
+
+func ParseDifficulty(str string) (Difficulty, error) {
+	for d := Difficulty(0); d < DifficultySize; d++ {
+		if strings.EqualFold(str, DifficultyStrs[d]) {
+			return d, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown difficulty %q", str)
+}
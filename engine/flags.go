@@ -0,0 +1,113 @@
+package engine
+
+// ==============================================
+// flags
+// ==============================================
+//
+// Flags is a two player variant played on one board.
+// Players take turns stepping on tiles.
+// Stepping on a mine claims it and gives the player another turn,
+// stepping on a safe tile reveals it like usual and ends the turn.
+// First player to claim more than half of the mines wins.
+//
+// There are no flags or chording in this variant (name is from the original game).
+
+const FlagsPlayerCount = 2
+
+// board of the original game, odd mine count so that there's no tie
+const (
+	FlagsDefaultWidth     = 16
+	FlagsDefaultHeight    = 16
+	FlagsDefaultMineCount = 51
+)
+
+// FlagsMatch is the state of a Flags game that Board doesn't have
+type FlagsMatch struct {
+	// player whose turn it is
+	Turn int
+
+	// number of mines each player has claimed
+	Claims [FlagsPlayerCount]int
+
+	// player that claimed the mine, -1 if it's not claimed
+	Owners Array2D[int8]
+}
+
+func NewFlagsMatch(width, height int) *FlagsMatch {
+	fm := new(FlagsMatch)
+
+	fm.Owners = NewArray2D[int8](width, height)
+	for i := range fm.Owners.Data {
+		fm.Owners.Data[i] = -1
+	}
+
+	return fm
+}
+
+// returns winner, -1 if there's no winner yet (or it's a tie)
+func (fm *FlagsMatch) Winner(mineCount int) int {
+	for player, claims := range fm.Claims {
+		if claims*2 > mineCount {
+			return player
+		}
+	}
+	return -1
+}
+
+// true if someone won or every mine is claimed
+func (fm *FlagsMatch) IsOver(mineCount int) bool {
+	if fm.Winner(mineCount) >= 0 {
+		return true
+	}
+
+	total := 0
+	for _, claims := range fm.Claims {
+		total += claims
+	}
+	return total >= mineCount
+}
+
+// InteractAt is Board.InteractAt with Flags rules.
+//
+// Only InteractionTypeStep does anything.
+// Returns GameStateWon when match is over (see Winner for who won).
+func (fm *FlagsMatch) InteractAt(
+	board *Board,
+	posX int, posY int,
+	interaction BoardInteractionType,
+	gameState GameState,
+
+	// information needed to spawn mines
+	minesToSpawn int, firstClick FirstClickPolicy, seed [32]byte,
+) GameState {
+	if gameState != GameStatePlaying {
+		return gameState
+	}
+
+	if interaction != InteractionTypeStep || !board.IsPosInBoard(posX, posY) {
+		return GameStatePlaying
+	}
+
+	if board.HasNoMines() {
+		board.PlaceMinesEx(minesToSpawn, posX, posY, firstClick, seed)
+	}
+
+	if board.Revealed.Get(posX, posY) {
+		return GameStatePlaying
+	}
+
+	if board.Mines.Get(posX, posY) {
+		// claimed, same player goes again
+		board.Revealed.Set(posX, posY, true)
+		fm.Owners.Set(posX, posY, int8(fm.Turn))
+		fm.Claims[fm.Turn]++
+	} else {
+		board.SpreadSafeArea(posX, posY)
+		fm.Turn = (fm.Turn + 1) % FlagsPlayerCount
+	}
+
+	if fm.IsOver(minesToSpawn) {
+		return GameStateWon
+	}
+	return GameStatePlaying
+}
@@ -0,0 +1,542 @@
+package engine
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+)
+
+// ==============================================
+// game code
+// ==============================================
+//
+// Game code is a short text that describes a board.
+// Two games started from the same game code
+// will have exactly the same mines at the same places
+// (given that first click is at the same tile).
+//
+// Binary layout (before base32 encoding):
+//
+//	version    : 1 byte
+//	seed       : 32 bytes
+//	width      : uvarint
+//	height     : uvarint
+//	mine count : uvarint
+//	variants   : uvarint
+//	first click: 1 byte
+//	checksum   : 4 bytes (crc32 of everything above)
+//
+// Version 2 has board target after first click,
+// it's only used when code has a target so older codes stay the same:
+//
+//	target kind: 1 byte
+//	target min : uvarint
+//	target max : uvarint
+//
+// Version 3 has mine placer after that,
+// used when placer isn't uniform or when code has a target,
+// since version 2 codes draw target candidates differently (see GameCode.TargetV2):
+//
+//	placer     : 1 byte
+//	layout len : uvarint
+//	layout     : layout len bytes
+//
+// Version 4 has lives after that, only used when code has lives:
+//
+//	lives      : uvarint
+//
+// Encoded text has GameCodePrefix in front of it.
+
+// bit flags for rule variants
+//
+// unknown bits are rejected when parsing
+// since we can't recreate the exact same board with them
+type GameVariant uint16
+
+const (
+	// two players take turns and race to claim mines, see FlagsMatch
+	GameVariantFlags GameVariant = 1 << iota
+
+	// stepping on a mine is forgiven when there was nothing but guesses left,
+	// see Board.ForgiveGuess
+	GameVariantForgiving
+
+	// every guess is a mine, see Board.PunishGuess
+	GameVariantEvil
+
+	// clock counts down and game is lost when it runs out, see TimeAttack
+	GameVariantTimeAttack
+)
+
+const GameVariantAll GameVariant = GameVariantFlags | GameVariantForgiving | GameVariantEvil | GameVariantTimeAttack
+
+type GameCode struct {
+	Seed [32]byte
+
+	Width     int
+	Height    int
+	MineCount int
+
+	Variants   GameVariant
+	FirstClick FirstClickPolicy
+
+	// 3BV or rating the board is generated for, see BoardTarget
+	Target BoardTarget
+	// Target candidates are drawn the way version 2 codes drew them,
+	// before there were mine placers. ParseGameCode sets it
+	// for version 2 codes so that they keep their boards
+	TargetV2 bool
+
+	// how mines are placed, see MinePlacer
+	Placer PlacerKind
+	// mines of PlacerLayout, see ParseLayout
+	Layout string
+
+	// mines player can step on and keep playing, 0 for a normal game.
+	// see Board.InteractAtEx
+	Lives int
+}
+
+const (
+	GameCodeVersion = 1
+
+	// version of codes with a board target
+	GameCodeVersionTarget = 2
+
+	// version of codes with a mine placer
+	GameCodeVersionPlacer = 3
+
+	// version of codes with lives
+	GameCodeVersionLives = 4
+
+	GameCodePrefix = "MS-"
+
+	// maximum width and height a game code can have
+	GameCodeMaxBoardSize = 256
+
+	// maximum lives a game code can have
+	GameCodeMaxLives = 99
+)
+
+// Crockford's base32 alphabet
+// doesn't have I, L, O, U so it's harder to misread
+var gameCodeEncoding = base32.NewEncoding(
+	"0123456789ABCDEFGHJKMNPQRSTVWXYZ",
+).WithPadding(base32.NoPadding)
+
+var (
+	ErrGameCodeBadFormat   = errors.New("game code is not formatted correctly")
+	ErrGameCodeBadChecksum = errors.New("game code checksum does not match (typo?)")
+	ErrGameCodeBadVersion  = errors.New("game code is from an unsupported version")
+)
+
+func (code GameCode) Validate() error {
+	if code.Width <= 0 || code.Height <= 0 {
+		return fmt.Errorf("board size %dx%d is too small", code.Width, code.Height)
+	}
+	if code.Width > GameCodeMaxBoardSize || code.Height > GameCodeMaxBoardSize {
+		return fmt.Errorf(
+			"board size %dx%d is bigger than %dx%d",
+			code.Width, code.Height, GameCodeMaxBoardSize, GameCodeMaxBoardSize,
+		)
+	}
+	if code.MineCount < 0 || code.MineCount >= code.Width*code.Height {
+		return fmt.Errorf(
+			"mine count %d is invalid for %dx%d board",
+			code.MineCount, code.Width, code.Height,
+		)
+	}
+	if code.Variants&^GameVariantAll != 0 {
+		return fmt.Errorf("unknown variant flags %b", code.Variants&^GameVariantAll)
+	}
+	if code.Variants&GameVariantFlags != 0 && code.Variants&(GameVariantForgiving|GameVariantEvil) != 0 {
+		return errors.New("flags variant can't be forgiving or evil, mines are what players are looking for")
+	}
+	if code.Variants&GameVariantForgiving != 0 && code.Variants&GameVariantEvil != 0 {
+		return errors.New("board can't be both forgiving and evil")
+	}
+	if code.Variants&GameVariantFlags != 0 && code.Variants&GameVariantTimeAttack != 0 {
+		return errors.New("flags variant can't be time attack, players take turns")
+	}
+	if code.FirstClick >= FirstClickPolicySize {
+		return fmt.Errorf("unknown first click policy %d", code.FirstClick)
+	}
+	if err := code.Target.Validate(); err != nil {
+		return err
+	}
+	if code.Target.Kind != TargetNone && code.Variants&GameVariantFlags != 0 {
+		return errors.New("flags variant can't have a board target")
+	}
+	if code.TargetV2 && (code.Target.Kind == TargetNone || code.Placer != PlacerUniform || code.Lives > 0) {
+		return errors.New("only codes with a target and nothing newer can draw candidates like version 2")
+	}
+	if code.Placer >= PlacerKindSize {
+		return fmt.Errorf("unknown mine placer %d", code.Placer)
+	}
+	if code.Placer == PlacerLayout {
+		if len(code.Layout) != (code.Width*code.Height+7)/8 {
+			return fmt.Errorf("layout doesn't fit %dx%d board", code.Width, code.Height)
+		}
+		if layoutMineCount(code.Layout) != code.MineCount {
+			return fmt.Errorf("layout has %d mines, not %d", layoutMineCount(code.Layout), code.MineCount)
+		}
+	} else if code.Layout != "" {
+		return fmt.Errorf("%v placer can't have a layout", code.Placer)
+	}
+	if code.Lives < 0 || code.Lives > GameCodeMaxLives {
+		return fmt.Errorf("lives %d is not in 0..%d", code.Lives, GameCodeMaxLives)
+	}
+	if code.Lives > 0 && code.Variants&GameVariantFlags != 0 {
+		return errors.New("flags variant can't have lives, stepping on a mine claims it")
+	}
+
+	return nil
+}
+
+func (code GameCode) String() string {
+	var buf []byte
+
+	version := byte(GameCodeVersion)
+	if code.Lives > 0 {
+		version = GameCodeVersionLives
+	} else if code.TargetV2 {
+		version = GameCodeVersionTarget
+	} else if code.Placer != PlacerUniform || code.Target.Kind != TargetNone {
+		version = GameCodeVersionPlacer
+	}
+	buf = append(buf, version)
+	buf = append(buf, code.Seed[:]...)
+	buf = binary.AppendUvarint(buf, uint64(code.Width))
+	buf = binary.AppendUvarint(buf, uint64(code.Height))
+	buf = binary.AppendUvarint(buf, uint64(code.MineCount))
+	buf = binary.AppendUvarint(buf, uint64(code.Variants))
+	buf = append(buf, byte(code.FirstClick))
+	if version >= GameCodeVersionTarget {
+		buf = append(buf, byte(code.Target.Kind))
+		buf = binary.AppendUvarint(buf, uint64(code.Target.Min))
+		buf = binary.AppendUvarint(buf, uint64(code.Target.Max))
+	}
+	if version >= GameCodeVersionPlacer {
+		buf = append(buf, byte(code.Placer))
+		buf = binary.AppendUvarint(buf, uint64(len(code.Layout)))
+		buf = append(buf, code.Layout...)
+	}
+	if version >= GameCodeVersionLives {
+		buf = binary.AppendUvarint(buf, uint64(code.Lives))
+	}
+
+	buf = binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+
+	return GameCodePrefix + gameCodeEncoding.EncodeToString(buf)
+}
+
+// ParseGameCode is forgiving about how code is typed.
+// Letter cases, spaces and dashes are ignored
+// and prefix is optional.
+func ParseGameCode(str string) (GameCode, error) {
+	var code GameCode
+
+	str = strings.ToUpper(strings.TrimSpace(str))
+	str = strings.TrimPrefix(str, GameCodePrefix)
+
+	str = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r', '-':
+			return -1
+		// commonly misread letters
+		case 'O':
+			return '0'
+		case 'I', 'L':
+			return '1'
+		}
+		return r
+	}, str)
+
+	buf, err := gameCodeEncoding.DecodeString(str)
+	if err != nil {
+		return code, ErrGameCodeBadFormat
+	}
+
+	if len(buf) < 1+32+4 {
+		return code, ErrGameCodeBadFormat
+	}
+
+	// check checksum
+	{
+		body := buf[:len(buf)-4]
+		checksum := binary.BigEndian.Uint32(buf[len(buf)-4:])
+		if crc32.ChecksumIEEE(body) != checksum {
+			return code, ErrGameCodeBadChecksum
+		}
+		buf = body
+	}
+
+	version := buf[0]
+	if version < GameCodeVersion || version > GameCodeVersionLives {
+		return code, ErrGameCodeBadVersion
+	}
+	buf = buf[1:]
+
+	copy(code.Seed[:], buf[:32])
+	reader := bytes.NewReader(buf[32:])
+
+	readUvarint := func() (int, error) {
+		v, err := binary.ReadUvarint(reader)
+		if err != nil || v > 1<<20 {
+			return 0, ErrGameCodeBadFormat
+		}
+		return int(v), nil
+	}
+
+	if code.Width, err = readUvarint(); err != nil {
+		return code, err
+	}
+	if code.Height, err = readUvarint(); err != nil {
+		return code, err
+	}
+	if code.MineCount, err = readUvarint(); err != nil {
+		return code, err
+	}
+
+	variants, err := readUvarint()
+	if err != nil {
+		return code, err
+	}
+	code.Variants = GameVariant(variants)
+
+	firstClick, err := reader.ReadByte()
+	if err != nil {
+		return code, ErrGameCodeBadFormat
+	}
+	code.FirstClick = FirstClickPolicy(firstClick)
+
+	if version >= GameCodeVersionTarget {
+		kind, err := reader.ReadByte()
+		if err != nil {
+			return code, ErrGameCodeBadFormat
+		}
+		code.Target.Kind = TargetKind(kind)
+
+		if code.Target.Min, err = readUvarint(); err != nil {
+			return code, err
+		}
+		if code.Target.Max, err = readUvarint(); err != nil {
+			return code, err
+		}
+
+		// there would be two codes for the same board otherwise
+		if version == GameCodeVersionTarget && code.Target.Kind == TargetNone {
+			return code, ErrGameCodeBadFormat
+		}
+		code.TargetV2 = version == GameCodeVersionTarget
+	}
+
+	if version >= GameCodeVersionPlacer {
+		placer, err := reader.ReadByte()
+		if err != nil {
+			return code, ErrGameCodeBadFormat
+		}
+		code.Placer = PlacerKind(placer)
+
+		layoutLen, err := readUvarint()
+		if err != nil || layoutLen > reader.Len() {
+			return code, ErrGameCodeBadFormat
+		}
+		layout := make([]byte, layoutLen)
+		reader.Read(layout)
+		code.Layout = string(layout)
+
+		if version == GameCodeVersionPlacer && code.Placer == PlacerUniform && code.Target.Kind == TargetNone {
+			return code, ErrGameCodeBadFormat
+		}
+	}
+
+	if version >= GameCodeVersionLives {
+		if code.Lives, err = readUvarint(); err != nil {
+			return code, err
+		}
+
+		if code.Lives == 0 {
+			return code, ErrGameCodeBadFormat
+		}
+	}
+
+	if reader.Len() != 0 {
+		return code, ErrGameCodeBadFormat
+	}
+
+	if err = code.Validate(); err != nil {
+		return code, err
+	}
+
+	return code, nil
+}
+
+// BoardOptions describes a board the way command line tools and servers take it,
+// zero value of each field is its default
+type BoardOptions struct {
+	// game code, can't be used with other options
+	Code string
+
+	// easy, medium or hard, defaults to easy
+	Difficulty string
+
+	// override size and mine count of Difficulty
+	Width  int
+	Height int
+	Mines  int
+
+	// 64 hex digits, defaults to a random seed
+	Seed string
+
+	// safe-area or safe-tile, defaults to safe-area
+	FirstClick string
+
+	// two player flags match, size and mine count default to FlagsDefaultWidth and so on
+	Flags bool
+
+	Forgiving  bool
+	Evil       bool
+	TimeAttack bool
+
+	// see GameCode.Lives
+	Lives int
+
+	// see ParseBoardTarget
+	Target string
+
+	// see PlacerKindStrs, defaults to uniform. Layout needs a board,
+	// so it comes from Code or Layout
+	Placer string
+
+	// board drawn in text, see ParseLayout.
+	// It decides size, mines and placer, so it can't be used with them
+	Layout string
+}
+
+// GameCode returns game code of the board opts describe
+func (opts BoardOptions) GameCode() (GameCode, error) {
+	var code GameCode
+
+	if opts.Code != "" {
+		if opts != (BoardOptions{Code: opts.Code}) {
+			return code, errors.New("game code can't be used with other board options")
+		}
+		return ParseGameCode(opts.Code)
+	}
+
+	difficulty := DifficultyEasy
+	if opts.Difficulty != "" {
+		var err error
+		if difficulty, err = ParseDifficulty(opts.Difficulty); err != nil {
+			return code, err
+		}
+	}
+
+	var err error
+
+	if opts.Layout != "" {
+		if opts.Difficulty != "" || opts.Width != 0 || opts.Height != 0 || opts.Mines != 0 ||
+			opts.Target != "" || opts.Placer != "" {
+			return code, errors.New("layout decides the board, it can't be used with size, mines, target or placer")
+		}
+		if code, err = ParseLayout(opts.Layout); err != nil {
+			return code, err
+		}
+	} else if opts.Flags {
+		code.Width = FlagsDefaultWidth
+		code.Height = FlagsDefaultHeight
+		code.MineCount = FlagsDefaultMineCount
+	} else {
+		code.Width = DifficultyBoardSizesNormal[difficulty].X
+		code.Height = DifficultyBoardSizesNormal[difficulty].Y
+		code.MineCount = DifficultyMineCounts[difficulty]
+	}
+
+	if opts.Width != 0 {
+		code.Width = opts.Width
+	}
+	if opts.Height != 0 {
+		code.Height = opts.Height
+	}
+	if opts.Mines != 0 {
+		code.MineCount = opts.Mines
+	}
+
+	if opts.Flags {
+		code.Variants |= GameVariantFlags
+	}
+	if opts.Forgiving {
+		code.Variants |= GameVariantForgiving
+	}
+	if opts.Evil {
+		code.Variants |= GameVariantEvil
+	}
+	if opts.TimeAttack {
+		code.Variants |= GameVariantTimeAttack
+	}
+	code.Lives = opts.Lives
+
+	if code.Target, err = ParseBoardTarget(opts.Target); err != nil {
+		return code, err
+	}
+
+	if opts.Placer != "" {
+		if err = code.Placer.UnmarshalText([]byte(opts.Placer)); err != nil {
+			return code, err
+		}
+		if code.Placer == PlacerLayout {
+			return code, errors.New("layout placer needs a board, play it from a game code")
+		}
+	}
+
+	if opts.FirstClick != "" {
+		if code.FirstClick, err = ParseFirstClickPolicy(opts.FirstClick); err != nil {
+			return code, err
+		}
+	}
+
+	if opts.Seed != "" {
+		if code.Seed, err = ParseSeed(opts.Seed); err != nil {
+			return code, err
+		}
+	} else {
+		rand.Read(code.Seed[:])
+	}
+
+	if err = code.Validate(); err != nil {
+		return code, err
+	}
+
+	return code, nil
+}
+
+// ==============================================
+// seed
+// ==============================================
+
+func SeedToString(seed [32]byte) string {
+	return hex.EncodeToString(seed[:])
+}
+
+func ParseSeed(str string) ([32]byte, error) {
+	var seed [32]byte
+
+	decoded, err := hex.DecodeString(strings.TrimSpace(str))
+	if err != nil {
+		return seed, fmt.Errorf("seed is not a hex string: %w", err)
+	}
+	if len(decoded) != 32 {
+		return seed, fmt.Errorf("seed must be 32 bytes (64 hex digits), got %d bytes", len(decoded))
+	}
+
+	copy(seed[:], decoded)
+
+	return seed, nil
+}
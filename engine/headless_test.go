@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// packages that must build without a display
+var headlessPackages = []string{
+	"minesweeper/engine",
+	"minesweeper/bot",
+	"minesweeper/server",
+	"minesweeper/tournament",
+	"minesweeper/coop",
+	"minesweeper/race",
+	"minesweeper/spectate",
+	"minesweeper/lesson",
+	"minesweeper/puzzle",
+}
+
+// programs (relative to this directory) that must build without a display,
+// tui.go is meant to be played over ssh
+var headlessPrograms = []string{
+	"../tui.go",
+	"../bot_runner.go",
+	"../bot_example.go",
+	"../validate_replay.go",
+	"../run_server.go",
+	"../run_web.go",
+}
+
+func TestHeadlessPackages(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command is not available")
+	}
+
+	for _, pkg := range headlessPackages {
+		checkNoEbiten(t, pkg)
+	}
+	for _, program := range headlessPrograms {
+		checkNoEbiten(t, program)
+	}
+}
+
+func checkNoEbiten(t *testing.T, pkg string) {
+	t.Helper()
+
+	out, err := exec.Command("go", "list", "-deps", "-f", "{{.ImportPath}}", pkg).Output()
+	if err != nil {
+		t.Fatalf("go list %s: %v", pkg, err)
+	}
+
+	for _, dep := range strings.Fields(string(out)) {
+		if strings.HasPrefix(dep, "github.com/hajimehoshi/ebiten") {
+			t.Errorf("%s imports %s", pkg, dep)
+			return
+		}
+	}
+}
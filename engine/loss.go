@@ -0,0 +1,310 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ==============================================
+// loss analysis
+// ==============================================
+//
+// AnalyzeLoss replays a lost game and looks at the board
+// right before the fatal click, so player can tell bad luck from a bad move.
+//
+// What could be known is decided with arrangements,
+// same as ForgiveGuess decides a forced guess.
+// Deduction is explained with the solver's rules when they find it,
+// otherwise it takes all numbers of the group at once.
+
+type LossCause int
+
+const (
+	// no tile could be proven safe, it had to be a guess
+	LossCauseForcedGuess LossCause = iota
+
+	// clicked tile wasn't known, but some other tile could be proven safe
+	LossCauseNeedlessGuess
+
+	// clicked tile could be proven to be a mine
+	LossCauseMistake
+
+	LossCauseSize
+)
+
+var LossCauseStrs = [LossCauseSize]string{
+	"forced-guess",
+	"needless-guess",
+	"mistake",
+}
+
+func (lc LossCause) String() string {
+	if lc < 0 || lc >= LossCauseSize {
+		return fmt.Sprintf("LossCause(%d)", int(lc))
+	}
+	return LossCauseStrs[lc]
+}
+
+func (lc LossCause) MarshalText() ([]byte, error) {
+	if lc < 0 || lc >= LossCauseSize {
+		return nil, fmt.Errorf("invalid loss cause %d", int(lc))
+	}
+	return []byte(LossCauseStrs[lc]), nil
+}
+
+func (lc *LossCause) UnmarshalText(text []byte) error {
+	for i := LossCause(0); i < LossCauseSize; i++ {
+		if string(text) == LossCauseStrs[i] {
+			*lc = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown loss cause %q", string(text))
+}
+
+var ErrGameNotLost = errors.New("game wasn't lost")
+
+type LossAnalysis struct {
+	Cause LossCause `json:"cause"`
+
+	// mine that lost the game,
+	// for a check with a wrong flag it's the mine that got revealed
+	X int `json:"x"`
+	Y int `json:"y"`
+
+	// estimated chance X, Y was safe (see RateBoard),
+	// 0 if it was known to be a mine
+	Odds float64 `json:"odds"`
+
+	// best chance any tile had, same as Odds if player took the best guess
+	BestOdds float64 `json:"best_odds"`
+
+	// deduction player missed, unused for forced guess
+	//
+	// It proves MissedX, MissedY is a mine for a mistake
+	// and that it's safe for a needless guess.
+	MissedX    int           `json:"missed_x"`
+	MissedY    int           `json:"missed_y"`
+	MissedRule DeductionRule `json:"missed_rule"`
+
+	// numbers the last step of deduction uses,
+	// earlier steps might be needed to get there
+	Numbers [][2]int `json:"numbers,omitempty"`
+	// true if deduction needs number of mines left too
+	UsesMineCount bool `json:"uses_mine_count,omitempty"`
+
+	// true if solver's rules don't find the deduction
+	// and it takes every arrangement of mines that fits Numbers,
+	// MissedRule is DeductionNone then
+	Arrangements bool `json:"arrangements,omitempty"`
+}
+
+// rule that deduction uses, for String
+func (la LossAnalysis) ruleName() string {
+	if la.Arrangements {
+		return "every arrangement"
+	}
+	return fmt.Sprintf("%v rule", la.MissedRule)
+}
+
+func (la LossAnalysis) String() string {
+	switch la.Cause {
+	case LossCauseForcedGuess:
+		return fmt.Sprintf("forced guess at %.0f%%", la.Odds*100)
+	case LossCauseNeedlessGuess:
+		return fmt.Sprintf("guessed at %.0f%%, %s finds a safe tile", la.Odds*100, la.ruleName())
+	default:
+		return fmt.Sprintf("mistake, %s shows it's a mine", la.ruleName())
+	}
+}
+
+// AnalyzeLoss replays replay until the interaction that lost the game and tells why it was lost.
+//
+// Returns ErrGameNotLost if replay doesn't lose.
+// Replay is not validated, use ValidateReplay first if it's not trusted.
+func AnalyzeLoss(replay Replay) (LossAnalysis, error) {
+	var la LossAnalysis
+
+	code, err := ParseGameCode(replay.Code)
+	if err != nil {
+		return la, err
+	}
+
+	board := NewBoard(code.Width, code.Height)
+	before := NewBoard(code.Width, code.Height)
+	state := GameStatePlaying
+
+	var flagsMatch *FlagsMatch
+	if code.Variants&GameVariantFlags != 0 {
+		flagsMatch = NewFlagsMatch(code.Width, code.Height)
+	}
+
+	var lost *ReplayEvent
+
+	for i, event := range replay.Events {
+		if event.Type < 0 || event.Type >= InteractionTypeSize {
+			continue
+		}
+		if state != GameStatePlaying || !board.IsPosInBoard(event.X, event.Y) {
+			continue
+		}
+
+		copy(before.Mines.Data, board.Mines.Data)
+		copy(before.Revealed.Data, board.Revealed.Data)
+		copy(before.Flags.Data, board.Flags.Data)
+
+		state = board.InteractWithCode(event.X, event.Y, event.Type, state, code, flagsMatch)
+
+		if state == GameStateLost {
+			lost = &replay.Events[i]
+			break
+		}
+	}
+
+	if lost == nil {
+		return la, ErrGameNotLost
+	}
+
+	// lost on the first step, player couldn't see anything yet
+	if before.HasNoMines() {
+		copy(before.Mines.Data, board.Mines.Data)
+	}
+
+	// ==========================
+	// find the mine
+	// ==========================
+	la.X, la.Y = lost.X, lost.Y
+
+	if lost.Type == InteractionTypeCheck {
+		iter := NewBoardIterator(lost.X-1, lost.Y-1, lost.X+1, lost.Y+1)
+		for iter.HasNext() {
+			x, y := iter.GetNext()
+			// mines hit with lives before running out are revealed
+			if !board.IsPosInBoard(x, y) || board.Revealed.Get(x, y) || before.Flags.Get(x, y) {
+				continue
+			}
+			if board.Mines.Get(x, y) {
+				la.X, la.Y = x, y
+				break
+			}
+		}
+	}
+
+	// ==========================
+	// what could player know
+	// ==========================
+	sol := before.solve()
+	odds := before.guessOdds(sol)
+
+	a := before.arrangements()
+	canMine, canSafe := a.possible(&before)
+
+	// solver can still prove some tiles of unresolved components
+	known := make([]int8, len(sol.known))
+	for i := range known {
+		switch {
+		case before.Revealed.Data[i]:
+			known[i] = tileUnknown
+		case !canMine[i] || sol.known[i] == tileSafe:
+			known[i] = tileSafe
+		case !canSafe[i] || sol.known[i] == tileMine:
+			known[i] = tileMine
+		default:
+			known[i] = tileUnknown
+		}
+		if known[i] != tileUnknown {
+			odds[i] = -1
+		}
+	}
+
+	fatal := la.X + la.Y*board.Width
+
+	la.Odds = max(odds[fatal], 0)
+	for _, o := range odds {
+		la.BestOdds = max(la.BestOdds, o)
+	}
+
+	missed := -1
+
+	if known[fatal] == tileMine {
+		la.Cause = LossCauseMistake
+		la.Odds = 0
+		missed = fatal
+	} else {
+		// easiest safe tile, closest to where player clicked,
+		// tiles solver finds first
+		rule := func(i int) DeductionRule {
+			if sol.known[i] == tileSafe {
+				return sol.rules[i]
+			}
+			return DeductionRuleSize
+		}
+		dist := func(i int) int {
+			dx, dy := i%board.Width-la.X, i/board.Width-la.Y
+			return dx*dx + dy*dy
+		}
+		for i, k := range known {
+			if k != tileSafe {
+				continue
+			}
+			if missed < 0 || rule(i) < rule(missed) ||
+				(rule(i) == rule(missed) && dist(i) < dist(missed)) {
+				missed = i
+			}
+		}
+
+		la.Cause = LossCauseForcedGuess
+		if missed >= 0 {
+			la.Cause = LossCauseNeedlessGuess
+		}
+	}
+
+	if missed < 0 {
+		return la, nil
+	}
+
+	la.MissedX, la.MissedY = missed%board.Width, missed/board.Width
+
+	if sol.known[missed] != tileUnknown {
+		la.MissedRule = sol.rules[missed]
+
+		for _, ci := range sol.reasons[missed] {
+			at := sol.constraints[ci].at
+			if at < 0 {
+				la.UsesMineCount = true
+			} else {
+				la.Numbers = append(la.Numbers, [2]int{at % board.Width, at / board.Width})
+			}
+		}
+
+		return la, nil
+	}
+
+	la.Arrangements = true
+
+	// numbers of the component, or of all of them for a tile away from numbers
+	components := a.components
+	if c := a.componentOf[missed]; c >= 0 {
+		components = a.components[c : c+1]
+
+		// it's decided without the mine count
+		// if the component alone has no arrangement with the other value
+		ac := a.components[c]
+		other := 1
+		if known[missed] == tileMine {
+			other = 0
+		}
+		la.UsesMineCount = slices.Contains(ac.countsWith(a.positionOf[missed], other), true)
+	} else {
+		la.UsesMineCount = true
+	}
+
+	for _, ac := range components {
+		for _, c := range ac.constraints {
+			la.Numbers = append(la.Numbers, [2]int{c.at % board.Width, c.at / board.Width})
+		}
+	}
+
+	return la, nil
+}
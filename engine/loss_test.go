@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+// loses a game on a board where solver finds no safe tile but arrangements do,
+// by stepping on a mine that could still be safe
+func TestAnalyzeLossNeedlessGuessOnlyArrangementsFind(t *testing.T) {
+	for g := range 200 {
+		code := GameCode{
+			Seed:      testSeed(g),
+			Width:     DifficultyBoardSizesNormal[DifficultyHard].X,
+			Height:    DifficultyBoardSizesNormal[DifficultyHard].Y,
+			MineCount: DifficultyMineCounts[DifficultyHard],
+		}
+
+		replay := Replay{Version: ReplayVersion, Code: code.String()}
+		board := NewBoard(code.Width, code.Height)
+		state := GameStatePlaying
+
+		step := func(x, y int) {
+			state = board.InteractWithCode(x, y, InteractionTypeStep, state, code, nil)
+			replay.Events = append(replay.Events, ReplayEvent{Type: InteractionTypeStep, X: x, Y: y})
+		}
+
+		step(code.Width/2, code.Height/2)
+
+		for state == GameStatePlaying {
+			if solverSafe := board.SolverSafeTiles(); len(solverSafe) > 0 {
+				for _, s := range solverSafe {
+					if state == GameStatePlaying && !board.Revealed.Get(s[0], s[1]) {
+						step(s[0], s[1])
+					}
+				}
+				continue
+			}
+			safe := board.FindSafeTiles()
+			if len(safe) <= 0 {
+				break
+			}
+
+			// a mine that is not proven to be one
+			_, canSafe := board.arrangements().possible(&board)
+			fatal := -1
+			for i, ok := range canSafe {
+				if ok && board.Mines.Data[i] {
+					fatal = i
+					break
+				}
+			}
+			if fatal < 0 {
+				break
+			}
+
+			step(fatal%code.Width, fatal/code.Width)
+			if state != GameStateLost {
+				t.Fatalf("%s : stepping on a mine didn't lose", code.String())
+			}
+
+			la, err := AnalyzeLoss(replay)
+			if err != nil {
+				t.Fatalf("%s : %v", code.String(), err)
+			}
+			if la.Cause != LossCauseNeedlessGuess || !la.Arrangements {
+				t.Fatalf("%s : loss is %v, arrangements %v, want needless guess found by arrangements",
+					code.String(), la, la.Arrangements)
+			}
+			if !slices.Contains(safe, [2]int{la.MissedX, la.MissedY}) {
+				t.Fatalf("%s : missed tile %d, %d is not safe", code.String(), la.MissedX, la.MissedY)
+			}
+			if len(la.Numbers) <= 0 && !la.UsesMineCount {
+				t.Fatalf("%s : deduction has no numbers and no mine count", code.String())
+			}
+			return
+		}
+	}
+
+	t.Fatal("no game had a board where only arrangements find a safe tile")
+}
@@ -0,0 +1,470 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+// ==============================================
+// mine placers
+// ==============================================
+//
+// MinePlacer decides where mines go on the first step.
+// Which one is used is part of the game code (see PlacerKind),
+// so boards made by any of them can be shared and replayed.
+//
+// Placers must only use rng they are given,
+// same rng must give exactly the same mines.
+
+type MinePlacer interface {
+	// PlaceMines puts count mines on board that has no mines yet.
+	//
+	// Tiles first click policy keeps free must stay free,
+	// Board.MineCandidates returns the tiles that can get mines.
+	PlaceMines(board *Board, count, firstX, firstY int, policy FirstClickPolicy, rng *rand.Rand)
+}
+
+type PlacerKind uint8
+
+const (
+	// every tile is equally likely, see UniformPlacer
+	PlacerUniform PlacerKind = iota
+
+	// mines clump together, see ClusteredPlacer
+	PlacerClustered
+
+	// one side of the board has more mines, see GradientPlacer
+	PlacerGradient
+
+	// classic patterns show up more, see PatternPlacer
+	PlacerPattern
+
+	// mines are given in the game code, see LayoutPlacer
+	PlacerLayout
+
+	// boards that can be solved without guessing, see NoGuessPlacer
+	PlacerNoGuess
+
+	PlacerKindSize
+)
+
+var PlacerKindStrs = [PlacerKindSize]string{
+	"uniform",
+	"clustered",
+	"gradient",
+	"pattern",
+	"layout",
+	"no-guess",
+}
+
+func (pk PlacerKind) String() string {
+	if pk >= PlacerKindSize {
+		return fmt.Sprintf("PlacerKind(%d)", int(pk))
+	}
+	return PlacerKindStrs[pk]
+}
+
+func (pk PlacerKind) MarshalText() ([]byte, error) {
+	if pk >= PlacerKindSize {
+		return nil, fmt.Errorf("invalid placer kind %d", int(pk))
+	}
+	return []byte(PlacerKindStrs[pk]), nil
+}
+
+func (pk *PlacerKind) UnmarshalText(text []byte) error {
+	for i := PlacerKind(0); i < PlacerKindSize; i++ {
+		if string(text) == PlacerKindStrs[i] {
+			*pk = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown placer kind %q", string(text))
+}
+
+// MinePlacer returns placer of code
+func (code GameCode) MinePlacer() MinePlacer {
+	if code.TargetV2 {
+		return new(targetV2Placer)
+	}
+
+	switch code.Placer {
+	case PlacerClustered:
+		return ClusteredPlacer{}
+	case PlacerGradient:
+		return GradientPlacer{}
+	case PlacerPattern:
+		return PatternPlacer{}
+	case PlacerLayout:
+		return LayoutPlacer{Layout: code.Layout}
+	case PlacerNoGuess:
+		return NoGuessPlacer{}
+	default:
+		return UniformPlacer{}
+	}
+}
+
+// MineCandidates returns tiles that can get mines when first click is at firstX, firstY.
+//
+// If there are too many mines for policy,
+// neighbors of first click are added at the end.
+func (board *Board) MineCandidates(count, firstX, firstY int, policy FirstClickPolicy) [][2]int {
+	places := make([][2]int, 0, board.Width*board.Height)
+
+	for x := range board.Width {
+		for y := range board.Height {
+			if policy == FirstClickSafeTile {
+				if x == firstX && y == firstY {
+					continue
+				}
+			} else if abs(x-firstX) <= 1 && abs(y-firstY) <= 1 {
+				continue
+			}
+
+			places = append(places, [2]int{x, y})
+		}
+	}
+
+	if len(places) < count {
+		for x := firstX - 1; x <= firstX+1; x++ {
+			for y := firstY - 1; y <= firstY+1; y++ {
+				if !(x == firstX && y == firstY) && board.IsPosInBoard(x, y) {
+					places = append(places, [2]int{x, y})
+				}
+			}
+		}
+	}
+
+	return places
+}
+
+func shufflePlaces(places [][2]int, rng *rand.Rand) {
+	rng.Shuffle(len(places), func(i, j int) {
+		places[i], places[j] = places[j], places[i]
+	})
+}
+
+// ==========================
+// uniform
+// ==========================
+
+// UniformPlacer picks count tiles at random, it's what the game always did.
+type UniformPlacer struct{}
+
+func (UniformPlacer) PlaceMines(board *Board, count, firstX, firstY int, policy FirstClickPolicy, rng *rand.Rand) {
+	places := board.MineCandidates(count, firstX, firstY, policy)
+
+	for range 4 {
+		shufflePlaces(places, rng)
+	}
+
+	for i := 0; i < count; i++ {
+		board.Mines.Set(places[i][0], places[i][1], true)
+	}
+}
+
+// ==========================
+// clustered
+// ==========================
+
+// ClusteredPlacer places some mines at random,
+// then grows the rest next to mines that are already placed.
+// Board ends up with big openings and walls of mines.
+type ClusteredPlacer struct{}
+
+func (ClusteredPlacer) PlaceMines(board *Board, count, firstX, firstY int, policy FirstClickPolicy, rng *rand.Rand) {
+	places := board.MineCandidates(count, firstX, firstY, policy)
+	shufflePlaces(places, rng)
+
+	allowed := NewArray2D[bool](board.Width, board.Height)
+	for _, p := range places {
+		allowed.Set(p[0], p[1], true)
+	}
+
+	mines := make([][2]int, 0, count)
+	place := func(x, y int) {
+		board.Mines.Set(x, y, true)
+		mines = append(mines, [2]int{x, y})
+	}
+
+	// cluster centers
+	next := 0
+	for ; next < max(count/8, 1) && next < count; next++ {
+		place(places[next][0], places[next][1])
+	}
+
+	misses := 0
+	for len(mines) < count {
+		// clusters are stuck, start a new one
+		if misses >= 16 {
+			for board.Mines.Get(places[next][0], places[next][1]) {
+				next++
+			}
+			place(places[next][0], places[next][1])
+			misses = 0
+			continue
+		}
+
+		m := mines[rng.IntN(len(mines))]
+		x, y := m[0]+rng.IntN(3)-1, m[1]+rng.IntN(3)-1
+
+		if board.IsPosInBoard(x, y) && allowed.Get(x, y) && !board.Mines.Get(x, y) {
+			place(x, y)
+			misses = 0
+		} else {
+			misses++
+		}
+	}
+}
+
+// ==========================
+// gradient
+// ==========================
+
+// GradientPlacer makes mines denser towards one side of the board,
+// side is picked at random.
+// Far side has about 9 times as many mines as near side.
+type GradientPlacer struct{}
+
+func (GradientPlacer) PlaceMines(board *Board, count, firstX, firstY int, policy FirstClickPolicy, rng *rand.Rand) {
+	places := board.MineCandidates(count, firstX, firstY, policy)
+
+	side := rng.IntN(4)
+
+	// 0 at near side, 1 at far side
+	along := func(x, y int) float64 {
+		var t float64
+		switch side {
+		case 0:
+			t = float64(x) / float64(max(board.Width-1, 1))
+		case 1:
+			t = float64(board.Width-1-x) / float64(max(board.Width-1, 1))
+		case 2:
+			t = float64(y) / float64(max(board.Height-1, 1))
+		default:
+			t = float64(board.Height-1-y) / float64(max(board.Height-1, 1))
+		}
+		return t
+	}
+
+	// weighted sampling without replacement (Efraimidis-Spirakis),
+	// tiles with highest log(u)/weight win
+	keys := make([]float64, len(places))
+	for i, p := range places {
+		weight := 0.2 + 1.6*along(p[0], p[1])
+		u := 1 - rng.Float64() // never 0
+		keys[i] = math.Log(u) / weight
+	}
+
+	order := make([]int, len(places))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		if keys[a] > keys[b] {
+			return -1
+		} else if keys[a] < keys[b] {
+			return 1
+		}
+		return 0
+	})
+
+	for _, i := range order[:count] {
+		board.Mines.Set(places[i][0], places[i][1], true)
+	}
+}
+
+// ==========================
+// pattern
+// ==========================
+
+// mines that make classic patterns when tiles next to them are revealed
+var placerPatterns = [][][2]int{
+	{{0, 0}, {2, 0}}, // 1-2-1
+	{{0, 0}, {0, 2}},
+	{{0, 0}, {1, 0}}, // 1-2-2-1
+	{{0, 0}, {0, 1}},
+	{{0, 0}, {1, 1}}, // diagonal pair
+	{{1, 0}, {0, 1}},
+}
+
+// PatternPlacer places about half of the mines in pairs from placerPatterns
+// and the rest at random, so 1-2-1s and 1-2-2-1s come up more than usual.
+type PatternPlacer struct{}
+
+func (PatternPlacer) PlaceMines(board *Board, count, firstX, firstY int, policy FirstClickPolicy, rng *rand.Rand) {
+	places := board.MineCandidates(count, firstX, firstY, policy)
+	shufflePlaces(places, rng)
+
+	allowed := NewArray2D[bool](board.Width, board.Height)
+	for _, p := range places {
+		allowed.Set(p[0], p[1], true)
+	}
+
+	placed := 0
+
+	for range count {
+		if placed >= count/2 {
+			break
+		}
+
+		origin := places[rng.IntN(len(places))]
+		pattern := placerPatterns[rng.IntN(len(placerPatterns))]
+
+		fits := true
+		for _, offset := range pattern {
+			x, y := origin[0]+offset[0], origin[1]+offset[1]
+			if !board.IsPosInBoard(x, y) || !allowed.Get(x, y) || board.Mines.Get(x, y) {
+				fits = false
+				break
+			}
+		}
+		if !fits || placed+len(pattern) > count {
+			continue
+		}
+
+		for _, offset := range pattern {
+			board.Mines.Set(origin[0]+offset[0], origin[1]+offset[1], true)
+		}
+		placed += len(pattern)
+	}
+
+	for _, p := range places {
+		if placed >= count {
+			break
+		}
+		if !board.Mines.Get(p[0], p[1]) {
+			board.Mines.Set(p[0], p[1], true)
+			placed++
+		}
+	}
+}
+
+// ==========================
+// layout
+// ==========================
+
+// LayoutPlacer places mines exactly where layout says, first click and count are ignored.
+//
+// Layout has a bit for every tile, row by row, see ParseLayout.
+type LayoutPlacer struct {
+	Layout string
+}
+
+func (lp LayoutPlacer) PlaceMines(board *Board, count, firstX, firstY int, policy FirstClickPolicy, rng *rand.Rand) {
+	for i := range board.Mines.Data {
+		if i/8 < len(lp.Layout) && lp.Layout[i/8]&(1<<(i%8)) != 0 {
+			board.Mines.Data[i] = true
+		}
+	}
+}
+
+// returns number of mines in layout
+func layoutMineCount(layout string) int {
+	count := 0
+	for i := 0; i < len(layout); i++ {
+		count += bits.OnesCount8(layout[i])
+	}
+	return count
+}
+
+// ParseLayout makes a game code from a text drawing of a board,
+// for LayoutPlacer. Seed is left empty.
+//
+// Each line is a row, '*' or 'x' is a mine and anything else is a safe tile.
+// Empty lines and lines starting with '#' are ignored.
+//
+//	# 1-2-1 wall
+//	*.*.....
+//	........
+//	........
+func ParseLayout(text string) (GameCode, error) {
+	var code GameCode
+
+	var rows []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rows = append(rows, line)
+	}
+
+	if len(rows) <= 0 {
+		return code, errors.New("layout is empty")
+	}
+
+	code.Width = len(rows[0])
+	code.Height = len(rows)
+	code.Placer = PlacerLayout
+
+	layout := make([]byte, (code.Width*code.Height+7)/8)
+
+	for y, row := range rows {
+		if len(row) != code.Width {
+			return code, fmt.Errorf("layout row %d has %d tiles, expected %d", y+1, len(row), code.Width)
+		}
+		for x := range len(row) {
+			if row[x] == '*' || row[x] == 'x' || row[x] == 'X' {
+				i := x + y*code.Width
+				layout[i/8] |= 1 << (i % 8)
+				code.MineCount++
+			}
+		}
+	}
+
+	code.Layout = string(layout)
+
+	if err := code.Validate(); err != nil {
+		return code, err
+	}
+
+	return code, nil
+}
+
+// ==========================
+// no guess
+// ==========================
+
+// NoGuessPlacer draws boards with UniformPlacer
+// until solver can clear one without guessing (see RateBoard).
+//
+// If none is found after a while, the one with fewest guesses is used.
+// Bigger boards get fewer tries, see ratingCandidates.
+type NoGuessPlacer struct{}
+
+// boards drawn before settling for fewest guesses
+const noGuessMaxCandidates = 200
+
+func (NoGuessPlacer) PlaceMines(board *Board, count, firstX, firstY int, policy FirstClickPolicy, rng *rand.Rand) {
+	candidates := ratingCandidates(board.Width*board.Height, noGuessMaxCandidates)
+
+	var best []bool
+	bestGuesses := 0
+
+	for i := range candidates {
+		clear(board.Mines.Data)
+		UniformPlacer{}.PlaceMines(board, count, firstX, firstY, policy, rng)
+
+		maxGuesses := math.MaxInt
+		if i > 0 {
+			// only better boards matter
+			maxGuesses = bestGuesses - 1
+		}
+
+		guesses := board.rateUntil(firstX, firstY, maxGuesses).Guesses
+		if guesses <= 0 {
+			return
+		}
+		if i == 0 || guesses < bestGuesses {
+			best = slices.Clone(board.Mines.Data)
+			bestGuesses = guesses
+		}
+	}
+
+	copy(board.Mines.Data, best)
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ==============================================
+// positions
+// ==============================================
+//
+// ParseBoard reads a board in the middle of a game from a text drawing,
+// for lessons and puzzles that start from a hand made position.
+//
+// Each string is a row and each character is a tile:
+//
+//	# : hidden safe tile
+//	* : hidden mine
+//	F : flagged mine
+//	. : revealed safe tile
+//	0-8 : revealed safe tile, must match mines around it
+//
+// For example
+//
+//	#*##*#
+//	111111
+//	......
+
+// ParseBoard makes a board from rows of a text drawing (see above)
+func ParseBoard(rows []string) (Board, error) {
+	if len(rows) <= 0 || len(rows[0]) <= 0 {
+		return Board{}, errors.New("board is empty")
+	}
+
+	width, height := len(rows[0]), len(rows)
+
+	board := NewBoard(width, height)
+
+	for y, row := range rows {
+		if len(row) != width {
+			return board, fmt.Errorf("board row %d has %d tiles, expected %d", y+1, len(row), width)
+		}
+		for x := range len(row) {
+			switch c := row[x]; {
+			case c == '#':
+				// pass
+			case c == '*':
+				board.Mines.Set(x, y, true)
+			case c == 'F':
+				board.Mines.Set(x, y, true)
+				board.Flags.Set(x, y, true)
+			case c == '.' || ('0' <= c && c <= '8'):
+				board.Revealed.Set(x, y, true)
+			default:
+				return board, fmt.Errorf("board row %d has unknown tile %q", y+1, rune(c))
+			}
+		}
+	}
+
+	// check numbers after every mine is placed
+	for y, row := range rows {
+		for x := range len(row) {
+			if c := row[x]; '0' <= c && c <= '8' {
+				if count := board.GetNeighborMineCount(x, y); count != int(c-'0') {
+					return board, fmt.Errorf("board has %c at %d, %d but there are %d mines around it", c, x, y, count)
+				}
+			}
+		}
+	}
+
+	if board.HasNoMines() {
+		return board, errors.New("board has no mines")
+	}
+	if board.IsAllSafeTileRevealed() {
+		return board, errors.New("board is already cleared")
+	}
+
+	return board, nil
+}
+
+// MineLayout returns mines of board in the format LayoutPlacer takes
+func (board *Board) MineLayout() string {
+	layout := make([]byte, (board.Width*board.Height+7)/8)
+	for i, mine := range board.Mines.Data {
+		if mine {
+			layout[i/8] |= 1 << (i % 8)
+		}
+	}
+	return string(layout)
+}
+
+// MineCount returns number of mines on board
+func (board *Board) MineCount() int {
+	count := 0
+	for _, mine := range board.Mines.Data {
+		if mine {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,218 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ==============================================
+// board rating
+// ==============================================
+//
+// RateBoard plays a board with the solver and records how much work it took.
+// Mine count alone says little about a board,
+// two boards with the same mine count can be a walk or a coin flip fest.
+//
+// Variants are ignored, board is rated as a normal board.
+
+type BoardRating struct {
+	// 3BV of the board, see Board.Get3BV
+	BBBV int `json:"3bv"`
+
+	// times solver had to look at the board again to find safe tiles
+	LogicalSteps int `json:"logical_steps"`
+
+	// hardest rule solver needed
+	HardestRule DeductionRule `json:"hardest_rule"`
+
+	// times there was nothing left to prove
+	Guesses int `json:"guesses"`
+
+	// estimated chance of each guess being safe, for the best guess there was
+	GuessOdds []float64 `json:"guess_odds,omitempty"`
+
+	// chance of clearing the board without hitting a mine, 1 if there were no guesses
+	SafeChance float64 `json:"safe_chance"`
+}
+
+func (r BoardRating) String() string {
+	sb := &strings.Builder{}
+
+	fmt.Fprintf(sb, "3BV %d, %d steps, hardest %v", r.BBBV, r.LogicalSteps, r.HardestRule)
+
+	switch r.Guesses {
+	case 0:
+		sb.WriteString(", no guess")
+	case 1:
+		fmt.Fprintf(sb, ", 1 guess at %.0f%%", r.SafeChance*100)
+	default:
+		fmt.Fprintf(sb, ", %d guesses at %.0f%%", r.Guesses, r.SafeChance*100)
+	}
+
+	return sb.String()
+}
+
+// boards with more tiles than this aren't rated by RateBoard,
+// solver looks at the whole board for every step so it gets slow fast
+const RateBoardMaxTiles = 50 * 50
+
+// RateBoard rates the board of code when first click is at firstX, firstY.
+//
+// Returns zero BBBV if board is too big, see RateBoardMaxTiles.
+func RateBoard(code GameCode, firstX, firstY int) BoardRating {
+	board := NewBoard(code.Width, code.Height)
+	if !board.IsPosInBoard(firstX, firstY) || code.Width*code.Height > RateBoardMaxTiles {
+		return BoardRating{SafeChance: 1}
+	}
+
+	board.PlaceMinesForCode(code, firstX, firstY)
+
+	return board.rate(firstX, firstY)
+}
+
+// how many boards to rate when drawing up to maxCandidates of them,
+// bigger boards get fewer so that first click doesn't take forever.
+// It only depends on board size so boards stay the same for the same code.
+// Expert gets all of them.
+func ratingCandidates(tiles, maxCandidates int) int {
+	const work = 480 * 480
+
+	if tiles <= 0 {
+		return maxCandidates
+	}
+	return max(min(maxCandidates*work/(tiles*tiles), maxCandidates), 1)
+}
+
+// rate plays a copy of board that has mines placed, from firstX, firstY
+func (board *Board) rate(firstX, firstY int) BoardRating {
+	return board.rateUntil(firstX, firstY, math.MaxInt)
+}
+
+// rateUntil is rate that gives up once there are more guesses than maxGuesses
+func (board *Board) rateUntil(firstX, firstY int, maxGuesses int) BoardRating {
+	rating := BoardRating{SafeChance: 1}
+
+	played := NewBoard(board.Width, board.Height)
+	copy(played.Mines.Data, board.Mines.Data)
+	board = &played
+
+	// mines are already placed, so these are never used
+	const (
+		minesToSpawn = 0
+		firstClick   = FirstClickSafeArea
+	)
+	var seed [32]byte
+
+	state := board.InteractAt(
+		firstX, firstY, InteractionTypeStep, GameStatePlaying,
+		minesToSpawn, firstClick, seed,
+	)
+
+	rating.BBBV = board.Get3BV()
+
+	step := func(x, y int) {
+		state = board.InteractAt(
+			x, y, InteractionTypeStep, state,
+			minesToSpawn, firstClick, seed,
+		)
+	}
+
+	for state == GameStatePlaying {
+		sol := board.solve()
+
+		var safeTiles []int
+		for i, k := range sol.known {
+			if k == tileSafe && !board.Revealed.Data[i] {
+				safeTiles = append(safeTiles, i)
+			}
+		}
+
+		if len(safeTiles) > 0 {
+			rating.LogicalSteps++
+			rating.HardestRule = max(rating.HardestRule, sol.hardest)
+
+			for _, i := range safeTiles {
+				step(i%board.Width, i/board.Width)
+			}
+			continue
+		}
+
+		// ==========================
+		// guess
+		// ==========================
+		odds := board.guessOdds(sol)
+
+		best, bestSafe := -1, -1
+		for i, o := range odds {
+			if o < 0 {
+				continue
+			}
+			if best < 0 || o > odds[best] {
+				best = i
+			}
+			if !board.Mines.Data[i] && (bestSafe < 0 || o > odds[bestSafe]) {
+				bestSafe = i
+			}
+		}
+		if bestSafe < 0 {
+			// can't happen, game would have been won
+			break
+		}
+
+		rating.Guesses++
+		rating.GuessOdds = append(rating.GuessOdds, odds[best])
+		rating.SafeChance *= odds[best]
+
+		if rating.Guesses > maxGuesses {
+			break
+		}
+
+		// we know where mines are, so carry on as if the guess went well
+		step(bestSafe%board.Width, bestSafe/board.Width)
+	}
+
+	return rating
+}
+
+// guessOdds estimates chance of each tile being safe, -1 for tiles that can't be guessed.
+//
+// Tile next to numbers takes the worst odds of its numbers,
+// other tiles share mines that are left evenly.
+// It's not exact, but it's what people do in their heads.
+func (board *Board) guessOdds(sol solution) []float64 {
+	odds := make([]float64, len(sol.known))
+	for i := range odds {
+		odds[i] = -1
+	}
+
+	// last one is the whole board
+	local := sol.constraints[:len(sol.constraints)-1]
+	global := sol.constraints[len(sol.constraints)-1]
+
+	nextToNumber := make(map[int]bool)
+	for _, c := range local {
+		if len(c.tiles) <= 0 {
+			continue
+		}
+		chance := 1 - float64(c.count)/float64(len(c.tiles))
+		for _, t := range c.tiles {
+			if !nextToNumber[t] || chance < odds[t] {
+				odds[t] = chance
+				nextToNumber[t] = true
+			}
+		}
+	}
+
+	density := 0.0
+	if len(global.tiles) > 0 {
+		density = float64(global.count) / float64(len(global.tiles))
+	}
+	for _, t := range global.tiles {
+		if !nextToNumber[t] {
+			odds[t] = 1 - density
+		}
+	}
+
+	return odds
+}
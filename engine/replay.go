@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ==============================================
+// replay
+// ==============================================
+//
+// Replay is a list of every interaction that was
+// given to Board.InteractAt and when it was given.
+//
+// Board is recreated from game code,
+// so replaying events in order gives the exact same game.
+//
+// It's saved as json so that other programs
+// (possibly not written in go) can read it.
+
+const ReplayVersion = 1
+
+type ReplayEvent struct {
+	// time since the first interaction in milliseconds
+	TimeMs int64 `json:"t"`
+
+	Type BoardInteractionType `json:"type"`
+
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type Replay struct {
+	Version int `json:"version"`
+
+	// game code of the board
+	Code string `json:"code"`
+
+	Result GameState `json:"result"`
+
+	// why the game was stopped before it ended (see ReplayRecorder.Abort),
+	// Result is GameStatePlaying then
+	Aborted string `json:"aborted,omitempty"`
+
+	// time between the first interaction and the end of the game
+	DurationMs int64 `json:"duration_ms"`
+
+	// 3BV of the board, see Board.Get3BV
+	BBBV int `json:"3bv"`
+
+	// times player was shown a hint or took a move back,
+	// see ReplayRecorder.RecordHint and RecordUndo
+	Hints int `json:"hints,omitempty"`
+	Undos int `json:"undos,omitempty"`
+
+	// rating of the board from the first step, see RateBoard.
+	// nil if it wasn't rated, ResultFromReplay rates it then
+	Rating *BoardRating `json:"rating,omitempty"`
+
+	Events []ReplayEvent `json:"events"`
+}
+
+func (r *Replay) Duration() time.Duration {
+	return time.Duration(r.DurationMs) * time.Millisecond
+}
+
+// Rate rates the board from the first step and saves it in Rating,
+// if it's not there yet. It can take a while on big boards.
+func (r *Replay) Rate() {
+	if r.Rating != nil {
+		return
+	}
+
+	code, err := ParseGameCode(r.Code)
+	if err != nil {
+		return
+	}
+	if x, y, ok := r.FirstStep(); ok {
+		rating := RateBoard(code, x, y)
+		r.Rating = &rating
+	}
+}
+
+// FirstStep returns position of the first step, where mines were placed
+func (r *Replay) FirstStep() (int, int, bool) {
+	for _, event := range r.Events {
+		if event.Type == InteractionTypeStep {
+			return event.X, event.Y, true
+		}
+	}
+	return 0, 0, false
+}
+
+func SaveReplay(path string, replay Replay) error {
+	jsonBytes, err := json.MarshalIndent(replay, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, jsonBytes, 0644)
+}
+
+func LoadReplay(path string) (Replay, error) {
+	var replay Replay
+
+	jsonBytes, err := os.ReadFile(path)
+	if err != nil {
+		return replay, err
+	}
+
+	if err = json.Unmarshal(jsonBytes, &replay); err != nil {
+		return replay, err
+	}
+
+	if replay.Version != ReplayVersion {
+		return replay, fmt.Errorf("unsupported replay version %d", replay.Version)
+	}
+
+	return replay, nil
+}
+
+// returns a file name that is unique enough for a replay
+//
+// for example:
+//
+//	replay-2024-12-01-15-04-05-won.json
+func ReplayFileName(replay Replay, t time.Time) string {
+	result := replay.Result.String()
+	if replay.Aborted != "" {
+		result = "aborted"
+	}
+	return fmt.Sprintf("replay-%s-%s.json", t.Format("2006-01-02-15-04-05"), result)
+}
+
+type ReplayRecorder struct {
+	Replay Replay
+
+	startTime time.Time
+	finished  bool
+}
+
+func NewReplayRecorder(code GameCode) *ReplayRecorder {
+	rr := new(ReplayRecorder)
+
+	rr.Replay.Version = ReplayVersion
+	rr.Replay.Code = code.String()
+	rr.Replay.Result = GameStatePlaying
+
+	return rr
+}
+
+func (rr *ReplayRecorder) Record(interaction BoardInteractionType, x, y int) {
+	if rr.finished || interaction == InteractionTypeNone {
+		return
+	}
+
+	now := time.Now()
+
+	if len(rr.Replay.Events) <= 0 {
+		rr.startTime = now
+	}
+
+	rr.Replay.Events = append(rr.Replay.Events, ReplayEvent{
+		TimeMs: now.Sub(rr.startTime).Milliseconds(),
+		Type:   interaction,
+		X:      x,
+		Y:      y,
+	})
+}
+
+// SetRating saves rating of the board with the replay,
+// it can be set after the game has ended
+func (rr *ReplayRecorder) SetRating(rating BoardRating) {
+	rr.Replay.Rating = &rating
+}
+
+// RecordHint counts a hint shown to player, like a lesson telling what to look at
+func (rr *ReplayRecorder) RecordHint() {
+	if !rr.finished {
+		rr.Replay.Hints++
+	}
+}
+
+// RecordUndo counts a move player took back.
+// Events stay as they were, so only games that can replay undos should have them
+func (rr *ReplayRecorder) RecordUndo() {
+	if !rr.finished {
+		rr.Replay.Undos++
+	}
+}
+
+// time since the first interaction, 0 if there wasn't one
+func (rr *ReplayRecorder) Elapsed() time.Duration {
+	if len(rr.Replay.Events) <= 0 {
+		return 0
+	}
+	return time.Since(rr.startTime)
+}
+
+// time of the last recorded event since the first one, as replay has it
+func (rr *ReplayRecorder) LastEventTime() time.Duration {
+	if len(rr.Replay.Events) <= 0 {
+		return 0
+	}
+	return time.Duration(rr.Replay.Events[len(rr.Replay.Events)-1].TimeMs) * time.Millisecond
+}
+
+// call it when game has ended
+func (rr *ReplayRecorder) Finish(result GameState, board Board) {
+	rr.FinishAt(result, board, rr.LastEventTime())
+}
+
+// FinishAt is Finish for games that didn't end with an interaction,
+// duration is time between the first interaction and the end
+func (rr *ReplayRecorder) FinishAt(result GameState, board Board, duration time.Duration) {
+	if rr.finished {
+		return
+	}
+	rr.finished = true
+
+	rr.Replay.Result = result
+	rr.Replay.BBBV = board.Get3BV()
+	rr.Replay.DurationMs = duration.Milliseconds()
+}
+
+// Abort is Finish for games that were stopped before they ended,
+// replay keeps GameStatePlaying as result and reason as Replay.Aborted
+func (rr *ReplayRecorder) Abort(reason string, board Board) {
+	if rr.finished {
+		return
+	}
+	rr.Finish(GameStatePlaying, board)
+	rr.Replay.Aborted = reason
+}
@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ==============================================
+// replay validation
+// ==============================================
+//
+// Replay is made by the client, so nothing in it can be trusted.
+// ValidateReplay recreates the board from game code,
+// plays every event through InteractAt and checks
+// that result, duration and 3BV the replay claims are what actually happened.
+//
+// Time attack replays can also be lost by running out of time,
+// which is checked against the clock the events made.
+
+var ErrReplayInvalid = errors.New("invalid replay")
+
+type ReplayValidateOptions struct {
+	// events that are closer than this are rejected
+	// as too fast to be made by a person
+	//
+	// 0 means no check
+	MinEventInterval time.Duration
+
+	// won replays must take at least this much for every 3BV but the first,
+	// which is the click that starts the clock
+	//
+	// 0 means no check
+	MinTimePer3BV time.Duration
+}
+
+// game runs at 120 ticks per second, but ebiten runs Updates back to back
+// to catch up when it falls behind, so events of a real game
+// can be recorded in the same millisecond. Wall clock time between events
+// can't tell a person from a program, so it's not checked by default
+var DefaultReplayValidateOptions = ReplayValidateOptions{
+	MinEventInterval: 0,
+}
+
+// for replays that go on a leaderboard, where a forged replay
+// with every event at 0ms must not win.
+//
+// Nobody clicks twice in 20ms or clears 20 3BV a second
+// (records are under 10), a real game that stuttered
+// and recorded two clicks closer than that is rejected along with them
+var StrictReplayValidateOptions = ReplayValidateOptions{
+	MinEventInterval: time.Millisecond * 20,
+	MinTimePer3BV:    time.Millisecond * 50,
+}
+
+// what actually happened in the replay
+type ReplayReport struct {
+	Code GameCode
+
+	Result   GameState
+	Duration time.Duration
+	BBBV     int
+
+	// see Replay.Aborted
+	Aborted string
+
+	EventCount int
+}
+
+func invalidReplay(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrReplayInvalid, fmt.Sprintf(format, args...))
+}
+
+// ValidateReplay returns ErrReplayInvalid (wrapped with the reason)
+// if replay is impossible or claims something that didn't happen.
+func ValidateReplay(replay Replay, opts ReplayValidateOptions) (ReplayReport, error) {
+	var report ReplayReport
+
+	if replay.Version != ReplayVersion {
+		return report, invalidReplay("unsupported replay version %d", replay.Version)
+	}
+
+	code, err := ParseGameCode(replay.Code)
+	if err != nil {
+		return report, invalidReplay("bad game code: %v", err)
+	}
+	report.Code = code
+
+	board := NewBoard(code.Width, code.Height)
+	state := GameStatePlaying
+
+	var flagsMatch *FlagsMatch
+	if code.Variants&GameVariantFlags != 0 {
+		flagsMatch = NewFlagsMatch(code.Width, code.Height)
+	}
+
+	var timeAttack *TimeAttack
+	var before Board
+	if code.Variants&GameVariantTimeAttack != 0 {
+		ta := NewTimeAttack(code)
+		timeAttack = &ta
+		before = NewBoard(code.Width, code.Height)
+	}
+
+	minIntervalMs := opts.MinEventInterval.Milliseconds()
+
+	for i, event := range replay.Events {
+		if state != GameStatePlaying {
+			return report, invalidReplay("event %d happened after the game ended", i)
+		}
+
+		// ==========================
+		// check timing
+		// ==========================
+		if i == 0 {
+			if event.TimeMs != 0 {
+				return report, invalidReplay("first event is at %dms, not at 0ms", event.TimeMs)
+			}
+		} else {
+			prevTimeMs := replay.Events[i-1].TimeMs
+
+			if event.TimeMs < prevTimeMs {
+				return report, invalidReplay("event %d is earlier than the event before it", i)
+			}
+			if event.TimeMs-prevTimeMs < minIntervalMs {
+				return report, invalidReplay(
+					"event %d is only %dms after the event before it", i, event.TimeMs-prevTimeMs)
+			}
+		}
+
+		// ==========================
+		// check event
+		// ==========================
+		if event.Type != InteractionTypeStep &&
+			event.Type != InteractionTypeFlag &&
+			event.Type != InteractionTypeCheck {
+			return report, invalidReplay("event %d has invalid type %v", i, event.Type)
+		}
+		if !board.IsPosInBoard(event.X, event.Y) {
+			return report, invalidReplay("event %d at %d, %d is out of board", i, event.X, event.Y)
+		}
+		if timeAttack != nil && timeAttack.TimeUp(time.Duration(event.TimeMs)*time.Millisecond) {
+			return report, invalidReplay("event %d happened after time ran out", i)
+		}
+
+		if timeAttack != nil {
+			board.SaveTo(before)
+		}
+
+		state = board.InteractWithCode(event.X, event.Y, event.Type, state, code, flagsMatch)
+
+		if timeAttack != nil {
+			timeAttack.OnReveal(before, board)
+		}
+	}
+
+	report.Result = state
+	report.BBBV = board.Get3BV()
+	report.EventCount = len(replay.Events)
+	if len(replay.Events) > 0 {
+		report.Duration = time.Duration(replay.Events[len(replay.Events)-1].TimeMs) * time.Millisecond
+	}
+
+	// ran out of time, which happens exactly at the limit
+	if timeAttack != nil && state == GameStatePlaying && replay.Result == GameStateLost &&
+		replay.Duration() == timeAttack.Limit {
+		report.Result = GameStateLost
+		report.Duration = timeAttack.Limit
+	}
+
+	// ==========================
+	// check claims
+	// ==========================
+	if replay.Result != report.Result {
+		return report, invalidReplay("replay claims %v but game was %v", replay.Result, report.Result)
+	}
+	if replay.Aborted != "" && report.Result != GameStatePlaying {
+		return report, invalidReplay("replay claims it was aborted but game ended")
+	}
+	report.Aborted = replay.Aborted
+	if report.Result == GameStateWon && opts.MinTimePer3BV > 0 &&
+		report.Duration < opts.MinTimePer3BV*time.Duration(report.BBBV-1) {
+		return report, invalidReplay("game of 3BV %d was won in only %v", report.BBBV, report.Duration)
+	}
+	if replay.Duration() != report.Duration {
+		return report, invalidReplay("replay claims %v but game took %v", replay.Duration(), report.Duration)
+	}
+	if replay.BBBV != report.BBBV {
+		return report, invalidReplay("replay claims 3BV of %d but board has %d", replay.BBBV, report.BBBV)
+	}
+
+	return report, nil
+}
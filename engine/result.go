@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"time"
+)
+
+// ==============================================
+// game result
+// ==============================================
+//
+// GameResult is everything about a finished game.
+//
+// It's made from the replay, so it can be made
+// for a game that was just played as well as for a saved replay.
+
+type GameResult struct {
+	Outcome  GameState
+	Duration time.Duration
+
+	// board size, mine count, variants and seed
+	Code GameCode
+
+	// tile that lost the game (stepped mine or chorded number),
+	// -1, -1 if game wasn't lost
+	LosingX int
+	LosingY int
+
+	// every interaction by type, including ones that didn't change anything
+	Clicks [InteractionTypeSize]int
+
+	// times a flag was put on a tile
+	FlagsPlaced int
+	// flags on the board when game ended
+	FlagsLeft int
+
+	TilesRevealed int
+
+	// mines stepped on without losing, only for games with lives
+	MinesHit int
+
+	// hints shown and moves taken back, see Replay.Hints and Replay.Undos
+	HintsUsed int
+	UndosUsed int
+
+	// only for time attack, time left on the clock when game was won
+	// and if game was lost by running out of time
+	TimeLeft time.Duration
+	TimedOut bool
+
+	// 3BV of the board, see Board.Get3BV
+	BBBV int
+
+	// zero if there was no step
+	Rating BoardRating
+
+	// why game was lost, nil if it wasn't
+	Loss *LossAnalysis
+
+	// full interaction log
+	Replay Replay
+}
+
+func (r *GameResult) Won() bool {
+	return r.Outcome == GameStateWon
+}
+
+// ResultFromReplay plays replay and returns what happened.
+//
+// Replay is not validated, use ValidateReplay first if it's not trusted.
+func ResultFromReplay(replay Replay) (GameResult, error) {
+	result := GameResult{
+		LosingX: -1,
+		LosingY: -1,
+		Replay:  replay,
+	}
+
+	code, err := ParseGameCode(replay.Code)
+	if err != nil {
+		return result, err
+	}
+	result.Code = code
+
+	board := NewBoard(code.Width, code.Height)
+	state := GameStatePlaying
+
+	var flagsMatch *FlagsMatch
+	if code.Variants&GameVariantFlags != 0 {
+		flagsMatch = NewFlagsMatch(code.Width, code.Height)
+	}
+
+	var timeAttack *TimeAttack
+	var before Board
+	if code.Variants&GameVariantTimeAttack != 0 {
+		ta := NewTimeAttack(code)
+		timeAttack = &ta
+		before = NewBoard(code.Width, code.Height)
+	}
+
+	for _, event := range replay.Events {
+		if event.Type < 0 || event.Type >= InteractionTypeSize {
+			continue
+		}
+		result.Clicks[event.Type]++
+
+		if state != GameStatePlaying || !board.IsPosInBoard(event.X, event.Y) {
+			continue
+		}
+
+		flagged := board.Flags.Get(event.X, event.Y)
+
+		if timeAttack != nil {
+			board.SaveTo(before)
+		}
+
+		state = board.InteractWithCode(event.X, event.Y, event.Type, state, code, flagsMatch)
+
+		if timeAttack != nil {
+			timeAttack.OnReveal(before, board)
+		}
+
+		if !flagged && board.Flags.Get(event.X, event.Y) {
+			result.FlagsPlaced++
+		}
+		if state == GameStateLost {
+			result.LosingX, result.LosingY = event.X, event.Y
+		}
+	}
+
+	result.Outcome = state
+	if state == GameStateLost {
+		if loss, err := AnalyzeLoss(replay); err == nil {
+			result.Loss = &loss
+		}
+	}
+	result.BBBV = board.Get3BV()
+	if replay.Rating != nil {
+		result.Rating = *replay.Rating
+	} else if x, y, ok := replay.FirstStep(); ok {
+		result.Rating = RateBoard(code, x, y)
+	}
+	if len(replay.Events) > 0 {
+		result.Duration = time.Duration(replay.Events[len(replay.Events)-1].TimeMs) * time.Millisecond
+	}
+
+	if timeAttack != nil {
+		if state == GameStateWon {
+			timeAttack.OnClear(board, result.Duration)
+			result.TimeLeft = timeAttack.Left(result.Duration)
+		} else if state == GameStatePlaying && replay.Result == GameStateLost {
+			// replay doesn't have an event for running out of time,
+			// see ValidateReplay for checking it did
+			result.Outcome = GameStateLost
+			result.Duration = timeAttack.Limit
+			result.TimedOut = true
+		}
+	}
+
+	if code.Lives > 0 {
+		result.MinesHit = board.HitMineCount()
+	}
+
+	result.HintsUsed = replay.Hints
+	result.UndosUsed = replay.Undos
+
+	for i := range board.Revealed.Data {
+		if board.Revealed.Data[i] {
+			result.TilesRevealed++
+		}
+		if board.Flags.Data[i] {
+			result.FlagsLeft++
+		}
+	}
+
+	return result, nil
+}
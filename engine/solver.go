@@ -0,0 +1,443 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ==============================================
+// solver
+// ==============================================
+//
+// Solver only looks at what player can see:
+// revealed numbers and how many mines are on the board.
+// Flags are ignored since player can put them anywhere.
+//
+// It's not a complete solver. It uses each number alone
+// and pairs of numbers that share tiles, which is how people play.
+// Anything it can't prove is treated as a guess.
+// FindSafeTiles, ForgiveGuess and PunishGuess need every tile that can be proven,
+// they go through arrangements instead.
+
+// says exactly count of tiles are mines
+type constraint struct {
+	// indices into Array2D.Data, sorted
+	tiles []int
+	count int
+
+	// index of the number it came from, -1 for mine count
+	at int
+}
+
+// constraints of revealed numbers that have unrevealed neighbors
+func (board *Board) visibleConstraints() []constraint {
+	var constraints []constraint
+
+	for y := range board.Height {
+		for x := range board.Width {
+			if !board.Revealed.Get(x, y) || board.Mines.Get(x, y) {
+				continue
+			}
+
+			c := constraint{count: board.GetNeighborMineCount(x, y), at: x + y*board.Width}
+
+			iter := NewBoardIterator(x-1, y-1, x+1, y+1)
+			for iter.HasNext() {
+				nx, ny := iter.GetNext()
+				if !board.IsPosInBoard(nx, ny) {
+					continue
+				}
+				if !board.Revealed.Get(nx, ny) {
+					c.tiles = append(c.tiles, nx+ny*board.Width)
+				} else if board.Mines.Get(nx, ny) {
+					// claimed mine in flags variant
+					c.count--
+				}
+			}
+
+			if len(c.tiles) > 0 {
+				slices.Sort(c.tiles)
+				constraints = append(constraints, c)
+			}
+		}
+	}
+
+	return constraints
+}
+
+// returns tiles only in a, tiles only in b and number of tiles in both
+func splitConstraints(a, b constraint) ([]int, []int, int) {
+	var onlyA, onlyB []int
+	both := 0
+
+	i, j := 0, 0
+	for i < len(a.tiles) || j < len(b.tiles) {
+		switch {
+		case j >= len(b.tiles) || (i < len(a.tiles) && a.tiles[i] < b.tiles[j]):
+			onlyA = append(onlyA, a.tiles[i])
+			i++
+		case i >= len(a.tiles) || b.tiles[j] < a.tiles[i]:
+			onlyB = append(onlyB, b.tiles[j])
+			j++
+		default:
+			both++
+			i++
+			j++
+		}
+	}
+
+	return onlyA, onlyB, both
+}
+
+// DeductionRule is a kind of reasoning solver uses, from easiest to hardest
+type DeductionRule int
+
+const (
+	DeductionNone DeductionRule = iota
+
+	// one number alone (number equals hidden tiles, or is already satisfied)
+	DeductionSingle
+
+	// two numbers that share tiles
+	DeductionPair
+
+	// number of mines left on the board
+	DeductionMineCount
+
+	DeductionRuleSize
+)
+
+var DeductionRuleStrs = [DeductionRuleSize]string{
+	"none",
+	"single",
+	"pair",
+	"mine-count",
+}
+
+func (r DeductionRule) String() string {
+	if r < 0 || r >= DeductionRuleSize {
+		return fmt.Sprintf("DeductionRule(%d)", int(r))
+	}
+	return DeductionRuleStrs[r]
+}
+
+func (r DeductionRule) MarshalText() ([]byte, error) {
+	if r < 0 || r >= DeductionRuleSize {
+		return nil, fmt.Errorf("invalid deduction rule %d", int(r))
+	}
+	return []byte(DeductionRuleStrs[r]), nil
+}
+
+func (r *DeductionRule) UnmarshalText(text []byte) error {
+	for i := DeductionRule(0); i < DeductionRuleSize; i++ {
+		if string(text) == DeductionRuleStrs[i] {
+			*r = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown deduction rule %q", string(text))
+}
+
+const (
+	tileUnknown = -1
+	tileSafe    = 0
+	tileMine    = 1
+)
+
+// what solver found out
+type solution struct {
+	// tileUnknown, tileSafe or tileMine for every tile
+	known []int8
+
+	// constraints that are left, with known tiles removed
+	constraints []constraint
+
+	// hardest rule that was needed
+	hardest DeductionRule
+
+	// for every tile solver found out,
+	// rule it used and indices of constraints it used
+	rules   []DeductionRule
+	reasons [][]int
+}
+
+// solve uses easier rules first and only goes to harder ones when easier ones find nothing
+func (board *Board) solve() solution {
+	known := make([]int8, len(board.Mines.Data))
+	for i := range known {
+		known[i] = tileUnknown
+		if board.Revealed.Data[i] {
+			known[i] = tileSafe
+			if board.Mines.Data[i] {
+				known[i] = tileMine
+			}
+		}
+	}
+
+	constraints := board.visibleConstraints()
+	localCount := len(constraints)
+
+	// whole board, there's no way player doesn't know the mine count
+	{
+		global := constraint{at: -1}
+		for i := range board.Mines.Data {
+			if board.Revealed.Data[i] {
+				continue
+			}
+			global.tiles = append(global.tiles, i)
+			if board.Mines.Data[i] {
+				global.count++
+			}
+		}
+		constraints = append(constraints, global)
+	}
+
+	rules := make([]DeductionRule, len(known))
+	reasons := make([][]int, len(known))
+
+	// rule is the rule being tried, used to remember why tiles are known
+	rule := DeductionNone
+
+	mark := func(tiles []int, value int8, reason ...int) bool {
+		changed := false
+		for _, t := range tiles {
+			if known[t] == tileUnknown {
+				known[t] = value
+				rules[t] = rule
+				reasons[t] = reason
+				changed = true
+			}
+		}
+		return changed
+	}
+
+	single := func(ci int) bool {
+		c := constraints[ci]
+		if c.count <= 0 {
+			return mark(c.tiles, tileSafe, ci)
+		} else if c.count >= len(c.tiles) {
+			return mark(c.tiles, tileMine, ci)
+		}
+		return false
+	}
+
+	pair := func(ai, bi int) bool {
+		a, b := constraints[ai], constraints[bi]
+
+		onlyA, onlyB, both := splitConstraints(a, b)
+		if both <= 0 {
+			return false
+		}
+
+		// mines shared by a and b is between these
+		sharedMin := max(a.count-len(onlyA), 0)
+		sharedMax := min(both, a.count)
+
+		changed := false
+		if b.count-sharedMax >= len(onlyB) {
+			changed = mark(onlyB, tileMine, ai, bi) || changed
+		}
+		if b.count-sharedMin <= 0 {
+			changed = mark(onlyB, tileSafe, ai, bi) || changed
+		}
+		return changed
+	}
+
+	hardest := DeductionNone
+
+	for {
+		// remove tiles we know about
+		for ci := range constraints {
+			c := &constraints[ci]
+			c.tiles = slices.DeleteFunc(c.tiles, func(t int) bool {
+				if known[t] == tileMine {
+					c.count--
+				}
+				return known[t] != tileUnknown
+			})
+		}
+
+		local := constraints[:localCount]
+		global := localCount
+
+		// numbers alone
+		rule = DeductionSingle
+		changed := false
+		for ci := range local {
+			changed = single(ci) || changed
+		}
+		if changed {
+			hardest = max(hardest, DeductionSingle)
+			continue
+		}
+
+		// pairs of numbers
+		rule = DeductionPair
+		byTile := make(map[int][]int)
+		for ci, c := range local {
+			for _, t := range c.tiles {
+				byTile[t] = append(byTile[t], ci)
+			}
+		}
+		for ai, a := range local {
+			checked := make(map[int]bool)
+			for _, t := range a.tiles {
+				for _, bi := range byTile[t] {
+					if bi == ai || checked[bi] {
+						continue
+					}
+					checked[bi] = true
+					changed = pair(ai, bi) || changed
+				}
+			}
+		}
+		if changed {
+			hardest = max(hardest, DeductionPair)
+			continue
+		}
+
+		// mine count, alone and with every number
+		rule = DeductionMineCount
+		changed = single(global)
+		for ci := range local {
+			changed = pair(ci, global) || changed
+			changed = pair(global, ci) || changed
+		}
+		if changed {
+			hardest = max(hardest, DeductionMineCount)
+			continue
+		}
+
+		break
+	}
+
+	return solution{
+		known:       known,
+		constraints: constraints,
+		hardest:     hardest,
+		rules:       rules,
+		reasons:     reasons,
+	}
+}
+
+// SolverSafeTiles returns unrevealed tiles solver proves safe
+// with the rules people use (see DeductionRule), some of FindSafeTiles.
+//
+// Returns nothing if mines are not placed yet.
+func (board *Board) SolverSafeTiles() [][2]int {
+	if board.HasNoMines() {
+		return nil
+	}
+
+	var safeTiles [][2]int
+	for i, k := range board.solve().known {
+		if k == tileSafe && !board.Revealed.Data[i] {
+			safeTiles = append(safeTiles, [2]int{i % board.Width, i / board.Width})
+		}
+	}
+
+	return safeTiles
+}
+
+// FindSafeTiles returns unrevealed tiles that can be proven safe,
+// that is tiles with no mine in every arrangement that agrees with what player can see
+// (see arrangements). Unlike solve, it finds every one of them.
+//
+// Returns nothing if mines are not placed yet.
+func (board *Board) FindSafeTiles() [][2]int {
+	if board.HasNoMines() {
+		return nil
+	}
+
+	a := board.arrangements()
+	canMine, _ := a.possible(board)
+
+	safe := make([]bool, len(canMine))
+	for i := range canMine {
+		safe[i] = !board.Revealed.Data[i] && !canMine[i]
+	}
+
+	// solver can still prove some tiles of unresolved components
+	if !a.resolved() {
+		for i, k := range board.solve().known {
+			if k == tileSafe && !board.Revealed.Data[i] {
+				safe[i] = true
+			}
+		}
+	}
+
+	var safeTiles [][2]int
+	for i, ok := range safe {
+		if ok {
+			safeTiles = append(safeTiles, [2]int{i % board.Width, i / board.Width})
+		}
+	}
+
+	return safeTiles
+}
+
+// ForgiveGuess makes stepping on posX, posY safe if it's a guess player was forced into.
+//
+// If tile is a mine and there was no tile that could be proven safe,
+// mines are moved with RelocateMines. Otherwise nothing happens.
+// Returns true if mines were moved.
+func (board *Board) ForgiveGuess(posX, posY int, seed [32]byte) bool {
+	if !board.IsPosInBoard(posX, posY) {
+		return false
+	}
+	if board.Revealed.Get(posX, posY) || board.Flags.Get(posX, posY) || !board.Mines.Get(posX, posY) {
+		return false
+	}
+	if len(board.FindSafeTiles()) > 0 {
+		return false
+	}
+
+	return board.RelocateMines(posX, posY, seed)
+}
+
+// PunishGuess makes interaction at posX, posY hit a mine if it's a guess.
+//
+// Any tile that step (or check) would reveal, that could be a mine
+// given what player can see, is made a mine with RelocateMinesOnto.
+// So only moves that can be proven safe are safe.
+// Returns true if mines were moved.
+//
+// Checking a number with wrong flags loses after this,
+// since a tile that got a mine takes it from one of the flags.
+func (board *Board) PunishGuess(posX, posY int, interaction BoardInteractionType, seed [32]byte) bool {
+	if !board.IsPosInBoard(posX, posY) || board.HasNoMines() {
+		return false
+	}
+
+	var targets [][2]int
+
+	switch interaction {
+	case InteractionTypeStep:
+		if !board.Revealed.Get(posX, posY) && !board.Flags.Get(posX, posY) {
+			targets = append(targets, [2]int{posX, posY})
+		}
+	case InteractionTypeCheck:
+		if !board.Revealed.Get(posX, posY) ||
+			board.GetNeighborMineCount(posX, posY) != board.GetNeighborFlagCount(posX, posY) {
+			return false
+		}
+		iter := NewBoardIterator(posX-1, posY-1, posX+1, posY+1)
+		for iter.HasNext() {
+			x, y := iter.GetNext()
+			if board.IsPosInBoard(x, y) && !board.Revealed.Get(x, y) && !board.Flags.Get(x, y) {
+				targets = append(targets, [2]int{x, y})
+			}
+		}
+	}
+
+	for _, t := range targets {
+		if board.Mines.Get(t[0], t[1]) {
+			return false
+		}
+		if board.RelocateMinesOnto(t[0], t[1], seed) {
+			return true
+		}
+	}
+
+	return false
+}
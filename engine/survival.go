@@ -0,0 +1,227 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ==============================================
+// survival run
+// ==============================================
+//
+// Survival is a run of boards played one after another,
+// each one bigger or denser than the one before it.
+// Run ends on the first mine.
+//
+// Every board of a run comes from the board before it (see SurvivalNext),
+// seed of the next board being sha256 of the seed before it.
+// So game code of the first board is all it takes to make every board of the run,
+// and a run can be checked by validating replays of its boards in order
+// (see ValidateSurvivalRun).
+
+const (
+	// boards of a run stop getting bigger at this width and height,
+	// and get denser instead
+	SurvivalMaxBoardSize = 30
+
+	// and stop getting denser at this many mines per 100 tiles
+	SurvivalMaxDensity = 25
+)
+
+// returns error if code can't be the first board of a run
+func ValidateSurvivalStart(code GameCode) error {
+	if err := code.Validate(); err != nil {
+		return err
+	}
+	if code.Variants&GameVariantFlags != 0 {
+		return errors.New("flags boards can't be used in survival")
+	}
+	if code.Lives != 0 {
+		return errors.New("survival ends on the first mine, board can't have lives")
+	}
+	if code.Variants&GameVariantTimeAttack != 0 {
+		return errors.New("time attack boards can't be used in survival, timer goes on through the run")
+	}
+	if code.Placer == PlacerLayout {
+		return errors.New("layout boards can't grow, they can't be used in survival")
+	}
+	return nil
+}
+
+// SurvivalNext returns game code of the board after code in a run.
+//
+// Board grows by 2 tiles in both directions keeping its density
+// until SurvivalMaxBoardSize, then gets 1 more mine per 100 tiles
+// until SurvivalMaxDensity. Variants, first click policy and placer are kept,
+// target is dropped since the same range makes no sense on a bigger board.
+func SurvivalNext(code GameCode) GameCode {
+	next := code
+
+	next.Seed = sha256.Sum256(code.Seed[:])
+	next.Target = BoardTarget{}
+
+	tiles := code.Width * code.Height
+
+	if code.Width < SurvivalMaxBoardSize || code.Height < SurvivalMaxBoardSize {
+		next.Width = max(code.Width, min(code.Width+2, SurvivalMaxBoardSize))
+		next.Height = max(code.Height, min(code.Height+2, SurvivalMaxBoardSize))
+		next.MineCount = code.MineCount * next.Width * next.Height / tiles
+	} else {
+		maxMines := tiles * SurvivalMaxDensity / 100
+		next.MineCount = max(code.MineCount, min(code.MineCount+max(tiles/100, 1), maxMines))
+	}
+
+	return next
+}
+
+// SurvivalStage returns game code of stage-th board (from 0) of a run starting from start
+func SurvivalStage(start GameCode, stage int) GameCode {
+	code := start
+	for range stage {
+		code = SurvivalNext(code)
+	}
+	return code
+}
+
+type SurvivalRun struct {
+	// game code of the first board
+	Start GameCode
+
+	// results of boards played so far, in order.
+	// last one is lost if run is over
+	Results []GameResult
+}
+
+func NewSurvivalRun(start GameCode) (*SurvivalRun, error) {
+	if err := ValidateSurvivalStart(start); err != nil {
+		return nil, err
+	}
+	return &SurvivalRun{Start: start}, nil
+}
+
+// index of the board being played (from 0)
+func (run *SurvivalRun) Stage() int {
+	return len(run.Results)
+}
+
+// game code of the board being played
+func (run *SurvivalRun) Code() GameCode {
+	return SurvivalStage(run.Start, run.Stage())
+}
+
+// Add adds result of the board being played,
+// it must be a finished game on Code()
+func (run *SurvivalRun) Add(result GameResult) error {
+	if run.Over() {
+		return errors.New("survival run is already over")
+	}
+	if result.Code != run.Code() {
+		return errors.New("result is not from the board being played")
+	}
+	if result.Outcome == GameStatePlaying {
+		return errors.New("result is from a game that hasn't ended")
+	}
+	run.Results = append(run.Results, result)
+	return nil
+}
+
+func (run *SurvivalRun) Over() bool {
+	return len(run.Results) > 0 && !run.Results[len(run.Results)-1].Won()
+}
+
+// number of boards cleared
+func (run *SurvivalRun) Cleared() int {
+	cleared := 0
+	for _, result := range run.Results {
+		if result.Won() {
+			cleared++
+		}
+	}
+	return cleared
+}
+
+// Score is 3BV of every cleared board added up
+func (run *SurvivalRun) Score() int {
+	score := 0
+	for _, result := range run.Results {
+		if result.Won() {
+			score += result.BBBV
+		}
+	}
+	return score
+}
+
+// time spent on every board so far added up
+func (run *SurvivalRun) Duration() time.Duration {
+	var d time.Duration
+	for _, result := range run.Results {
+		d += result.Duration
+	}
+	return d
+}
+
+// what actually happened in a run
+type SurvivalReport struct {
+	Start GameCode
+
+	Cleared  int
+	Score    int
+	Duration time.Duration
+
+	// false if the last board was won (player stopped before hitting a mine)
+	Over bool
+}
+
+// ValidateSurvivalRun checks replays of every board of a run, in order,
+// and that each board is the one that comes after the board before it.
+//
+// Only the last board can be lost.
+func ValidateSurvivalRun(replays []Replay, opts ReplayValidateOptions) (SurvivalReport, error) {
+	var report SurvivalReport
+
+	if len(replays) <= 0 {
+		return report, invalidReplay("survival run has no boards")
+	}
+
+	var run *SurvivalRun
+
+	for i, replay := range replays {
+		r, err := ValidateReplay(replay, opts)
+		if err != nil {
+			return report, fmt.Errorf("board %d: %w", i+1, err)
+		}
+
+		if i == 0 {
+			if run, err = NewSurvivalRun(r.Code); err != nil {
+				return report, invalidReplay("board 1: %v", err)
+			}
+			report.Start = r.Code
+		}
+
+		if run.Over() {
+			return report, invalidReplay("board %d was played after the run ended", i+1)
+		}
+		if r.Code != run.Code() {
+			return report, invalidReplay("board %d is not the board after board %d", i+1, i)
+		}
+		if r.Result == GameStatePlaying {
+			return report, invalidReplay("board %d hasn't ended", i+1)
+		}
+
+		run.Results = append(run.Results, GameResult{
+			Outcome:  r.Result,
+			Duration: r.Duration,
+			Code:     r.Code,
+			BBBV:     r.BBBV,
+		})
+	}
+
+	report.Cleared = run.Cleared()
+	report.Score = run.Score()
+	report.Duration = run.Duration()
+	report.Over = run.Over()
+
+	return report, nil
+}
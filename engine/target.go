@@ -0,0 +1,235 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// ==============================================
+// board target
+// ==============================================
+//
+// BoardTarget asks for a board whose 3BV or rating is in a range.
+//
+// Mines are placed like usual, and if the board is outside the range
+// mines are placed again with the same rng until one is inside.
+// Every candidate comes from the seed, so same game code
+// and same first click still gives exactly the same board.
+//
+// If no candidate is inside the range after a while,
+// the one that was closest is used.
+// Rating targets get fewer tries on bigger boards, see ratingCandidates.
+// Version 2 codes keep drawing the way they did before, see targetV2Placer.
+
+type TargetKind uint8
+
+const (
+	// any board, mines are placed once
+	TargetNone TargetKind = iota
+
+	// Board.Get3BV
+	Target3BV
+
+	// BoardRating.Guesses
+	TargetGuesses
+
+	// BoardRating.HardestRule
+	TargetRule
+
+	TargetKindSize
+)
+
+var TargetKindStrs = [TargetKindSize]string{
+	"none",
+	"3bv",
+	"guesses",
+	"rule",
+}
+
+func (tk TargetKind) String() string {
+	if tk >= TargetKindSize {
+		return fmt.Sprintf("TargetKind(%d)", int(tk))
+	}
+	return TargetKindStrs[tk]
+}
+
+func (tk TargetKind) MarshalText() ([]byte, error) {
+	if tk >= TargetKindSize {
+		return nil, fmt.Errorf("invalid target kind %d", int(tk))
+	}
+	return []byte(TargetKindStrs[tk]), nil
+}
+
+func (tk *TargetKind) UnmarshalText(text []byte) error {
+	for i := TargetKind(0); i < TargetKindSize; i++ {
+		if string(text) == TargetKindStrs[i] {
+			*tk = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown target kind %q", string(text))
+}
+
+type BoardTarget struct {
+	Kind TargetKind
+
+	// inclusive, for TargetRule these are DeductionRule
+	Min int
+	Max int
+}
+
+// candidates drawn before settling for the closest one,
+// rating plays the whole board so it gets less
+const (
+	targetMaxCandidates3BV    = 1000
+	targetMaxCandidatesRating = 50
+)
+
+func (t BoardTarget) Validate() error {
+	if t.Kind >= TargetKindSize {
+		return fmt.Errorf("unknown target kind %d", int(t.Kind))
+	}
+	if t.Kind == TargetNone {
+		return nil
+	}
+	if t.Min < 0 || t.Min > t.Max {
+		return fmt.Errorf("target range %v is invalid", t)
+	}
+	if t.Kind == TargetRule && t.Max >= int(DeductionRuleSize) {
+		return fmt.Errorf("target range %v is invalid", t)
+	}
+	return nil
+}
+
+// String returns target in the format ParseBoardTarget takes
+func (t BoardTarget) String() string {
+	if t.Kind == TargetNone {
+		return TargetNone.String()
+	}
+
+	value := func(v int) string {
+		if t.Kind == TargetRule {
+			return DeductionRule(v).String()
+		}
+		return strconv.Itoa(v)
+	}
+
+	if t.Min == t.Max {
+		return fmt.Sprintf("%v:%s", t.Kind, value(t.Min))
+	}
+	return fmt.Sprintf("%v:%s..%s", t.Kind, value(t.Min), value(t.Max))
+}
+
+// ParseBoardTarget parses string like "3bv:120..160", "guesses:0" or "rule:single..pair"
+func ParseBoardTarget(str string) (BoardTarget, error) {
+	var t BoardTarget
+
+	str = strings.ToLower(strings.TrimSpace(str))
+	if str == "" || str == TargetNone.String() {
+		return t, nil
+	}
+
+	kindStr, rangeStr, ok := strings.Cut(str, ":")
+	if !ok {
+		return t, fmt.Errorf("target %q is not in KIND:MIN..MAX format", str)
+	}
+	if err := t.Kind.UnmarshalText([]byte(kindStr)); err != nil {
+		return t, err
+	}
+
+	parse := func(v string) (int, error) {
+		v = strings.TrimSpace(v)
+		if t.Kind == TargetRule {
+			var rule DeductionRule
+			err := rule.UnmarshalText([]byte(v))
+			return int(rule), err
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("target %q has invalid number %q", str, v)
+		}
+		return n, nil
+	}
+
+	minStr, maxStr, isRange := strings.Cut(rangeStr, "..")
+	if !isRange {
+		maxStr = minStr
+	}
+
+	var err error
+	if t.Min, err = parse(minStr); err != nil {
+		return t, err
+	}
+	if t.Max, err = parse(maxStr); err != nil {
+		return t, err
+	}
+
+	return t, t.Validate()
+}
+
+// how far board with mines placed is from the range, 0 if it's inside
+func (t BoardTarget) distance(board *Board, firstX, firstY int) int {
+	var value int
+
+	switch t.Kind {
+	case Target3BV:
+		value = board.Get3BV()
+	case TargetGuesses:
+		value = board.rate(firstX, firstY).Guesses
+	case TargetRule:
+		value = int(board.rate(firstX, firstY).HardestRule)
+	default:
+		return 0
+	}
+
+	if value < t.Min {
+		return t.Min - value
+	}
+	if value > t.Max {
+		return value - t.Max
+	}
+	return 0
+}
+
+func (t BoardTarget) maxCandidates(board *Board, placer MinePlacer) int {
+	if t.Kind == Target3BV {
+		return targetMaxCandidates3BV
+	}
+	// version 2 codes rated every candidate no matter how big the board is
+	if _, ok := placer.(*targetV2Placer); ok {
+		return targetMaxCandidatesRating
+	}
+	return ratingCandidates(board.Width*board.Height, targetMaxCandidatesRating)
+}
+
+// targetV2Placer places uniform mines like version 2 codes did.
+// Tiles are shuffled four times for the first candidate
+// and once more for every candidate after it,
+// instead of starting over like UniformPlacer does.
+type targetV2Placer struct {
+	places [][2]int
+}
+
+func (p *targetV2Placer) PlaceMines(board *Board, count, firstX, firstY int, policy FirstClickPolicy, rng *rand.Rand) {
+	shuffles := 1
+	if p.places == nil {
+		p.places = board.MineCandidates(count, firstX, firstY, policy)
+		shuffles = 4
+	}
+
+	for range shuffles {
+		shufflePlaces(p.places, rng)
+	}
+
+	for i := 0; i < count; i++ {
+		board.Mines.Set(p.places[i][0], p.places[i][1], true)
+	}
+}
+
+// PlaceMinesForCode places mines like the first step at firstX, firstY does,
+// with first click policy, placer and target of code.
+func (board *Board) PlaceMinesForCode(code GameCode, firstX, firstY int) {
+	board.placeMines(code.MineCount, firstX, firstY, code.FirstClick, code.MinePlacer(), code.Target, code.Seed)
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+// boards version 2 codes got before there were mine placers,
+// first click is at the center
+func TestTargetV2CodeKeepsBoard(t *testing.T) {
+	tests := []struct {
+		code  string
+		mines []int
+	}{
+		{
+			"MS-083G00000000000000000000000000000000000000000000000007GGCC0000CP06G024DAY4YG",
+			[]int{
+				12, 16, 21, 28, 38, 41, 44, 46, 47, 48, 64, 82, 104, 105, 106, 108, 111, 116, 118, 120,
+				137, 138, 143, 145, 150, 157, 158, 165, 169, 178, 183, 184, 194, 197, 202, 204, 205, 211,
+				215, 217, 221, 223, 228, 234, 240, 246, 247, 251, 252, 257, 258, 270, 273, 279, 287, 289,
+				295, 310, 311, 312, 315, 316, 325, 328, 329, 341, 342, 348, 350, 354, 356, 357, 370, 377,
+				381, 382, 393, 394, 395, 403, 405, 413, 416, 421, 432, 436, 441, 442, 456, 458, 462, 465,
+				466, 473, 474, 475, 477, 478, 479,
+			},
+		},
+		{
+			"MS-083G00000000000000000000000000000000000000000000000007GGCC0000G000EEAGNK",
+			[]int{
+				0, 4, 8, 9, 12, 16, 27, 29, 39, 46, 49, 58, 59, 61, 66, 73, 74, 76, 81, 87, 89, 93, 94,
+				95, 100, 105, 106, 110, 113, 123, 126, 130, 135, 141, 143, 145, 147, 149, 155, 156, 160,
+				169, 172, 175, 180, 182, 188, 191, 202, 204, 205, 207, 208, 210, 214, 223, 232, 233, 242,
+				277, 288, 292, 302, 303, 310, 320, 322, 324, 326, 327, 331, 337, 341, 344, 364, 372, 374,
+				381, 382, 384, 397, 401, 402, 409, 411, 414, 415, 420, 422, 424, 426, 430, 438, 447, 449,
+				468, 472, 474, 475,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		code, err := ParseGameCode(test.code)
+		if err != nil {
+			t.Fatalf("%s : %v", test.code, err)
+		}
+		if !code.TargetV2 {
+			t.Fatalf("%s : version 2 code isn't TargetV2", test.code)
+		}
+		if code.String() != test.code {
+			t.Fatalf("%s : code changed to %s", test.code, code.String())
+		}
+
+		board := NewBoard(code.Width, code.Height)
+		board.PlaceMinesForCode(code, code.Width/2, code.Height/2)
+
+		var mines []int
+		for i, mine := range board.Mines.Data {
+			if mine {
+				mines = append(mines, i)
+			}
+		}
+		if !slices.Equal(mines, test.mines) {
+			t.Fatalf("%s : mines are %v, want %v", test.code, mines, test.mines)
+		}
+	}
+}
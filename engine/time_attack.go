@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"time"
+)
+
+// ==============================================
+// time attack
+// ==============================================
+//
+// In time attack (GameVariantTimeAttack) clock counts down
+// from the first interaction and game is lost when it runs out.
+//
+// Every opening revealed adds TimeAttackOpeningBonus to the clock.
+// When board is cleared, every flag on a safe tile costs TimeAttackWrongFlagPenalty,
+// and time left after that is how well the game went.
+//
+// Running out of time doesn't come from an interaction,
+// so replay of such game ends at TimeAttack.Limit instead of at the last event.
+
+const (
+	// clock starts from TimeAttackStartPerTile for every tile,
+	// but not from less than TimeAttackMinStart
+	TimeAttackMinStart     = time.Second * 20
+	TimeAttackStartPerTile = time.Millisecond * 150
+
+	TimeAttackOpeningBonus     = time.Second * 5
+	TimeAttackWrongFlagPenalty = time.Second * 10
+)
+
+type TimeAttack struct {
+	// clock runs out when this much time has passed since the first interaction,
+	// it's start time with every bonus and penalty so far
+	Limit time.Duration
+}
+
+func NewTimeAttack(code GameCode) TimeAttack {
+	return TimeAttack{
+		Limit: max(TimeAttackMinStart, time.Duration(code.Width*code.Height)*TimeAttackStartPerTile),
+	}
+}
+
+// time left on the clock after elapsed since the first interaction
+func (ta *TimeAttack) Left(elapsed time.Duration) time.Duration {
+	return max(ta.Limit-elapsed, 0)
+}
+
+func (ta *TimeAttack) TimeUp(elapsed time.Duration) bool {
+	return elapsed >= ta.Limit
+}
+
+// OnReveal adds bonus for every opening that got revealed
+// going from before to after, and returns the bonus
+func (ta *TimeAttack) OnReveal(before, after Board) time.Duration {
+	bonus := time.Duration(countNewOpenings(before, after)) * TimeAttackOpeningBonus
+	ta.Limit += bonus
+	return bonus
+}
+
+// OnClear takes penalty for flags on safe tiles of a cleared board
+// and returns it. Penalty doesn't take more than what's left on the clock
+func (ta *TimeAttack) OnClear(board Board, elapsed time.Duration) time.Duration {
+	wrongFlags := 0
+	for i, flag := range board.Flags.Data {
+		if flag && !board.Mines.Data[i] {
+			wrongFlags++
+		}
+	}
+
+	penalty := min(time.Duration(wrongFlags)*TimeAttackWrongFlagPenalty, ta.Left(elapsed))
+	ta.Limit -= penalty
+	return penalty
+}
+
+// counts openings (connected areas of tiles with no neighboring mines)
+// that are revealed in after but not in before
+func countNewOpenings(before, after Board) int {
+	isNewOpening := func(x, y int) bool {
+		return after.Revealed.Get(x, y) && !before.Revealed.Get(x, y) &&
+			!after.Mines.Get(x, y) && after.GetNeighborMineCount(x, y) == 0
+	}
+
+	counted := NewArray2D[bool](after.Width, after.Height)
+	stack := make([][2]int, 0, 64)
+
+	openings := 0
+
+	for x := range after.Width {
+		for y := range after.Height {
+			if counted.Get(x, y) || !isNewOpening(x, y) {
+				continue
+			}
+
+			openings++
+
+			counted.Set(x, y, true)
+			stack = append(stack[:0], [2]int{x, y})
+
+			for len(stack) > 0 {
+				pos := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+
+				iter := NewBoardIterator(pos[0]-1, pos[1]-1, pos[0]+1, pos[1]+1)
+				for iter.HasNext() {
+					nx, ny := iter.GetNext()
+					if !after.IsPosInBoard(nx, ny) || counted.Get(nx, ny) || !isNewOpening(nx, ny) {
+						continue
+					}
+					counted.Set(nx, ny, true)
+					stack = append(stack, [2]int{nx, ny})
+				}
+			}
+		}
+	}
+
+	return openings
+}
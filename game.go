@@ -10,6 +10,8 @@ import (
 	"strconv"
 	"time"
 
+	"minesweeper/engine"
+
 	eb "github.com/hajimehoshi/ebiten/v2"
 	ebt "github.com/hajimehoshi/ebiten/v2/text/v2"
 	ebv "github.com/hajimehoshi/ebiten/v2/vector"
@@ -37,6 +39,58 @@ type GameInput struct {
 	ByTouch bool
 }
 
+// GameInputSource replaces user's board input,
+// for example with commands from a bot
+type GameInputSource interface {
+	// called every update
+	// returns input with InputTypeNone if there is nothing to do
+	GetGameInput(board engine.Board, gameState engine.GameState, mineCount int) GameInput
+
+	// called when board is reset
+	OnBoardReset()
+}
+
+// GameRemote makes Game play a board that lives on a server (for co-op)
+//
+// Local interactions are sent to the server instead of being applied
+// and board only changes by updates from the server.
+type GameRemote interface {
+	SendInteraction(interaction engine.BoardInteractionType, x, y int)
+
+	// cursor position in tile units
+	SendCursor(x, y float64)
+
+	// asks server for a new board, called when board is reset locally
+	RequestReset(code engine.GameCode)
+
+	// returns next update from the server, false if there is none
+	PollUpdate() (RemoteUpdate, bool)
+
+	// cursors of every player
+	Cursors() []RemoteCursor
+}
+
+type RemoteUpdate struct {
+	// true if server started a new board
+	NewBoard bool
+
+	Code      engine.GameCode
+	Board     engine.Board
+	GameState engine.GameState
+
+	// interaction that caused this update,
+	// InteractionTypeNone if it's not caused by an interaction
+	Interaction engine.BoardInteractionType
+	X, Y        int
+}
+
+type RemoteCursor struct {
+	// in tile units
+	X, Y float64
+
+	Color ColorTableIndex
+}
+
 type GameInputHandler struct {
 	NoInputZones []FRectangle
 
@@ -72,9 +126,9 @@ func NewGameInputHandler() *GameInputHandler {
 }
 
 func (gi *GameInputHandler) Update(
-	board Board,
+	board engine.Board,
 	boardRect FRectangle,
-	gameState GameState,
+	gameState engine.GameState,
 ) {
 	im := &TheInputManager
 	// =============================
@@ -207,7 +261,7 @@ func (gi *GameInputHandler) Update(
 
 		// if it did start in number tile, check it it has any space to left to flag
 		if startedInNum {
-			iter := NewBoardIterator(startedBX-1, startedBY-1, startedBX+1, startedBY+1)
+			iter := engine.NewBoardIterator(startedBX-1, startedBY-1, startedBX+1, startedBY+1)
 
 			tilesCanBeFlagged := 0
 			tilesThatAreFlagged := 0
@@ -236,7 +290,7 @@ func (gi *GameInputHandler) Update(
 		if !info.DidEnd && startedInNum {
 			var safeNeighbors [9]bool
 
-			iter := NewBoardIterator(
+			iter := engine.NewBoardIterator(
 				startedBX-1, startedBY-1,
 				startedBX+1, startedBY+1,
 			)
@@ -245,7 +299,7 @@ func (gi *GameInputHandler) Update(
 				x, y := iter.GetNext()
 
 				if board.IsPosInBoard(x, y) && !board.Revealed.Get(x, y) {
-					innerIter := NewBoardIterator(
+					innerIter := engine.NewBoardIterator(
 						max(x-1, startedBX-1), max(y-1, startedBY-1),
 						min(x+1, startedBX+1), min(y+1, startedBY+1),
 					)
@@ -263,7 +317,7 @@ func (gi *GameInputHandler) Update(
 
 			inSafeNeighbor := false
 
-			iter = NewBoardIterator(0, 0, 2, 2)
+			iter = engine.NewBoardIterator(0, 0, 2, 2)
 			for iter.HasNext() {
 				rx, ry := iter.GetNext()
 				index := ry*3 + rx
@@ -309,7 +363,7 @@ func (gi *GameInputHandler) Update(
 			var neighborX, neighborY int
 
 			if (startedBX != endedBX) || (startedBY != endedBY) {
-				iter := NewBoardIterator(startedBX-1, startedBY-1, startedBX+1, startedBY+1)
+				iter := engine.NewBoardIterator(startedBX-1, startedBY-1, startedBX+1, startedBY+1)
 
 				var minDist float64 = math.MaxFloat64
 
@@ -355,7 +409,7 @@ func (gi *GameInputHandler) Update(
 		// ======================
 		// handle dragging
 		// ======================
-		if (!startedInNum || gi.ignoreForFlag[touchId] || gameState != GameStatePlaying) && !info.DidEnd {
+		if (!startedInNum || gi.ignoreForFlag[touchId] || gameState != engine.GameStatePlaying) && !info.DidEnd {
 			gi.ignoreForFlag[touchId] = true
 			if !gi.dragStarted {
 				gi.dragDelta = FPt(0, 0)
@@ -481,6 +535,8 @@ type TileStyle struct {
 	BgFillColor color.Color
 
 	BgBombAnim float64
+	// color around the mine, ColorMineBg1 if nil
+	BgBombColor color.Color
 
 	BgAlpha float64
 
@@ -626,6 +682,10 @@ const (
 	AnimationTagWin
 	AnimationTagDefeat
 
+	AnimationTagMineHit
+
+	AnimationTagTimeUp
+
 	AnimationTagRetryButtonReveal
 
 	AnimationTagHideBoard
@@ -689,12 +749,12 @@ func AnimationQueueSkipUntilTag(queue *CircularQueue[CallbackAnimation], tags ..
 
 // called every update
 type StyleModifier func(
-	prevBoard, board Board,
+	prevBoard, board engine.Board,
 	boardRect FRectangle,
-	interaction BoardInteractionType,
+	interaction engine.BoardInteractionType,
 	stateChanged bool, // GameState or board has changed
-	prevGameState, gameState GameState,
-	tileStyles Array2D[TileStyle], // modify these to change style
+	prevGameState, gameState engine.GameState,
+	tileStyles engine.Array2D[TileStyle], // modify these to change style
 	gi GameInput,
 ) bool
 
@@ -711,15 +771,13 @@ type Game struct {
 	Zoom   float64
 	Offset FPoint
 
-	OnAfterBoardReset  func()
-	OnBeforeBoardReset func()
-	OnGameEnd          func(didWin bool)
-	OnFirstInteraction func()
+	// everything that happens in the game is published here
+	Events *GameEventBus
 
-	BaseTileStyles   Array2D[TileStyle]
-	RenderTileStyles Array2D[TileStyle]
+	BaseTileStyles   engine.Array2D[TileStyle]
+	RenderTileStyles engine.Array2D[TileStyle]
 
-	TileAnimations Array2D[*CircularQueue[CallbackAnimation]]
+	TileAnimations engine.Array2D[*CircularQueue[CallbackAnimation]]
 
 	GameAnimations CircularQueue[CallbackAnimation]
 
@@ -727,14 +785,35 @@ type Game struct {
 
 	InputHandler *GameInputHandler
 
+	// if not nil, board interactions come from here instead of user
+	// (user can still zoom and pan)
+	InputSource GameInputSource
+
+	// if not nil, board is owned by a server
+	Remote GameRemote
+
+	// if not nil, local interactions it returns false for are dropped
+	// (interactions from Remote are not filtered)
+	InteractionFilter func(interaction engine.BoardInteractionType, x, y int) bool
+
+	// called before board is reset to a new board from Remote
+	OnRemoteNewBoard func(code engine.GameCode)
+
 	RetryButton *RetryButton
 
+	// if true, retry button resets to the same board instead of a new one
+	RetrySameBoard bool
+
+	// if true, win animation is followed by a reset instead of the retry button
+	// (for boards that are played one after another)
+	ContinueAfterWin bool
+
 	DrawRetryButton    bool
 	RetryButtonScale   float64
 	RetryButtonOffsetX float64
 	RetryButtonOffsetY float64
 
-	GameState GameState
+	GameState engine.GameState
 
 	WaterAlpha      float64
 	WaterFlowOffset time.Duration
@@ -748,17 +827,52 @@ type Game struct {
 	revealdTilesUsingTouch bool
 	plantedFlag            bool
 
-	board     Board
-	prevBoard Board
+	board     engine.Board
+	prevBoard engine.Board
 
-	mineCount int
+	mineCount  int
+	variants   engine.GameVariant
+	firstClick engine.FirstClickPolicy
+	target     engine.BoardTarget
+	targetV2   bool
+	placer     engine.PlacerKind
+	layout     string
+	lives      int
 
 	resetBoardWidth  int
 	resetBoardHeight int
 	resetMineCount   int
+	resetVariants    engine.GameVariant
+	resetFirstClick  engine.FirstClickPolicy
+	resetTarget      engine.BoardTarget
+	resetTargetV2    bool
+	resetPlacer      engine.PlacerKind
+	resetLayout      string
+	resetLives       int
+	resetPosition    *engine.Board
 
 	hadInteraction bool
 
+	// not nil when playing flags variant
+	flagsMatch *engine.FlagsMatch
+
+	// not nil when playing time attack variant
+	timeAttack *engine.TimeAttack
+
+	replayRecorder *engine.ReplayRecorder
+
+	// rating of the board, made off the game loop from the first step
+	// since it can take a while on big boards. nil before the first step,
+	// see startRating and Rating
+	rating chan engine.BoardRating
+
+	// set when game ends
+	result *engine.GameResult
+
+	// set after defeat animation, see PostMortem
+	postMortem      *engine.LossAnalysis
+	postMortemTimer Timer
+
 	playedAddFlagSound    bool
 	playedRemoveFlagSound bool
 
@@ -770,6 +884,11 @@ type Game struct {
 	retryButtonOffsetX float64
 	retryButtonOffsetY float64
 
+	resettingFromRemote bool
+
+	remoteCursorX float64
+	remoteCursorY float64
+
 	viBuffers [3]*VIBuffer
 
 	noInputZone FRectangle
@@ -780,6 +899,8 @@ func NewGame(boardWidth, boardHeight, mineCount int) *Game {
 
 	g.Zoom = 1
 
+	g.Events = NewGameEventBus()
+
 	g.InputHandler = NewGameInputHandler()
 
 	g.FlagTutorial = NewFlagTutorial()
@@ -793,6 +914,7 @@ func NewGame(boardWidth, boardHeight, mineCount int) *Game {
 	g.StyleModifiers = append(g.StyleModifiers, NewTileHighlightModifier())
 	g.StyleModifiers = append(g.StyleModifiers, NewFgClickModifier())
 	g.StyleModifiers = append(g.StyleModifiers, g.FlagTutorial.GetFlagTutorialStyleModifier())
+	g.StyleModifiers = append(g.StyleModifiers, g.postMortemStyleModifier())
 
 	g.RetryButton = NewRetryButton()
 	g.RetryButton.Disabled = true
@@ -822,14 +944,61 @@ func NewGame(boardWidth, boardHeight, mineCount int) *Game {
 }
 
 func (g *Game) SetResetParameter(boardWidth, boardHeight, mineCount int) {
+	g.SetResetParameterEx(boardWidth, boardHeight, mineCount, 0, engine.FirstClickSafeArea)
+}
+
+func (g *Game) SetResetParameterEx(
+	boardWidth, boardHeight, mineCount int,
+	variants engine.GameVariant, firstClick engine.FirstClickPolicy,
+) {
 	g.resetBoardWidth = boardWidth
 	g.resetBoardHeight = boardHeight
 	g.resetMineCount = mineCount
+	g.resetVariants = variants
+	g.resetFirstClick = firstClick
+}
+
+// SetResetTarget sets 3BV or rating range of boards made after next reset,
+// v2 draws them like version 2 codes did (see engine.GameCode.TargetV2)
+func (g *Game) SetResetTarget(target engine.BoardTarget, v2 bool) {
+	g.resetTarget = target
+	g.resetTargetV2 = v2
+}
+
+// SetResetPlacer sets how mines are placed on boards made after next reset,
+// layout is only for engine.PlacerLayout
+func (g *Game) SetResetPlacer(placer engine.PlacerKind, layout string) {
+	g.resetPlacer = placer
+	g.resetLayout = layout
+}
+
+// SetResetLives sets how many mines player can hit
+// on boards made after next reset, 0 for normal games
+func (g *Game) SetResetLives(lives int) {
+	g.resetLives = lives
+}
+
+// SetResetPosition makes boards after next reset start from position
+// instead of an empty board, nil goes back to empty boards.
+//
+// Mines come from position too, so size, mine count, target and placer
+// of reset parameter are replaced with position's.
+// Replay of such board doesn't have tiles that were revealed from the start.
+func (g *Game) SetResetPosition(position *engine.Board) {
+	g.resetPosition = position
 }
 
 func (g *Game) ResetBoardNotStylesEx(newSeed bool) {
-	if g.OnBeforeBoardReset != nil {
-		g.OnBeforeBoardReset()
+	g.Events.Publish(EventBeforeBoardReset{})
+
+	if pos := g.resetPosition; pos != nil {
+		g.resetBoardWidth = pos.Width
+		g.resetBoardHeight = pos.Height
+		g.resetMineCount = pos.MineCount()
+		g.resetTarget = engine.BoardTarget{}
+		g.resetTargetV2 = false
+		g.resetPlacer = engine.PlacerLayout
+		g.resetLayout = pos.MineLayout()
 	}
 
 	width := g.resetBoardWidth
@@ -839,17 +1008,55 @@ func (g *Game) ResetBoardNotStylesEx(newSeed bool) {
 	if newSeed {
 		g.Seed = GetSeed()
 	}
-	InfoLogger.Printf("resetting, seed : %s", SeedToString(g.Seed))
+	InfoLogger.Printf("resetting, seed : %s", engine.SeedToString(g.Seed))
 
 	g.hadInteraction = false
 
-	g.GameState = GameStatePlaying
+	g.GameState = engine.GameStatePlaying
 	g.GameAnimations.Clear()
 
-	g.board = NewBoard(width, height)
-	g.prevBoard = NewBoard(width, height)
+	g.board = engine.NewBoard(width, height)
+	g.prevBoard = engine.NewBoard(width, height)
+
+	if g.resetPosition != nil {
+		g.resetPosition.SaveTo(g.board)
+		g.resetPosition.SaveTo(g.prevBoard)
+	}
 
 	g.mineCount = mineCount
+	g.variants = g.resetVariants
+	g.firstClick = g.resetFirstClick
+	g.target = g.resetTarget
+	g.targetV2 = g.resetTargetV2
+	g.placer = g.resetPlacer
+	g.layout = g.resetLayout
+	g.lives = g.resetLives
+
+	g.flagsMatch = nil
+	if g.variants&engine.GameVariantFlags != 0 {
+		g.flagsMatch = engine.NewFlagsMatch(width, height)
+	}
+
+	g.timeAttack = nil
+	if g.variants&engine.GameVariantTimeAttack != 0 {
+		timeAttack := engine.NewTimeAttack(g.GameCode())
+		g.timeAttack = &timeAttack
+	}
+
+	InfoLogger.Printf("game code : %s", g.GameCode().String())
+
+	g.replayRecorder = engine.NewReplayRecorder(g.GameCode())
+	g.rating = nil
+	g.result = nil
+	g.postMortem = nil
+
+	if g.InputSource != nil {
+		g.InputSource.OnBoardReset()
+	}
+
+	if g.Remote != nil && !g.resettingFromRemote {
+		g.Remote.RequestReset(g.GameCode())
+	}
 
 	g.DrawRetryButton = false
 	g.RetryButton.Disabled = true
@@ -862,10 +1069,10 @@ func (g *Game) ResetBoardNotStylesEx(newSeed bool) {
 	g.DisableZoomAndPanControl = false
 	g.DoingZoomAnimation = false
 
-	g.BaseTileStyles = NewArray2D[TileStyle](width, height)
-	g.RenderTileStyles = NewArray2D[TileStyle](width, height)
+	g.BaseTileStyles = engine.NewArray2D[TileStyle](width, height)
+	g.RenderTileStyles = engine.NewArray2D[TileStyle](width, height)
 
-	g.TileAnimations = NewArray2D[*CircularQueue[CallbackAnimation]](width, height)
+	g.TileAnimations = engine.NewArray2D[*CircularQueue[CallbackAnimation]](width, height)
 	for x := range width {
 		for y := range height {
 			// TODO : do we need this much queued animation?
@@ -883,9 +1090,7 @@ func (g *Game) ResetBoardNotStylesEx(newSeed bool) {
 
 	g.Particles = g.Particles[:0]
 
-	if g.OnAfterBoardReset != nil {
-		g.OnAfterBoardReset()
-	}
+	g.Events.Publish(EventBoardReset{Code: g.GameCode()})
 }
 
 func (g *Game) ResetBoardEx(newSeed bool) {
@@ -893,7 +1098,7 @@ func (g *Game) ResetBoardEx(newSeed bool) {
 
 	for x := range g.resetBoardWidth {
 		for y := range g.resetBoardHeight {
-			targetStyle := GetAnimationTargetTileStyle(g.board, x, y)
+			targetStyle := g.targetTileStyle(x, y)
 			g.BaseTileStyles.Set(x, y, targetStyle)
 			g.RenderTileStyles.Set(x, y, targetStyle)
 		}
@@ -928,6 +1133,9 @@ func (g *Game) Update() {
 	g.InputHandler.Update(g.board, g.TransformedBoardRect(), g.GameState)
 
 	gi := g.InputHandler.GetGameInput()
+	if g.InputSource != nil {
+		gi = g.InputSource.GetGameInput(g.board, g.GameState, g.mineCount)
+	}
 
 	if !g.DisableZoomAndPanControl {
 		g.Zoom, g.Offset = g.InputHandler.GetZoomAndOffset(g.Zoom, g.Offset, FRectangleCenter(g.TransformedBoardRect()))
@@ -963,21 +1171,84 @@ func (g *Game) Update() {
 	// true if board or game state has changed
 	var stateChanged bool = false
 
-	var interaction BoardInteractionType = InteractionTypeNone
+	var interaction engine.BoardInteractionType = engine.InteractionTypeNone
+
+	// lost by running out of time in time attack
+	var timedOut bool = false
 	// =======================================
 
-	if g.GameState == GameStatePlaying && gi.Type != InputTypeNone {
+	// time running out changes game state without an interaction.
+	// clock is only kept locally, server doesn't know about it
+	if g.timeAttack != nil && g.Remote == nil && g.GameState == engine.GameStatePlaying &&
+		g.timeAttack.TimeUp(g.replayRecorder.Elapsed()) {
+		g.GameState = engine.GameStateLost
+		timedOut = true
+		needToCheckStateChange = true
+	}
+
+	if g.GameState == engine.GameStatePlaying && gi.Type != InputTypeNone {
 		if gi.Type == InputTypeCheck {
-			interaction = InteractionTypeCheck
+			interaction = engine.InteractionTypeCheck
 		} else if gi.Type == InputTypeFlag {
-			interaction = InteractionTypeFlag
+			interaction = engine.InteractionTypeFlag
 		} else if gi.Type == InputTypeStep {
-			interaction = InteractionTypeStep
+			interaction = engine.InteractionTypeStep
 		}
 
-		if interaction != InteractionTypeNone {
-			g.GameState = g.board.InteractAt(
-				gi.BoardX, gi.BoardY, interaction, g.GameState, g.mineCount, g.Seed)
+		if interaction != engine.InteractionTypeNone && g.InteractionFilter != nil &&
+			!g.InteractionFilter(interaction, gi.BoardX, gi.BoardY) {
+			interaction = engine.InteractionTypeNone
+		}
+
+		if interaction != engine.InteractionTypeNone {
+			if g.Remote != nil {
+				// server will send us the result
+				g.Remote.SendInteraction(interaction, gi.BoardX, gi.BoardY)
+			} else {
+				g.GameState = g.board.InteractWithCode(
+					gi.BoardX, gi.BoardY, interaction, g.GameState,
+					g.GameCode(), g.flagsMatch,
+				)
+				g.replayRecorder.Record(interaction, gi.BoardX, gi.BoardY)
+
+				if g.timeAttack != nil {
+					g.updateTimeAttack()
+				}
+
+				needToCheckStateChange = true
+			}
+		}
+	}
+
+	// where animations start from
+	originX, originY := gi.BoardX, gi.BoardY
+	// what changed the board, can be different from interaction when it's from remote
+	cause := interaction
+
+	// ======================================
+	// apply board from remote
+	// ======================================
+	if g.Remote != nil {
+		g.sendRemoteCursor()
+
+		if update, ok := g.Remote.PollUpdate(); ok {
+			if update.NewBoard ||
+				update.Board.Width != g.board.Width || update.Board.Height != g.board.Height {
+				g.resetForRemote(update.Code)
+
+				// compare against the new board
+				prevState = g.GameState
+				g.board.SaveTo(g.prevBoard)
+			}
+
+			update.Board.SaveTo(g.board)
+			g.GameState = update.GameState
+
+			if update.Interaction != engine.InteractionTypeNone {
+				g.replayRecorder.Record(update.Interaction, update.X, update.Y)
+				originX, originY = update.X, update.Y
+				cause = update.Interaction
+			}
 
 			needToCheckStateChange = true
 		}
@@ -986,11 +1257,11 @@ func (g *Game) Update() {
 	// ======================================
 	// changing board for debugging purpose
 	// ======================================
-	if IsKeyJustPressed(SetToDecoBoardKey) && IsDevVersion {
+	if IsHotkeyJustPressed(SetToDecoBoardKey) && IsDevVersion {
 		g.SetDebugBoardForDecoration()
 		needToCheckStateChange = true
 	}
-	if IsKeyJustPressed(InstantWinKey) && IsDevVersion {
+	if IsHotkeyJustPressed(InstantWinKey) && IsDevVersion {
 		g.SetBoardForInstantWin()
 		needToCheckStateChange = true
 	}
@@ -999,11 +1270,13 @@ func (g *Game) Update() {
 	// check if state has changed
 	// ==============================
 	if needToCheckStateChange {
+		g.startRating()
+
 		// first check game state
 		stateChanged = prevState != g.GameState
 
 		// then check board state
-		iter := NewBoardIterator(0, 0, g.board.Width-1, g.board.Height-1)
+		iter := engine.NewBoardIterator(0, 0, g.board.Width-1, g.board.Height-1)
 
 		for iter.HasNext() {
 			x, y := iter.GetNext()
@@ -1029,7 +1302,7 @@ func (g *Game) Update() {
 	// on state changes
 	// ==============================
 	// skipping animations
-	if prevState == GameStateLost || prevState == GameStateWon {
+	if prevState == engine.GameStateLost || prevState == engine.GameStateWon {
 		// all animations are skippable except AnimationTagRetryButtonReveal
 		pressedAny := IsMouseButtonJustPressed(eb.MouseButtonLeft)
 		pressedAny = pressedAny || IsMouseButtonJustPressed(eb.MouseButtonRight)
@@ -1050,7 +1323,7 @@ func (g *Game) Update() {
 
 		SetRedraw() // just do it!!
 
-		iter := NewBoardIterator(0, 0, g.board.Width-1, g.board.Height-1)
+		iter := engine.NewBoardIterator(0, 0, g.board.Width-1, g.board.Height-1)
 
 		// update flag
 		iter.Reset()
@@ -1075,38 +1348,53 @@ func (g *Game) Update() {
 				newTilesRevealed = true
 				g.QueueRevealAnimation(
 					g.prevBoard.Revealed, g.board.Revealed,
-					Clamp(gi.BoardX, 0, g.board.Width-1),
-					Clamp(gi.BoardY, 0, g.board.Height-1),
+					Clamp(originX, 0, g.board.Width-1),
+					Clamp(originY, 0, g.board.Height-1),
 				)
 				break
 			}
 		}
 
+		// mines hit with lives left
+		if g.lives > 0 {
+			iter.Reset()
+			for iter.HasNext() {
+				x, y := iter.GetNext()
+				if g.board.Revealed.Get(x, y) && g.board.Mines.Get(x, y) && !g.prevBoard.Revealed.Get(x, y) {
+					g.QueueMineHitAnimation(x, y)
+				}
+			}
+		}
+
 		if prevState != g.GameState {
-			if g.GameState == GameStateLost { // on loss
-				g.QueueDefeatAnimation(gi.BoardX, gi.BoardY)
-			} else if g.GameState == GameStateWon { // on win
-				g.QueueWinAnimation(gi.BoardX, gi.BoardY)
+			if timedOut {
+				g.QueueTimeUpAnimation()
+			} else if g.GameState == engine.GameStateLost { // on loss
+				g.QueueDefeatAnimation(originX, originY)
+			} else if g.GameState == engine.GameStateWon { // on win
+				g.QueueWinAnimation(originX, originY)
 			}
 		}
 
-		// call OnFirstInteraction
 		if !g.hadInteraction {
 			g.hadInteraction = true
-			if g.OnFirstInteraction != nil {
-				g.OnFirstInteraction()
-			}
+			g.Events.Publish(EventFirstInteraction{})
 		}
 
-		// call OnGameEnd
-		if g.GameState != prevState && (g.GameState == GameStateWon || g.GameState == GameStateLost) {
-			if g.OnGameEnd != nil {
-				g.OnGameEnd(g.GameState == GameStateWon)
+		if g.GameState != prevState && (g.GameState == engine.GameStateWon || g.GameState == engine.GameStateLost) {
+			if timedOut {
+				// replay ends when the clock ran out, not at the last interaction
+				g.replayRecorder.FinishAt(g.GameState, g.board, g.timeAttack.Limit)
+			} else {
+				g.replayRecorder.Finish(g.GameState, g.board)
 			}
+			g.finishResult()
 		}
+
+		g.publishBoardEvents(prevState, cause, originX, originY)
 	}
 
-	if interaction != InteractionTypeNone {
+	if interaction != engine.InteractionTypeNone {
 		if !stateChanged { // user wanted to do something but nothing happened
 			// pass
 		} else { // something did happened
@@ -1127,7 +1415,7 @@ func (g *Game) Update() {
 	if g.revealdTilesUsingTouch &&
 		!g.plantedFlag &&
 		gi.Type == InputTypeNone &&
-		g.GameState == GameStatePlaying {
+		g.GameState == engine.GameStatePlaying {
 		g.FlagTutorial.ShowFlagTutorial = true
 	} else {
 		g.FlagTutorial.ShowFlagTutorial = false
@@ -1249,11 +1537,11 @@ func (g *Game) Update() {
 	}
 	g.RetryButton.Update()
 
-	if IsKeyJustPressed(ResetBoardKey) && IsDevVersion {
+	if IsHotkeyJustPressed(ResetBoardKey) && IsDevVersion {
 		g.ResetBoard()
 		SetRedraw()
 	}
-	if IsKeyJustPressed(ResetToSameBoardKey) && IsDevVersion {
+	if IsHotkeyJustPressed(ResetToSameBoardKey) && IsDevVersion {
 		g.ResetBoardEx(false)
 		SetRedraw()
 	}
@@ -1263,7 +1551,7 @@ func (g *Game) Draw(dst *eb.Image) {
 	// background
 	dst.Fill(TheColorTable[ColorBg])
 
-	doWaterEffect := g.GameState == GameStateWon
+	doWaterEffect := g.GameState == engine.GameStateWon
 
 	if doWaterEffect {
 		SetRedraw()
@@ -1303,6 +1591,153 @@ func (g *Game) Draw(dst *eb.Image) {
 		g.board,
 		g.TransformedBoardRect(),
 	)
+
+	if g.Remote != nil {
+		g.drawRemoteCursors(dst)
+	}
+}
+
+// makes result of the game that just ended from its replay
+func (g *Game) finishResult() {
+	// it's only not ready if game ended right after the first step
+	g.Rating(true)
+
+	result, err := engine.ResultFromReplay(g.replayRecorder.Replay)
+	if err != nil {
+		ErrLogger.Printf("failed to make game result: %v", err)
+	}
+
+	// replay can't tell about boards that didn't come from interactions (debug boards)
+	result.Outcome = g.GameState
+
+	g.result = &result
+}
+
+// publishes what changed between prevBoard and board
+func (g *Game) publishBoardEvents(
+	prevState engine.GameState,
+	interaction engine.BoardInteractionType, x, y int,
+) {
+	var revealed []image.Point
+
+	iter := engine.NewBoardIterator(0, 0, g.board.Width-1, g.board.Height-1)
+	for iter.HasNext() {
+		tx, ty := iter.GetNext()
+
+		if g.board.Revealed.Get(tx, ty) && !g.prevBoard.Revealed.Get(tx, ty) {
+			revealed = append(revealed, image.Pt(tx, ty))
+		}
+
+		if g.board.Flags.Get(tx, ty) != g.prevBoard.Flags.Get(tx, ty) {
+			if g.board.Flags.Get(tx, ty) {
+				g.Events.Publish(EventFlagPlaced{X: tx, Y: ty})
+			} else {
+				g.Events.Publish(EventFlagRemoved{X: tx, Y: ty})
+			}
+		}
+	}
+
+	if len(revealed) > 0 {
+		g.Events.Publish(EventTileRevealed{
+			Interaction: interaction,
+			X:           x,
+			Y:           y,
+			Tiles:       revealed,
+			Cascade:     len(revealed) > 1,
+		})
+
+		if interaction == engine.InteractionTypeCheck {
+			g.Events.Publish(EventChordPerformed{X: x, Y: y, Revealed: len(revealed)})
+		}
+
+		for _, p := range revealed {
+			if g.board.Mines.Get(p.X, p.Y) {
+				g.Events.Publish(EventMineHit{X: p.X, Y: p.Y})
+			}
+		}
+	}
+
+	if g.GameState != prevState {
+		switch g.GameState {
+		case engine.GameStateWon:
+			g.Events.Publish(EventWon{Result: *g.result})
+		case engine.GameStateLost:
+			// losing doesn't reveal the mine, so it wasn't published above
+			// (and there's no mine when time ran out)
+			if !g.result.TimedOut {
+				g.Events.Publish(EventMineHit{X: x, Y: y})
+			}
+			g.Events.Publish(EventLost{Result: *g.result})
+		}
+	}
+
+	g.Events.Publish(EventBoardChanged{Interaction: interaction, X: x, Y: y})
+}
+
+// resets board to a board server started
+func (g *Game) resetForRemote(code engine.GameCode) {
+	if g.OnRemoteNewBoard != nil {
+		g.OnRemoteNewBoard(code)
+	}
+
+	// we already reset the board while waiting for the server
+	if !g.hadInteraction && g.GameState == engine.GameStatePlaying &&
+		g.board.Width == code.Width && g.board.Height == code.Height {
+		g.Seed = code.Seed
+		g.mineCount = code.MineCount
+		g.variants = code.Variants
+		g.firstClick = code.FirstClick
+		g.target = code.Target
+		g.targetV2 = code.TargetV2
+		g.placer = code.Placer
+		g.layout = code.Layout
+		g.lives = code.Lives
+		g.replayRecorder = engine.NewReplayRecorder(code)
+		g.rating = nil
+		return
+	}
+
+	g.SetResetParameterEx(code.Width, code.Height, code.MineCount, code.Variants, code.FirstClick)
+	g.SetResetTarget(code.Target, code.TargetV2)
+	g.SetResetPlacer(code.Placer, code.Layout)
+	g.SetResetLives(code.Lives)
+	g.Seed = code.Seed
+
+	g.resettingFromRemote = true
+	g.ResetBoardEx(false)
+	g.resettingFromRemote = false
+}
+
+func (g *Game) sendRemoteCursor() {
+	boardRect := g.TransformedBoardRect()
+	if boardRect.Dx() <= 0 || boardRect.Dy() <= 0 {
+		return
+	}
+
+	cursor := CursorFPt()
+
+	x := (cursor.X - boardRect.Min.X) / boardRect.Dx() * f64(g.board.Width)
+	y := (cursor.Y - boardRect.Min.Y) / boardRect.Dy() * f64(g.board.Height)
+
+	if x != g.remoteCursorX || y != g.remoteCursorY {
+		g.remoteCursorX, g.remoteCursorY = x, y
+		g.Remote.SendCursor(x, y)
+	}
+}
+
+func (g *Game) drawRemoteCursors(dst *eb.Image) {
+	boardRect := g.TransformedBoardRect()
+
+	tileSize := boardRect.Dx() / f64(g.board.Width)
+	radius := max(tileSize*0.18, 4)
+
+	for _, cursor := range g.Remote.Cursors() {
+		x := boardRect.Min.X + cursor.X*tileSize
+		y := boardRect.Min.Y + cursor.Y*tileSize
+
+		FillCircle(dst, x, y, radius, cursor.Color)
+		StrokeCircle(dst, x, y, radius, max(radius*0.25, 1), TheColorTable[ColorCoopCursorStroke])
+	}
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) {
@@ -1325,14 +1760,150 @@ func (g *Game) FlagCount() int {
 	return flagCount
 }
 
+// PostMortem returns why game was lost,
+// nil until defeat animation is done
+func (g *Game) PostMortem() *engine.LossAnalysis {
+	return g.postMortem
+}
+
+// Board returns the board being played,
+// it must not be modified
+func (g *Game) Board() engine.Board {
+	return g.board
+}
+
 func (g *Game) BoardTileCount() (int, int) {
 	return g.board.Width, g.board.Height
 }
 
+// returns game code for current board
+func (g *Game) GameCode() engine.GameCode {
+	return engine.GameCode{
+		Seed: g.Seed,
+
+		Width:     g.board.Width,
+		Height:    g.board.Height,
+		MineCount: g.mineCount,
+
+		Variants:   g.variants,
+		FirstClick: g.firstClick,
+
+		Target:   g.target,
+		TargetV2: g.targetV2,
+		Placer:   g.placer,
+		Layout:   g.layout,
+
+		Lives: g.lives,
+	}
+}
+
+// LivesLeft returns how many more mines player can hit,
+// 0 if board has no lives
+func (g *Game) LivesLeft() int {
+	return max(g.lives-g.board.HitMineCount(), 0)
+}
+
+// TimeLimit returns how long after the first interaction
+// time attack clock runs out, false if board is not time attack
+func (g *Game) TimeLimit() (time.Duration, bool) {
+	if g.timeAttack == nil {
+		return 0, false
+	}
+	return g.timeAttack.Limit, true
+}
+
+// gives time attack bonus and penalty for the interaction that was just made
+func (g *Game) updateTimeAttack() {
+	if bonus := g.timeAttack.OnReveal(g.prevBoard, g.board); bonus > 0 {
+		g.Events.Publish(EventTimeChanged{Change: bonus})
+	}
+	// clock at the interaction that cleared the board, as the replay has it
+	// so that engine.ResultFromReplay gets the same time left
+	if g.GameState == engine.GameStateWon {
+		if penalty := g.timeAttack.OnClear(g.board, g.replayRecorder.LastEventTime()); penalty > 0 {
+			g.Events.Publish(EventTimeChanged{Change: -penalty})
+		}
+	}
+}
+
+// returns nil if board is not flags variant
+func (g *Game) FlagsMatch() *engine.FlagsMatch {
+	return g.flagsMatch
+}
+
+// number of revealed tiles that are not mines
+func (g *Game) RevealedSafeTileCount() int {
+	count := 0
+	for i, revealed := range g.board.Revealed.Data {
+		if revealed && !g.board.Mines.Data[i] {
+			count++
+		}
+	}
+	return count
+}
+
 func (g *Game) HadInteraction() bool {
 	return g.hadInteraction
 }
 
+// starts rating the board once there is a first step
+func (g *Game) startRating() {
+	x, y, ok := g.replayRecorder.Replay.FirstStep()
+	if g.rating != nil || !ok {
+		return
+	}
+
+	code := g.GameCode()
+	rating := make(chan engine.BoardRating, 1)
+	go func() {
+		rating <- engine.RateBoard(code, x, y)
+	}()
+	g.rating = rating
+}
+
+// Rating returns rating of current board,
+// false before the first step or while it's still being made.
+// If wait is true, it waits for it instead.
+//
+// Rating is saved with the replay once it's made.
+func (g *Game) Rating(wait bool) (engine.BoardRating, bool) {
+	if g.replayRecorder.Replay.Rating == nil && g.rating != nil {
+		if wait {
+			g.replayRecorder.SetRating(<-g.rating)
+		} else {
+			select {
+			case rating := <-g.rating:
+				g.replayRecorder.SetRating(rating)
+			default:
+			}
+		}
+	}
+
+	if g.replayRecorder.Replay.Rating == nil {
+		return engine.BoardRating{}, false
+	}
+	return *g.replayRecorder.Replay.Rating, true
+}
+
+// RecordHint counts a hint shown to player in the replay of current game
+func (g *Game) RecordHint() {
+	g.replayRecorder.RecordHint()
+}
+
+// returns replay of current board
+// (it's finished only when game is over)
+func (g *Game) Replay() engine.Replay {
+	return g.replayRecorder.Replay
+}
+
+// Result returns result of current game, false if it hasn't ended yet
+func (g *Game) Result() (engine.GameResult, bool) {
+	if g.result == nil {
+		return engine.GameResult{}, false
+	}
+	return *g.result, true
+}
+
 func (g *Game) NoInputZone() FRectangle {
 	return g.noInputZone
 }
@@ -1390,14 +1961,17 @@ func GetTileFillColor(boardWidth, boardHeight, x, y int) color.Color {
 	}
 }
 
-func GetAnimationTargetTileStyle(board Board, x, y int) TileStyle {
+func GetAnimationTargetTileStyle(board engine.Board, x, y int) TileStyle {
 	style := NewTileStyle()
 
 	style.DrawBg = true
 	style.BgFillColor = GetBgFillColor(board.Width, board.Height, x, y)
 
 	if board.IsPosInBoard(x, y) {
-		if board.Revealed.Get(x, y) {
+		if board.Revealed.Get(x, y) && board.Mines.Get(x, y) {
+			// claimed mine in flags
+			style.BgBombAnim = 1
+		} else if board.Revealed.Get(x, y) {
 			style.DrawTile = true
 			style.DrawBg = false
 
@@ -1423,6 +1997,23 @@ func GetAnimationTargetTileStyle(board Board, x, y int) TileStyle {
 	return style
 }
 
+// GetAnimationTargetTileStyle with things that only Game knows about
+func (g *Game) targetTileStyle(x, y int) TileStyle {
+	style := GetAnimationTargetTileStyle(g.board, x, y)
+
+	if g.flagsMatch != nil && g.board.IsPosInBoard(x, y) {
+		if owner := g.flagsMatch.Owners.Get(x, y); owner >= 0 {
+			style.BgBombColor = ColorTableGetFlagsPlayer(int(owner))
+		}
+	}
+
+	if g.lives > 0 && g.board.IsPosInBoard(x, y) && g.board.Revealed.Get(x, y) && g.board.Mines.Get(x, y) {
+		style.BgBombColor = ColorMineHit
+	}
+
+	return style
+}
+
 type VIBuffer struct {
 	Vertices []eb.Vertex
 	Indices  []uint16
@@ -1751,19 +2342,19 @@ func VIaddSubViewInRect(
 var DBC = struct { // DrawBoard cache
 	VIBuffers [2]*VIBuffer
 
-	ShouldDrawBgTile Array2D[bool]
-	ShouldDrawTile   Array2D[bool]
-	ShouldDrawFgTile Array2D[bool]
+	ShouldDrawBgTile engine.Array2D[bool]
+	ShouldDrawTile   engine.Array2D[bool]
+	ShouldDrawFgTile engine.Array2D[bool]
 
-	BgTileRects Array2D[FRectangle]
+	BgTileRects engine.Array2D[FRectangle]
 
-	TileStrokeRects Array2D[FRectangle]
-	TileFillRects   Array2D[FRectangle]
+	TileStrokeRects engine.Array2D[FRectangle]
+	TileFillRects   engine.Array2D[FRectangle]
 
-	FgTileRects Array2D[FRectangle]
+	FgTileRects engine.Array2D[FRectangle]
 
-	TileFirmlyPlaced Array2D[bool]
-	TileRoundness    Array2D[[4]bool]
+	TileFirmlyPlaced engine.Array2D[bool]
+	TileRoundness    engine.Array2D[[4]bool]
 
 	WaterRenderTarget *eb.Image
 
@@ -1781,7 +2372,7 @@ func DrawBoard(
 
 	boardWidth, boardHeight int,
 	boardRect FRectangle,
-	tileStyles Array2D[TileStyle],
+	tileStyles engine.Array2D[TileStyle],
 
 	// params for water effect
 	doWaterEffect bool,
@@ -1815,7 +2406,7 @@ func DrawBoard(
 		}
 	}
 
-	iter := NewBoardIterator(0, 0, boardWidth-1, boardHeight-1)
+	iter := engine.NewBoardIterator(0, 0, boardWidth-1, boardHeight-1)
 
 	if DBC.NumberGlyphCache == nil {
 		DBC.NumberGlyphCache = NewNumberGlyphCache()
@@ -2023,10 +2614,15 @@ func DrawBoard(
 
 			innerRect = innerRect.Add(FPt(0, innerMargin))
 
+			var bombColor color.Color = ColorMineBg1
+			if style.BgBombColor != nil {
+				bombColor = style.BgBombColor
+			}
+
 			VIaddAllRoundTile(
 				shapeBuf,
 				outerRect,
-				modColor(ColorMineBg1, style.BgAlpha, style.Highlight, ColorBgHighLight),
+				modColor(bombColor, style.BgAlpha, style.Highlight, ColorBgHighLight),
 			)
 			VIaddAllRoundTile(
 				shapeBuf,
@@ -2535,7 +3131,7 @@ func NewTileHighlightModifier() StyleModifier {
 		return (a+b)*(a+b+1)/2 + b
 	}
 
-	highlightTile := func(tileStyles Array2D[TileStyle], x, y int) {
+	highlightTile := func(tileStyles engine.Array2D[TileStyle], x, y int) {
 		tileStyles.Data[x+tileStyles.Width*y].Highlight = 1
 
 		pairN := pair(u64(x), u64(y))
@@ -2550,15 +3146,15 @@ func NewTileHighlightModifier() StyleModifier {
 	}
 
 	return func(
-		prevBoard, board Board,
+		prevBoard, board engine.Board,
 		boardRect FRectangle,
-		interaction BoardInteractionType,
+		interaction engine.BoardInteractionType,
 		stateChanged bool, // GameState or board has changed
-		prevGameState, gameState GameState,
-		tileStyles Array2D[TileStyle], // modify these to change style
+		prevGameState, gameState engine.GameState,
+		tileStyles engine.Array2D[TileStyle], // modify these to change style
 		gi GameInput,
 	) bool {
-		if gameState != GameStatePlaying || gi.Type == InputTypeNone {
+		if gameState != engine.GameStatePlaying || gi.Type == InputTypeNone {
 			hlTiles = hlTiles[:0]
 			prevHlTiles = prevHlTiles[:0]
 
@@ -2586,12 +3182,12 @@ func NewTileHighlightModifier() StyleModifier {
 		hlX = gi.BoardX
 		hlY = gi.BoardY
 
-		var iter BoardIterator
+		var iter engine.BoardIterator
 
 		if hlWide {
-			iter = NewBoardIterator(hlX-1, hlY-1, hlX+1, hlY+1)
+			iter = engine.NewBoardIterator(hlX-1, hlY-1, hlX+1, hlY+1)
 		} else {
-			iter = NewBoardIterator(hlX, hlY, hlX, hlY)
+			iter = engine.NewBoardIterator(hlX, hlY, hlX, hlY)
 		}
 
 		for iter.HasNext() {
@@ -2633,18 +3229,54 @@ func NewTileHighlightModifier() StyleModifier {
 	}
 }
 
+// highlights numbers of the deduction player missed, see PostMortem
+func (g *Game) postMortemStyleModifier() StyleModifier {
+	return func(
+		prevBoard, board engine.Board,
+		boardRect FRectangle,
+		interaction engine.BoardInteractionType,
+		stateChanged bool,
+		prevGameState, gameState engine.GameState,
+		tileStyles engine.Array2D[TileStyle],
+		gi GameInput,
+	) bool {
+		pm := g.postMortem
+		if pm == nil || gameState != engine.GameStateLost {
+			return false
+		}
+
+		g.postMortemTimer.TickUp()
+		t := g.postMortemTimer.Normalize()
+
+		highlight := func(x, y int) {
+			if board.IsPosInBoard(x, y) {
+				tileStyles.Data[x+tileStyles.Width*y].Highlight = t
+			}
+		}
+
+		for _, n := range pm.Numbers {
+			highlight(n[0], n[1])
+		}
+		if pm.Cause == engine.LossCauseNeedlessGuess {
+			highlight(pm.MissedX, pm.MissedY)
+		}
+
+		return g.postMortemTimer.Current < g.postMortemTimer.Duration+UpdateDelta()
+	}
+}
+
 func NewFgClickModifier() StyleModifier {
 	const clickTimeDuration = time.Millisecond * 100
 
 	clickTimers := make(map[image.Point]Timer)
 
 	return func(
-		prevBoard, board Board,
+		prevBoard, board engine.Board,
 		boardRect FRectangle,
-		interaction BoardInteractionType,
+		interaction engine.BoardInteractionType,
 		stateChanged bool,
-		prevGameState, gameState GameState,
-		tileStyles Array2D[TileStyle],
+		prevGameState, gameState engine.GameState,
+		tileStyles engine.Array2D[TileStyle],
 		gi GameInput,
 	) bool {
 		prevClickTimers := make(map[image.Point]time.Duration)
@@ -2727,8 +3359,8 @@ func NewFgClickModifier() StyleModifier {
 	}
 }
 
-func (g *Game) QueueRevealAnimation(revealsBefore, revealsAfter Array2D[bool], originX, originY int) {
-	iter := NewBoardIterator(0, 0, g.board.Width-1, g.board.Height-1)
+func (g *Game) QueueRevealAnimation(revealsBefore, revealsAfter engine.Array2D[bool], originX, originY int) {
+	iter := engine.NewBoardIterator(0, 0, g.board.Width-1, g.board.Height-1)
 
 	getDist := func(x, y int) float64 {
 		return FPt(f64(originX), f64(originY)).Sub(FPt(f64(x), f64(y))).Length()
@@ -2741,7 +3373,7 @@ func (g *Game) QueueRevealAnimation(revealsBefore, revealsAfter Array2D[bool], o
 
 	var playedAt time.Time
 	playSound := func() {
-		if g.GameState != GameStatePlaying {
+		if g.GameState != engine.GameStatePlaying {
 			return
 		}
 		now := time.Now()
@@ -2755,10 +3387,15 @@ func (g *Game) QueueRevealAnimation(revealsBefore, revealsAfter Array2D[bool], o
 	distSquaredMax := 0
 	revealedTileCount := 0
 
+	// mines hit with lives have their own animation, see QueueMineHitAnimation
+	isHitMine := func(x, y int) bool {
+		return g.lives > 0 && g.board.Mines.Get(x, y)
+	}
+
 	iter.Reset()
 	for iter.HasNext() {
 		x, y := iter.GetNext()
-		if !revealsBefore.Get(x, y) && revealsAfter.Get(x, y) {
+		if !revealsBefore.Get(x, y) && revealsAfter.Get(x, y) && !isHitMine(x, y) {
 			distSquaredMax = max(distSquaredMax, getDistSquared(x, y))
 			minDist = min(minDist, getDist(x, y))
 			revealedTileCount++
@@ -2776,7 +3413,7 @@ func (g *Game) QueueRevealAnimation(revealsBefore, revealsAfter Array2D[bool], o
 			continue
 		}
 
-		if revealsBefore.Get(x, y) {
+		if revealsBefore.Get(x, y) || isHitMine(x, y) {
 			continue
 		}
 
@@ -2790,7 +3427,7 @@ func (g *Game) QueueRevealAnimation(revealsBefore, revealsAfter Array2D[bool], o
 		timer.Duration += time.Millisecond * 5
 		timer.Current = time.Duration(-distSubMinDist * f64(time.Millisecond) * 20)
 
-		targetStyle := GetAnimationTargetTileStyle(g.board, x, y)
+		targetStyle := g.targetTileStyle(x, y)
 
 		var anim CallbackAnimation
 		anim.Tag = AnimationTagTileReveal
@@ -3045,7 +3682,7 @@ func (g *Game) QueueDefeatAnimation(originX, originY int) {
 	// =================================
 	// remove wrongly placed flags
 	// =================================
-	iter := NewBoardIterator(0, 0, g.board.Width-1, g.board.Height-1)
+	iter := engine.NewBoardIterator(0, 0, g.board.Width-1, g.board.Height-1)
 
 	iter.Reset()
 	for iter.HasNext() {
@@ -3063,7 +3700,8 @@ func (g *Game) QueueDefeatAnimation(originX, originY int) {
 	iter.Reset()
 	for iter.HasNext() {
 		x, y := iter.GetNext()
-		if g.board.Mines.Get(x, y) && !g.board.Flags.Get(x, y) {
+		// mines hit with lives are already shown
+		if g.board.Mines.Get(x, y) && !g.board.Flags.Get(x, y) && !g.board.Revealed.Get(x, y) {
 			minePoses = append(minePoses, image.Point{X: x, Y: y})
 		}
 	}
@@ -3188,6 +3826,172 @@ func (g *Game) QueueDefeatAnimation(originX, originY int) {
 		return defeatAnimTimer.Current >= defeatAnimTimer.Duration && zoomAnim.Done()
 	}
 
+	anim.AfterDone = func() {
+		zoomAnim.AfterDone()
+		g.QueueRetryButtonAnimation()
+
+		if g.result != nil && g.result.Loss != nil {
+			g.postMortem = g.result.Loss
+			g.postMortemTimer = Timer{Duration: time.Millisecond * 400}
+		}
+	}
+
+	g.GameAnimations.Enqueue(anim)
+}
+
+// QueueMineHitAnimation shows mine at mineX, mineY going off
+// without ending the game, when board has lives
+func (g *Game) QueueMineHitAnimation(mineX, mineY int) {
+	var timer Timer
+	timer.Duration = time.Millisecond * 400
+
+	playedSound := false
+
+	var anim CallbackAnimation
+	anim.Tag = AnimationTagMineHit
+
+	anim.Update = func() {
+		if !playedSound {
+			playedSound = true
+			PlaySoundBytes(SePop, 0.6)
+		}
+
+		style := g.BaseTileStyles.Get(mineX, mineY)
+
+		timer.TickUp()
+		t := timer.Normalize()
+
+		style.DrawBg = true
+		style.BgBombAnim = Clamp(t*3, 0, 1)
+		style.BgBombColor = ColorMineHit
+		// pops out and settles back
+		style.BgScale = 1 + math.Sin(t*math.Pi)*0.35
+
+		g.BaseTileStyles.Set(mineX, mineY, style)
+	}
+
+	anim.Skip = func() {
+		timer.Current = timer.Duration
+		playedSound = true
+		anim.Update()
+	}
+
+	anim.Done = func() bool {
+		return timer.Current >= timer.Duration
+	}
+
+	anim.AfterDone = func() {
+		g.BaseTileStyles.Set(mineX, mineY, g.targetTileStyle(mineX, mineY))
+	}
+
+	g.TileAnimations.Get(mineX, mineY).Enqueue(anim)
+}
+
+// QueueTimeUpAnimation freezes hidden tiles from top to bottom
+// and shows where mines were, when time attack clock runs out
+func (g *Game) QueueTimeUpAnimation() {
+	PlaySoundBytes(SePop, 0.4)
+
+	// =================================
+	// remove wrongly placed flags
+	// =================================
+	iter := engine.NewBoardIterator(0, 0, g.board.Width-1, g.board.Height-1)
+
+	for iter.HasNext() {
+		x, y := iter.GetNext()
+		if g.board.Flags.Get(x, y) && !g.board.Mines.Get(x, y) {
+			g.QueueRemoveFlagAnimation(x, y)
+		}
+	}
+
+	// =================================
+	// queue freezing hidden tiles
+	// =================================
+	const rowOffset = time.Millisecond * 25
+
+	var timeUpDuration time.Duration
+
+	iter.Reset()
+	for iter.HasNext() {
+		x, y := iter.GetNext()
+		if g.board.Revealed.Get(x, y) {
+			continue
+		}
+
+		showMine := g.board.Mines.Get(x, y) && !g.board.Flags.Get(x, y)
+
+		var timer Timer
+		timer.Duration = time.Millisecond * 350
+		timer.Current = -rowOffset * time.Duration(y)
+
+		timeUpDuration = max(timeUpDuration, timer.Duration-timer.Current)
+
+		var anim CallbackAnimation
+		anim.Tag = AnimationTagTimeUp
+
+		var ogBgColor color.Color
+
+		anim.Update = func() {
+			style := g.BaseTileStyles.Get(x, y)
+
+			if ogBgColor == nil {
+				ogBgColor = style.BgFillColor
+			}
+
+			timer.TickUp()
+			t := timer.Normalize()
+
+			style.BgFillColor = LerpColorRGBA(ogBgColor, ColorTimeUp, EaseOutQuint(t)*0.6)
+			// shrinks a little like it's been frozen
+			style.BgScale = 1 - math.Sin(t*math.Pi*0.5)*0.08
+
+			if showMine {
+				style.BgBombColor = ColorTimeUp
+				style.BgBombAnim = Clamp(t*2-1, 0, 1)
+			}
+
+			g.BaseTileStyles.Set(x, y, style)
+		}
+
+		anim.Skip = func() {
+			timer.Current = timer.Duration
+			anim.Update()
+		}
+
+		anim.Done = func() bool {
+			return timer.Current >= timer.Duration
+		}
+
+		g.TileAnimations.Get(x, y).Enqueue(anim)
+	}
+
+	// =================================
+	// after the time up animaiton
+	// queue retry button show animation
+	// =================================
+	var timeUpAnimTimer Timer
+	timeUpAnimTimer.Duration = timeUpDuration + time.Millisecond*10
+
+	var anim CallbackAnimation
+	anim.Tag = AnimationTagTimeUp
+
+	zoomAnim := g.GetZoomOutAnimation(AnimationTagTimeUp)
+
+	anim.Update = func() {
+		zoomAnim.Update()
+		timeUpAnimTimer.TickUp()
+	}
+
+	anim.Skip = func() {
+		zoomAnim.Skip()
+		timeUpAnimTimer.Current = timeUpAnimTimer.Duration
+		anim.Update()
+	}
+
+	anim.Done = func() bool {
+		return timeUpAnimTimer.Current >= timeUpAnimTimer.Duration && zoomAnim.Done()
+	}
+
 	anim.AfterDone = func() {
 		zoomAnim.AfterDone()
 		g.QueueRetryButtonAnimation()
@@ -3322,7 +4126,11 @@ func (g *Game) QueueWinAnimation(originX, originY int) {
 
 	anim.AfterDone = func() {
 		zoomAnim.AfterDone()
-		g.QueueRetryButtonAnimation()
+		if g.ContinueAfterWin {
+			g.QueueResetBoardAnimation()
+		} else {
+			g.QueueRetryButtonAnimation()
+		}
 	}
 
 	g.GameAnimations.Enqueue(anim)
@@ -3353,7 +4161,7 @@ func (g *Game) QueueRetryButtonAnimation() {
 		maxTileX = Clamp(maxTileX, 0, g.board.Width-1)
 		maxTileY = Clamp(maxTileY, 0, g.board.Height-1)
 
-		iter := NewBoardIterator(
+		iter := engine.NewBoardIterator(
 			minTileX, minTileY, maxTileX, maxTileY,
 		)
 
@@ -3580,7 +4388,7 @@ func (g *Game) QueueResetBoardAnimation() {
 
 		anim.AfterDone = func() {
 			g.DrawRetryButton = true
-			g.ResetBoardNotStyles()
+			g.ResetBoardNotStylesEx(!g.RetrySameBoard)
 			g.QueueShowBoardAnimation(
 				g.board.Width/2,
 				g.board.Height/2,
@@ -3618,7 +4426,7 @@ func (g *Game) QueueShowBoardAnimation(originX, originy int) {
 			var anim CallbackAnimation
 			anim.Tag = AnimationTagShowBoard
 
-			targetStyle := GetAnimationTargetTileStyle(g.board, x, y)
+			targetStyle := g.targetTileStyle(x, y)
 
 			anim.Update = func() {
 				timer.TickUp()
@@ -3673,7 +4481,7 @@ func (g *Game) QueueShowBoardAnimation(originX, originy int) {
 		}
 
 		anim.AfterDone = func() {
-			g.GameState = GameStatePlaying
+			g.GameState = engine.GameStatePlaying
 		}
 
 		g.GameAnimations.Enqueue(anim)
@@ -3925,7 +4733,7 @@ func (g *Game) SetDebugBoardForDecoration() {
 
 	g.mineCount = 0
 
-	iter := NewBoardIterator(0, 0, newBoardWidth-1, newBoardHeight-1)
+	iter := engine.NewBoardIterator(0, 0, newBoardWidth-1, newBoardHeight-1)
 	for iter.HasNext() {
 		x, y := iter.GetNext()
 		if g.board.IsPosInBoard(x, y) {
@@ -3944,7 +4752,7 @@ func (g *Game) SetDebugBoardForDecoration() {
 		}
 	}
 
-	iter = NewBoardIterator(0, 0, g.board.Width-1, g.board.Height-1)
+	iter = engine.NewBoardIterator(0, 0, g.board.Width-1, g.board.Height-1)
 	for iter.HasNext() {
 		x, y := iter.GetNext()
 		if x < newBoardWidth+1 && y < newBoardHeight+1 {
@@ -3965,7 +4773,7 @@ func (g *Game) SetDebugBoardForDecoration() {
 		if !g.board.Mines.Get(x, y) {
 			if rand.Int64N(100) < 30 {
 				// flag the surrounding
-				innerIter := NewBoardIterator(x-1, y-1, x+1, y+1)
+				innerIter := engine.NewBoardIterator(x-1, y-1, x+1, y+1)
 				for innerIter.HasNext() {
 					inX, inY := innerIter.GetNext()
 					if g.board.IsPosInBoard(inX, inY) && g.board.Mines.Get(inX, inY) {
@@ -4304,12 +5112,12 @@ func NewFlagTutorial() *FlagTutorial {
 
 func (ft *FlagTutorial) GetFlagTutorialStyleModifier() StyleModifier {
 	return func(
-		prevBoard, board Board,
+		prevBoard, board engine.Board,
 		boardRect FRectangle,
-		interaction BoardInteractionType,
+		interaction engine.BoardInteractionType,
 		stateChanged bool,
-		prevGameState, gameState GameState,
-		tileStyles Array2D[TileStyle],
+		prevGameState, gameState engine.GameState,
+		tileStyles engine.Array2D[TileStyle],
 		gi GameInput,
 	) bool {
 		if !ft.foundGoodTile {
@@ -4320,7 +5128,7 @@ func (ft *FlagTutorial) GetFlagTutorialStyleModifier() StyleModifier {
 			return false
 		}
 
-		iter := NewBoardIterator(
+		iter := engine.NewBoardIterator(
 			ft.numberTileX-1, ft.numberTileY-1,
 			ft.numberTileX+1, ft.numberTileY+1,
 		)
@@ -4356,7 +5164,7 @@ func (ft *FlagTutorial) GetFlagTutorialStyleModifier() StyleModifier {
 }
 
 func (ft *FlagTutorial) Update(
-	board Board,
+	board engine.Board,
 	boardRect FRectangle,
 	maxRect FRectangle,
 ) {
@@ -4552,7 +5360,7 @@ func (ft *FlagTutorial) Update(
 
 func (ft *FlagTutorial) Draw(
 	dst *eb.Image,
-	board Board,
+	board engine.Board,
 	boardRect FRectangle,
 ) {
 	if ft.foundGoodTile && ft.animationTimer.Current >= 0 && ft.ShowFlagTutorial {
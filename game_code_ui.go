@@ -0,0 +1,276 @@
+package minesweeper
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"minesweeper/engine"
+
+	eb "github.com/hajimehoshi/ebiten/v2"
+	ebt "github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// popup for sharing and entering game codes
+type GameCodeUI struct {
+	DoShow bool
+
+	// code that is shown to user so that they can share it
+	CurrentCode engine.GameCode
+
+	// shown under the current code, see engine.BoardRating
+	Rating string
+
+	// called when user entered a valid game code
+	OnStart func(code engine.GameCode)
+
+	InputText string
+
+	CopyButton  *TextButton
+	StartButton *TextButton
+	CloseButton *TextButton
+
+	message        string
+	messageIsError bool
+
+	inputChars []rune
+}
+
+const gameCodeUIMaxInputLen = 128
+
+func NewGameCodeUI() *GameCodeUI {
+	cu := new(GameCodeUI)
+
+	cu.CopyButton = NewTextButton()
+	cu.CopyButton.Text = "copy"
+	cu.CopyButton.OnPress = func(bool) {
+		ClipboardWriteText(cu.CurrentCode.String())
+		InfoLogger.Printf("game code : %s", cu.CurrentCode.String())
+		cu.setMessage("copied current code", false)
+	}
+
+	cu.StartButton = NewTextButton()
+	cu.StartButton.Text = "start"
+	cu.StartButton.OnPress = func(bool) {
+		cu.submit()
+	}
+
+	cu.CloseButton = NewTextButton()
+	cu.CloseButton.Text = "close"
+	cu.CloseButton.OnPress = func(bool) {
+		cu.Hide()
+	}
+
+	return cu
+}
+
+func (cu *GameCodeUI) Show() {
+	cu.DoShow = true
+	cu.InputText = ""
+	cu.message = ""
+	SetTypingText(true)
+	SetRedraw()
+}
+
+func (cu *GameCodeUI) Hide() {
+	cu.DoShow = false
+	SetTypingText(false)
+	SetRedraw()
+}
+
+func (cu *GameCodeUI) setMessage(msg string, isError bool) {
+	cu.message = msg
+	cu.messageIsError = isError
+	SetRedraw()
+}
+
+func (cu *GameCodeUI) appendInput(str string) {
+	for _, r := range str {
+		if len(cu.InputText) >= gameCodeUIMaxInputLen {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == ' ' {
+			cu.InputText += string(r)
+		}
+	}
+	SetRedraw()
+}
+
+func (cu *GameCodeUI) submit() {
+	code, err := engine.ParseGameCode(cu.InputText)
+	if err != nil {
+		cu.setMessage(err.Error(), true)
+		return
+	}
+
+	cu.Hide()
+
+	if cu.OnStart != nil {
+		cu.OnStart(code)
+	}
+}
+
+func (cu *GameCodeUI) PanelRect() FRectangle {
+	w := min(ScreenWidth*0.92, 560)
+	h := min(ScreenHeight*0.6, w*0.55)
+
+	rect := FRectWH(w, h)
+	rect = CenterFRectangle(rect, ScreenWidth*0.5, ScreenHeight*0.5)
+
+	return rect
+}
+
+// returns rectangles for
+// title, current code, rating, input box, message, buttons
+func (cu *GameCodeUI) layout() (FRectangle, FRectangle, FRectangle, FRectangle, FRectangle, [3]FRectangle) {
+	panel := cu.PanelRect()
+	inner := panel.Inset(min(panel.Dx(), panel.Dy()) * 0.06)
+
+	rowH := inner.Dy() / 6
+
+	row := func(i float64, height float64) FRectangle {
+		return FRectXYWH(inner.Min.X, inner.Min.Y+rowH*i, inner.Dx(), rowH*height)
+	}
+
+	title := row(0, 1)
+	current := row(1, 0.65)
+	rating := row(1.65, 0.45)
+	input := row(2.2, 1.1)
+	message := row(3.4, 0.8)
+
+	var buttons [3]FRectangle
+	{
+		btnRow := row(4.6, 1.2)
+		margin := btnRow.Dx() * 0.04
+		btnW := (btnRow.Dx() - margin*2) / 3
+
+		for i := range 3 {
+			buttons[i] = FRectXYWH(
+				btnRow.Min.X+(btnW+margin)*f64(i), btnRow.Min.Y,
+				btnW, btnRow.Dy(),
+			)
+		}
+	}
+
+	return title, current, rating, input, message, buttons
+}
+
+func (cu *GameCodeUI) Update() {
+	if !cu.DoShow {
+		return
+	}
+
+	// ==========================
+	// text input
+	// ==========================
+	cu.inputChars = eb.AppendInputChars(cu.inputChars[:0])
+	if len(cu.inputChars) > 0 {
+		cu.appendInput(string(cu.inputChars))
+	}
+
+	ctrlPressed := IsKeyPressed(eb.KeyControl) || IsKeyPressed(eb.KeyMeta)
+
+	if ctrlPressed && IsKeyJustPressed(eb.KeyV) {
+		cu.appendInput(strings.TrimSpace(ClipboardReadText()))
+	}
+
+	const firstRate = 400 * time.Millisecond
+	const repeatRate = 40 * time.Millisecond
+
+	if HandleKeyRepeat(firstRate, repeatRate, eb.KeyBackspace) && len(cu.InputText) > 0 {
+		runes := []rune(cu.InputText)
+		cu.InputText = string(runes[:len(runes)-1])
+		SetRedraw()
+	}
+
+	if IsKeyJustPressed(eb.KeyEnter) || IsKeyJustPressed(eb.KeyNumpadEnter) {
+		cu.submit()
+	}
+	if IsKeyJustPressed(eb.KeyEscape) {
+		cu.Hide()
+	}
+
+	// ==========================
+	// buttons
+	// ==========================
+	_, _, _, _, _, buttons := cu.layout()
+
+	cu.CopyButton.Rect = buttons[0]
+	cu.StartButton.Rect = buttons[1]
+	cu.CloseButton.Rect = buttons[2]
+
+	cu.CopyButton.Update()
+	cu.StartButton.Update()
+	cu.CloseButton.Update()
+
+	// for cursor blinking
+	SetRedraw()
+}
+
+func (cu *GameCodeUI) Draw(dst *eb.Image) {
+	if !cu.DoShow {
+		return
+	}
+
+	FillRect(dst, FRectWH(ScreenWidth, ScreenHeight), ColorPopupDim)
+
+	panel := cu.PanelRect()
+	radius := min(panel.Dx(), panel.Dy()) * 0.05
+
+	FillRoundRect(dst, panel, radius, true, ColorPopupBg)
+	StrokeRoundRect(dst, panel, radius, true, 2, ColorPopupStroke)
+
+	titleRect, currentRect, ratingRect, inputRect, messageRect, _ := cu.layout()
+
+	drawLine := func(text string, face *ebt.GoTextFace, rect FRectangle, clr ColorTableIndex) {
+		face.Size = rect.Dy() * 0.75
+		WidthLimitFace(text, face, rect.Dx())
+
+		op := &DrawTextOptions{}
+		op.GeoM.Translate(rect.Min.X, rect.Min.Y+rect.Dy()*0.5-FaceSize(face)*0.5)
+		op.ColorScale.ScaleWithColor(clr)
+
+		DrawText(dst, text, face, op)
+	}
+
+	titleFace := &ebt.GoTextFace{Source: FaceSource}
+	titleFace.SetVariation(ebt.MustParseTag("wght"), 700)
+	drawLine("Game Code", titleFace, titleRect, ColorPopupText)
+
+	codeFace := &ebt.GoTextFace{Source: ClearFace.Source}
+	drawLine(cu.CurrentCode.String(), codeFace, currentRect, ColorPopupText)
+
+	if len(cu.Rating) > 0 {
+		ratingFace := &ebt.GoTextFace{Source: FaceSource}
+		drawLine(cu.Rating, ratingFace, ratingRect, ColorPopupText)
+	}
+
+	// input box
+	{
+		StrokeRect(dst, inputRect, 2, ColorPopupStroke)
+
+		text := cu.InputText
+		if (GlobalTimerNow()/(time.Millisecond*500))%2 == 0 {
+			text += "_"
+		}
+		if len(cu.InputText) <= 0 {
+			text = "type or paste a code" + text
+		}
+
+		inputFace := &ebt.GoTextFace{Source: ClearFace.Source}
+		drawLine(text, inputFace, inputRect.Inset(inputRect.Dy()*0.15), ColorPopupText)
+	}
+
+	if len(cu.message) > 0 {
+		clr := ColorPopupText
+		if cu.messageIsError {
+			clr = ColorPopupError
+		}
+		messageFace := &ebt.GoTextFace{Source: FaceSource}
+		drawLine(cu.message, messageFace, messageRect, clr)
+	}
+
+	cu.CopyButton.Draw(dst)
+	cu.StartButton.Draw(dst)
+	cu.CloseButton.Draw(dst)
+}
@@ -0,0 +1,172 @@
+package minesweeper
+
+import (
+	"image"
+	"slices"
+	"time"
+
+	"minesweeper/engine"
+)
+
+// ==============================================
+// events
+// ==============================================
+
+// GameEvent is something that happened in a Game.
+//
+// Events are published from Game.Update (and from board resets),
+// so subscribers are called on the game loop and can touch the game.
+type GameEvent interface {
+	isGameEvent()
+}
+
+// published before board is reset, reset parameters can still be changed here
+type EventBeforeBoardReset struct{}
+
+// published after board is reset
+type EventBoardReset struct {
+	Code engine.GameCode
+}
+
+// published when board or game state has changed for the first time since reset
+type EventFirstInteraction struct{}
+
+// published once for every update that changed board or game state,
+// after more specific events below
+type EventBoardChanged struct {
+	// what changed the board,
+	// InteractionTypeNone if it wasn't an interaction (debug boards for example)
+	Interaction engine.BoardInteractionType
+	X, Y        int
+}
+
+// tiles got revealed
+type EventTileRevealed struct {
+	Interaction engine.BoardInteractionType
+	X, Y        int
+
+	// every tile that got revealed, including X, Y if it was revealed
+	Tiles []image.Point
+
+	// true if revealing spread to tiles other than the one that was clicked
+	Cascade bool
+}
+
+type EventFlagPlaced struct {
+	X, Y int
+}
+
+// flag is gone, either by user or because the tile got revealed
+type EventFlagRemoved struct {
+	X, Y int
+}
+
+// check on a number whose flags were all placed,
+// published only if it changed something
+type EventChordPerformed struct {
+	X, Y int
+
+	// number of tiles it revealed
+	Revealed int
+}
+
+// user stepped on a mine at X, Y,
+// or chorded on X, Y next to a wrong flag
+//
+// In flags variant mines are claimed instead of losing,
+// and with lives left they're hit and game goes on.
+// This is still published for every mine that got revealed.
+type EventMineHit struct {
+	X, Y int
+}
+
+// time attack clock got a bonus (positive Change) or a penalty (negative Change),
+// see engine.TimeAttack
+type EventTimeChanged struct {
+	Change time.Duration
+}
+
+type EventWon struct {
+	Result engine.GameResult
+}
+
+type EventLost struct {
+	Result engine.GameResult
+}
+
+func (EventBeforeBoardReset) isGameEvent() {}
+func (EventBoardReset) isGameEvent()       {}
+func (EventFirstInteraction) isGameEvent() {}
+func (EventBoardChanged) isGameEvent()     {}
+func (EventTileRevealed) isGameEvent()     {}
+func (EventFlagPlaced) isGameEvent()       {}
+func (EventFlagRemoved) isGameEvent()      {}
+func (EventChordPerformed) isGameEvent()   {}
+func (EventMineHit) isGameEvent()          {}
+func (EventTimeChanged) isGameEvent()      {}
+func (EventWon) isGameEvent()              {}
+func (EventLost) isGameEvent()             {}
+
+// ==============================================
+// bus
+// ==============================================
+
+// returned by Subscribe, used to unsubscribe
+type Subscription int
+
+type subscriber struct {
+	id      Subscription
+	handler func(GameEvent)
+}
+
+// GameEventBus delivers published events to subscribers
+// in the order they subscribed.
+//
+// It's not safe to use from multiple goroutines,
+// everything happens on the game loop.
+type GameEventBus struct {
+	subscribers []subscriber
+	nextID      Subscription
+}
+
+func NewGameEventBus() *GameEventBus {
+	return &GameEventBus{
+		nextID: 1,
+	}
+}
+
+// Subscribe calls handler for every event of type E published to bus.
+func Subscribe[E GameEvent](bus *GameEventBus, handler func(E)) Subscription {
+	return bus.SubscribeAll(func(event GameEvent) {
+		if e, ok := event.(E); ok {
+			handler(e)
+		}
+	})
+}
+
+// SubscribeAll calls handler for every event published to bus.
+func (bus *GameEventBus) SubscribeAll(handler func(GameEvent)) Subscription {
+	id := bus.nextID
+	bus.nextID++
+
+	bus.subscribers = append(bus.subscribers, subscriber{id: id, handler: handler})
+
+	return id
+}
+
+// Unsubscribe stops calling handler of sub.
+//
+// It's fine to call it from a handler,
+// event being published still reaches everyone who was subscribed.
+func (bus *GameEventBus) Unsubscribe(sub Subscription) {
+	// don't modify the slice in place, Publish might be iterating over it
+	bus.subscribers = slices.DeleteFunc(slices.Clone(bus.subscribers), func(s subscriber) bool {
+		return s.id == sub
+	})
+}
+
+func (bus *GameEventBus) Publish(event GameEvent) {
+	for _, s := range bus.subscribers {
+		s.handler(event)
+	}
+}
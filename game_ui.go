@@ -4,41 +4,32 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"path/filepath"
 	"time"
 
+	"minesweeper/engine"
+	"minesweeper/lesson"
+	"minesweeper/puzzle"
+	"minesweeper/spectate"
+
 	eb "github.com/hajimehoshi/ebiten/v2"
 	ebt "github.com/hajimehoshi/ebiten/v2/text/v2"
 )
 
 var _ = color.White
 
-type Difficulty int
-
-const (
-	DifficultyEasy Difficulty = iota
-	DifficultyMedium
-	DifficultyHard
-	DifficultySize
-)
-
-var DifficultyStrs = [DifficultySize]string{
-	"Easy",
-	"Medium",
-	"Hard",
-}
-
 type GameUI struct {
 	Game *Game
 
-	Difficulty Difficulty
+	Difficulty engine.Difficulty
 
-	MineCounts [DifficultySize]int
+	MineCounts [engine.DifficultySize]int
 
-	BoardTileCountsNormal [DifficultySize]image.Point // constant
-	BoardTileCountsMobile [DifficultySize]image.Point // constant
+	BoardTileCountsNormal [engine.DifficultySize]image.Point // constant
+	BoardTileCountsMobile [engine.DifficultySize]image.Point // constant
 
-	BoardSizeRatiosNormal [DifficultySize]float64 // constant, relative to board area
-	BoardSizeRatiosMobile [DifficultySize]float64 // constant, relative to board area
+	BoardSizeRatiosNormal [engine.DifficultySize]float64 // constant, relative to board area
+	BoardSizeRatiosMobile [engine.DifficultySize]float64 // constant, relative to board area
 
 	ButtonSizeRatioNormal float64 // constant, relative to min(ScreenWidth, ScreenHeight)
 	ButtonSizeRatioMobile float64 // constant, relative to min(ScreenWidth, ScreenHeight)
@@ -52,6 +43,48 @@ type GameUI struct {
 	TopUIHeight    float64 // constant, relative to ScreenHeight
 	TopUIMinHeight float64 // constant
 
+	// if true, board is made from CustomBoard instead of Difficulty
+	UseCustomBoard bool
+	CustomBoard    engine.GameCode
+
+	// variants of boards made from Difficulty
+	Variants engine.GameVariant
+	// first click policy of boards made from Difficulty
+	FirstClick engine.FirstClickPolicy
+	// 3BV or rating range of boards made from Difficulty
+	Target engine.BoardTarget
+	// how mines are placed on boards made from Difficulty,
+	// engine.PlacerLayout only makes sense for CustomBoard
+	Placer engine.PlacerKind
+	// lives of boards made from Difficulty, 0 for normal games
+	Lives int
+
+	GameCodeUI *GameCodeUI
+
+	// not nil when playing in a race
+	RaceUI *RaceUI
+
+	// not nil when playing a lesson
+	LessonUI *LessonUI
+	// lessons LessonKey goes through
+	Lessons []lesson.Lesson
+
+	// not nil when playing a puzzle
+	PuzzleUI *PuzzleUI
+	// puzzles PuzzleKey goes through
+	Puzzles []puzzle.Puzzle
+	// how player did on puzzles, kept in save data
+	PuzzleRecord puzzle.Record
+
+	// not nil when playing a survival run
+	SurvivalUI *SurvivalUI
+
+	// if not empty, replay of every finished game is saved here
+	ReplayDir string
+
+	// if not nil, game is streamed to spectators
+	Spectators *spectate.Server
+
 	ResourceEditor *ResourceEditor
 
 	wasOnMobile bool
@@ -63,17 +96,13 @@ func NewGameUI() *GameUI {
 	gu.wasOnMobile = ProbablyOnMobile()
 
 	// set constants
-	gu.MineCounts = [DifficultySize]int{10, 40, 99}
+	gu.MineCounts = engine.DifficultyMineCounts
 
-	gu.BoardTileCountsNormal = [DifficultySize]image.Point{
-		image.Pt(10, 10), image.Pt(16, 16), image.Pt(22, 22),
-	}
-	gu.BoardTileCountsMobile = [DifficultySize]image.Point{
-		image.Pt(10, 10), image.Pt(13, 20), image.Pt(18, 27),
-	}
+	gu.BoardTileCountsNormal = engine.DifficultyBoardSizesNormal
+	gu.BoardTileCountsMobile = engine.DifficultyBoardSizesMobile
 
-	gu.BoardSizeRatiosNormal = [DifficultySize]float64{0.75, 0.9, 1}
-	gu.BoardSizeRatiosMobile = [DifficultySize]float64{1, 1, 1}
+	gu.BoardSizeRatiosNormal = [engine.DifficultySize]float64{0.75, 0.9, 1}
+	gu.BoardSizeRatiosMobile = [engine.DifficultySize]float64{1, 1, 1}
 
 	gu.ButtonSizeRatioNormal = 0.2
 	gu.ButtonSizeRatioMobile = 0.33
@@ -86,33 +115,122 @@ func NewGameUI() *GameUI {
 	gu.BoardMarginHorizontal = 10
 
 	gu.Game = NewGame(
-		gu.BoardTileCount(DifficultyEasy).X, gu.BoardTileCount(DifficultyEasy).Y,
-		gu.MineCounts[DifficultyEasy],
+		gu.BoardTileCount(engine.DifficultyEasy).X, gu.BoardTileCount(engine.DifficultyEasy).Y,
+		gu.MineCounts[engine.DifficultyEasy],
 	)
-	gu.Game.OnFirstInteraction = func() {
+	Subscribe(gu.Game.Events, func(EventFirstInteraction) {
 		gu.TopUI.TimerUI.Start()
+	})
+	Subscribe(gu.Game.Events, func(e EventTimeChanged) {
+		gu.TopUI.TimerUI.ShowTimeChange(e.Change)
+	})
+	onGameEnd := func(result engine.GameResult) {
+		// time attack clock stops where the replay ends,
+		// so that it shows result.TimeLeft
+		if _, countDown := gu.Game.TimeLimit(); countDown {
+			gu.TopUI.TimerUI.PauseAt(result.Duration)
+		} else {
+			gu.TopUI.TimerUI.Pause()
+		}
+		// replay can't start from a lesson or puzzle board
+		if gu.LessonUI == nil && gu.PuzzleUI == nil {
+			gu.SaveReplay(result)
+		}
+		if gu.RaceUI != nil {
+			gu.RaceUI.OnGameEnd(result)
+		}
+		if gu.SurvivalUI != nil {
+			gu.SurvivalUI.OnGameEnd(result)
+		}
 	}
-	gu.Game.OnGameEnd = func(didWin bool) {
-		gu.TopUI.TimerUI.Pause()
+	Subscribe(gu.Game.Events, func(e EventWon) { onGameEnd(e.Result) })
+	Subscribe(gu.Game.Events, func(e EventLost) { onGameEnd(e.Result) })
+	Subscribe(gu.Game.Events, func(EventBeforeBoardReset) {
+		// retry after a lesson is done goes to the next one
+		if gu.LessonUI != nil && gu.LessonUI.Progress.Done() {
+			gu.advanceLesson()
+		}
+		// and so does retry after a puzzle is solved
+		if gu.PuzzleUI != nil && gu.PuzzleUI.Attempt.Solved() {
+			gu.advancePuzzle()
+		}
+		// retry after a survival run is over starts a new run
+		if gu.SurvivalUI != nil && gu.SurvivalUI.Run.Over() {
+			gu.SurvivalUI.NewRun()
+		}
+		gu.SetGameResetParameter()
+		// timer keeps going through boards of a survival run
+		if gu.SurvivalUI == nil || gu.SurvivalUI.Run.Stage() == 0 {
+			gu.TopUI.TimerUI.Reset()
+		}
+	})
+	Subscribe(gu.Game.Events, func(e EventBoardReset) {
+		if gu.Spectators != nil {
+			gu.Spectators.Reset(e.Code)
+		}
+		if gu.LessonUI != nil {
+			gu.LessonUI.Restart()
+		}
+		if gu.PuzzleUI != nil {
+			gu.PuzzleUI.Restart()
+		}
+	})
+	Subscribe(gu.Game.Events, func(e EventBoardChanged) {
+		if gu.Spectators != nil {
+			gu.Spectators.Update(gu.Game.Board(), gu.Game.GameState, e.Interaction, e.X, e.Y)
+		}
+		if gu.LessonUI != nil {
+			gu.LessonUI.OnBoardChanged(gu.Game.Board())
+		}
+	})
+	gu.Game.OnRemoteNewBoard = func(code engine.GameCode) {
+		gu.UseRemoteBoard(code)
 	}
-	gu.Game.OnBeforeBoardReset = func() {
-		gu.Game.SetResetParameter(
-			gu.BoardTileCount(gu.Difficulty).X, gu.BoardTileCount(gu.Difficulty).Y,
-			gu.MineCounts[gu.Difficulty],
-		)
-		gu.TopUI.TimerUI.Reset()
+
+	gu.Game.InteractionFilter = func(interaction engine.BoardInteractionType, x, y int) bool {
+		if gu.LessonUI != nil {
+			allow := gu.LessonUI.Allow(gu.Game.Board(), interaction, x, y)
+			if !allow && gu.LessonUI.ShowsHint() {
+				gu.Game.RecordHint()
+			}
+			return allow
+		}
+		if gu.PuzzleUI != nil {
+			return gu.PuzzleUI.Allow(gu.Game.Board(), interaction, x, y)
+		}
+		return true
+	}
+	gu.Game.StyleModifiers = append(gu.Game.StyleModifiers, gu.lessonStyleModifier(), gu.puzzleStyleModifier())
+
+	if lessons, err := lesson.Builtin(); err != nil {
+		ErrLogger.Printf("failed to load lessons: %v", err)
+	} else {
+		gu.Lessons = lessons
 	}
 
+	if puzzles, err := puzzle.Builtin(); err != nil {
+		ErrLogger.Printf("failed to load puzzles: %v", err)
+	} else {
+		gu.Puzzles = puzzles
+	}
+	gu.loadPuzzleRecord()
+
 	gu.TopUI = NewTopUI()
-	gu.TopUI.DifficultySelectUI.OnDifficultyChange = func(newDifficulty Difficulty) {
+	gu.TopUI.DifficultySelectUI.OnDifficultyChange = func(newDifficulty engine.Difficulty) {
 		gu.Difficulty = newDifficulty
-		gu.Game.SetResetParameter(
-			gu.BoardTileCount(gu.Difficulty).X, gu.BoardTileCount(gu.Difficulty).Y,
-			gu.MineCounts[gu.Difficulty],
-		)
+		gu.UseCustomBoard = false
+		gu.StopLesson()
+		gu.StopPuzzle()
+		gu.StopSurvival()
+		gu.SetGameResetParameter()
 		gu.Game.ResetBoard()
 	}
 
+	gu.GameCodeUI = NewGameCodeUI()
+	gu.GameCodeUI.OnStart = func(code engine.GameCode) {
+		gu.StartGameCode(code)
+	}
+
 	gu.ResourceEditor = NewResourceEditor()
 
 	return gu
@@ -122,28 +240,104 @@ func (gu *GameUI) Update() {
 	if gu.wasOnMobile != ProbablyOnMobile() {
 		gu.wasOnMobile = ProbablyOnMobile()
 
-		if !gu.Game.HadInteraction() {
-			gu.Game.SetResetParameter(
-				gu.BoardTileCount(gu.Difficulty).X, gu.BoardTileCount(gu.Difficulty).Y,
-				gu.MineCounts[gu.Difficulty],
-			)
-			gu.Game.ResetBoard()
+		if !gu.Game.HadInteraction() && !gu.UseCustomBoard &&
+			gu.LessonUI == nil && gu.PuzzleUI == nil && gu.SurvivalUI == nil {
+			gu.SetGameResetParameter()
+			// keep the seed, it might have been set from launch options
+			gu.Game.ResetBoardEx(false)
 		}
 	}
 
+	if IsKeyJustPressed(GameCodeKey) && !gu.GameCodeUI.DoShow {
+		gu.GameCodeUI.CurrentCode = gu.Game.GameCode()
+		gu.GameCodeUI.Rating = gu.RatingText()
+		gu.GameCodeUI.Show()
+	} else {
+		// rating might not have been ready when it was shown
+		if gu.GameCodeUI.DoShow && gu.GameCodeUI.Rating == "" {
+			if rating := gu.RatingText(); rating != "" {
+				gu.GameCodeUI.Rating = rating
+				SetRedraw()
+			}
+		}
+		gu.GameCodeUI.Update()
+	}
+
+	if IsHotkeyJustPressed(LessonKey) && gu.RaceUI == nil && gu.Game.Remote == nil {
+		gu.StopPuzzle()
+		gu.StopSurvival()
+		gu.StartNextLesson()
+	}
+	if IsHotkeyJustPressed(PuzzleKey) && gu.RaceUI == nil && gu.Game.Remote == nil {
+		gu.StartNextPuzzle()
+	}
+	if IsHotkeyJustPressed(SurvivalKey) && gu.RaceUI == nil && gu.Game.Remote == nil {
+		gu.StartSurvivalFromDifficulty()
+	}
+
 	gu.TopUI.Rect = gu.TopUIRect()
-	gu.TopUI.Update()
+	if !gu.GameCodeUI.DoShow {
+		gu.TopUI.Update()
+	}
 
-	gu.Game.SetNoInputZone(gu.TopUI.Rect)
+	if gu.RaceUI != nil {
+		gu.RaceUI.Rect = gu.RaceUIRect()
+		gu.RaceUI.Update(gu.Game)
+	}
+
+	if gu.LessonUI != nil {
+		gu.LessonUI.Rect = gu.LessonUIRect()
+	}
+
+	if gu.PuzzleUI != nil {
+		gu.PuzzleUI.Rect = gu.PuzzleUIRect()
+		if gu.PuzzleUI.Update() && !gu.GameCodeUI.DoShow {
+			gu.StartNextPuzzle()
+		}
+	}
+
+	if gu.SurvivalUI != nil {
+		gu.SurvivalUI.Rect = gu.SurvivalUIRect()
+	}
+
+	if gu.GameCodeUI.DoShow || (gu.RaceUI != nil && gu.RaceUI.BlocksInput()) {
+		gu.Game.SetNoInputZone(FRectWH(ScreenWidth, ScreenHeight))
+	} else {
+		gu.Game.SetNoInputZone(gu.TopUI.Rect)
+	}
 
 	gu.Game.MaxRect = gu.MaxGameRect()
 	gu.Game.Rect = gu.BoardRect()
 	gu.Game.SetRetryButtonSize(min(ScreenWidth, ScreenHeight) * gu.ButtonSizeRatio())
+
+	if gu.Spectators != nil {
+		gu.Spectators.Tick(gu.TopUI.TimerUI.CurrentTime())
+	}
+
 	gu.Game.Update()
 
 	gu.TopUI.FlagUI.FlagCount = gu.Game.MineCount() - gu.Game.FlagCount()
 
-	if IsKeyJustPressed(ShowResourceEditorKey) && IsDevVersion {
+	gu.TopUI.ShowLivesUI = gu.Game.GameCode().Lives > 0
+	gu.TopUI.LivesUI.Lives = gu.Game.LivesLeft()
+
+	gu.TopUI.TimerUI.TimeLimit, gu.TopUI.TimerUI.CountDown = gu.Game.TimeLimit()
+
+	if match := gu.Game.FlagsMatch(); match != nil {
+		if !gu.TopUI.ShowTurnUI || gu.TopUI.TurnUI.Claims != match.Claims {
+			SetRedraw()
+		}
+		gu.TopUI.ShowTurnUI = true
+		gu.TopUI.TurnUI.Claims = match.Claims
+		gu.TopUI.TurnUI.Turn = match.Turn
+		if gu.Game.GameState != engine.GameStatePlaying {
+			gu.TopUI.TurnUI.Turn = -1
+		}
+	} else {
+		gu.TopUI.ShowTurnUI = false
+	}
+
+	if IsHotkeyJustPressed(ShowResourceEditorKey) && IsDevVersion {
 		gu.ResourceEditor.DoShow = !gu.ResourceEditor.DoShow
 	}
 	if gu.ResourceEditor.DoShow {
@@ -157,16 +351,241 @@ func (gu *GameUI) Update() {
 func (gu *GameUI) Draw(dst *eb.Image) {
 	gu.Game.Draw(dst)
 
+	if pm := gu.Game.PostMortem(); pm != nil {
+		gu.drawPostMortem(dst, *pm)
+	}
+
 	gu.TopUI.Draw(dst)
 
+	if gu.RaceUI != nil {
+		gu.RaceUI.Draw(dst, gu.Game.TransformedBoardRect())
+	}
+
+	if gu.LessonUI != nil {
+		gu.LessonUI.Draw(dst)
+	}
+
+	if gu.PuzzleUI != nil {
+		gu.PuzzleUI.Draw(dst)
+	}
+
+	if gu.SurvivalUI != nil {
+		gu.SurvivalUI.Draw(dst)
+	}
+
+	gu.GameCodeUI.Draw(dst)
+
 	gu.ResourceEditor.Draw(dst)
 }
 
+func postMortemText(pm engine.LossAnalysis) string {
+	switch pm.Cause {
+	case engine.LossCauseForcedGuess:
+		return fmt.Sprintf("Bad luck, it was a forced guess (%.0f%% safe)", pm.Odds*100)
+	case engine.LossCauseNeedlessGuess:
+		return fmt.Sprintf("Guessed at %.0f%%, highlighted numbers show a safe tile", pm.Odds*100) +
+			postMortemHint(pm)
+	default:
+		return "Mistake, highlighted numbers show it's a mine" + postMortemHint(pm)
+	}
+}
+
+func postMortemHint(pm engine.LossAnalysis) string {
+	switch {
+	case pm.Arrangements && pm.UsesMineCount:
+		return " (all of them at once, count mines left)"
+	case pm.Arrangements:
+		return " (all of them at once)"
+	case pm.UsesMineCount:
+		return " (count mines left)"
+	}
+	return ""
+}
+
+// draws why game was lost at the bottom of the board
+func (gu *GameUI) drawPostMortem(dst *eb.Image, pm engine.LossAnalysis) {
+	boardRect := gu.Game.TransformedBoardRect()
+
+	height := min(boardRect.Dy()*0.08, 40)
+	rect := FRectXYWH(boardRect.Min.X, boardRect.Max.Y-height, boardRect.Dx(), height)
+
+	FillRect(dst, rect, ColorFade(ColorPopupBg, 0.8))
+
+	text := postMortemText(pm)
+	textRect := rect.Inset(height * 0.2)
+
+	face := &ebt.GoTextFace{Source: FaceSource}
+	face.Size = textRect.Dy()
+	WidthLimitFace(text, face, textRect.Dx())
+
+	w, _ := ebt.Measure(text, face, FaceLineSpacing(face))
+
+	op := &DrawTextOptions{}
+	op.GeoM.Translate(textRect.Min.X+textRect.Dx()*0.5-w*0.5, textRect.Min.Y+textRect.Dy()*0.5-FaceSize(face)*0.5)
+	op.ColorScale.ScaleWithColor(ColorPopupText)
+
+	DrawText(dst, text, face, op)
+}
+
+// DrawTextPanel draws a panel with bold title and text under it,
+// text is wrapped and shrunk until it fits in rect
+func DrawTextPanel(dst *eb.Image, rect FRectangle, title, text string) {
+	FillRect(dst, rect, ColorFade(ColorPopupBg, 0.8))
+
+	rect = rect.Inset(min(rect.Dx(), rect.Dy()) * 0.08)
+
+	// title
+	titleFace := &ebt.GoTextFace{Source: FaceSource}
+	titleFace.Size = min(rect.Dy()*0.2, 30)
+	titleFace.SetVariation(ebt.MustParseTag("wght"), 700)
+	WidthLimitFace(title, titleFace, rect.Dx())
+
+	op := &DrawTextOptions{}
+	op.GeoM.Translate(rect.Min.X, rect.Min.Y)
+	op.ColorScale.ScaleWithColor(ColorPopupText)
+
+	DrawText(dst, title, titleFace, op)
+
+	// text, shrink until it fits
+	textRect := rect
+	textRect.Min.Y += FaceLineSpacing(titleFace) * 1.2
+
+	face := &ebt.GoTextFace{Source: FaceSource}
+	face.Size = titleFace.Size * 0.9
+
+	var wrapped string
+	for range 10 {
+		wrapped = WrapText(text, face, textRect.Dx())
+		if _, h := ebt.Measure(wrapped, face, FaceLineSpacing(face)); h <= textRect.Dy() {
+			break
+		}
+		face.Size *= 0.9
+	}
+
+	op = &DrawTextOptions{}
+	op.GeoM.Translate(textRect.Min.X, textRect.Min.Y)
+	op.LineSpacing = FaceLineSpacing(face)
+	op.ColorScale.ScaleWithColor(ColorPopupText)
+
+	DrawText(dst, wrapped, face, op)
+}
+
+// sets reset parameter of the Game
+// using either LessonUI, PuzzleUI, SurvivalUI, Difficulty or CustomBoard.
+//
+// Boards of a survival run come from the run,
+// so it sets seed of the Game too.
+func (gu *GameUI) SetGameResetParameter() {
+	var pos *engine.Board
+	if gu.LessonUI != nil {
+		pos = &gu.LessonUI.Position
+	} else if gu.PuzzleUI != nil {
+		pos = &gu.PuzzleUI.Position
+	}
+	if pos != nil {
+		gu.Game.SetResetParameterEx(pos.Width, pos.Height, pos.MineCount(), 0, engine.FirstClickSafeArea)
+		gu.Game.SetResetLives(0)
+		gu.Game.SetResetPosition(pos)
+		return
+	}
+	gu.Game.SetResetPosition(nil)
+
+	if gu.SurvivalUI != nil {
+		code := gu.SurvivalUI.Run.Code()
+		gu.Game.SetResetParameterEx(code.Width, code.Height, code.MineCount, code.Variants, code.FirstClick)
+		gu.Game.SetResetTarget(code.Target, code.TargetV2)
+		gu.Game.SetResetPlacer(code.Placer, code.Layout)
+		gu.Game.SetResetLives(0)
+		gu.Game.Seed = code.Seed
+		return
+	}
+
+	if gu.UseCustomBoard {
+		gu.Game.SetResetParameterEx(
+			gu.CustomBoard.Width, gu.CustomBoard.Height,
+			gu.CustomBoard.MineCount,
+			gu.CustomBoard.Variants, gu.CustomBoard.FirstClick,
+		)
+		gu.Game.SetResetTarget(gu.CustomBoard.Target, gu.CustomBoard.TargetV2)
+		gu.Game.SetResetPlacer(gu.CustomBoard.Placer, gu.CustomBoard.Layout)
+		gu.Game.SetResetLives(gu.CustomBoard.Lives)
+	} else {
+		gu.Game.SetResetParameterEx(
+			gu.BoardTileCount(gu.Difficulty).X, gu.BoardTileCount(gu.Difficulty).Y,
+			gu.MineCounts[gu.Difficulty],
+			gu.Variants, gu.FirstClick,
+		)
+		gu.Game.SetResetTarget(gu.Target, false)
+		gu.Game.SetResetPlacer(gu.Placer, "")
+		gu.Game.SetResetLives(gu.Lives)
+	}
+}
+
+// saves replay of a finished game to ReplayDir (if it's set)
+func (gu *GameUI) SaveReplay(result engine.GameResult) {
+	if gu.ReplayDir == "" {
+		return
+	}
+
+	replay := result.Replay
+	path := filepath.Join(gu.ReplayDir, engine.ReplayFileName(replay, time.Now()))
+
+	if err := engine.SaveReplay(path, replay); err != nil {
+		ErrLogger.Printf("failed to save replay %s: %v", path, err)
+	} else {
+		InfoLogger.Printf("saved replay %s", path)
+	}
+}
+
+// rating of current board, empty before the first step
+// since mines are placed then, and while it's being made
+func (gu *GameUI) RatingText() string {
+	if rating, ok := gu.Game.Rating(false); ok && rating.BBBV > 0 {
+		return rating.String()
+	}
+	return ""
+}
+
+// makes board settings match the board server started
+//
+// board stays a difficulty preset if it looks like one,
+// so that difficulty select doesn't show custom for no reason
+func (gu *GameUI) UseRemoteBoard(code engine.GameCode) {
+	if !gu.UseCustomBoard &&
+		code.Variants == gu.Variants && code.FirstClick == gu.FirstClick &&
+		code.Target == gu.Target && !code.TargetV2 && code.Placer == gu.Placer && code.Lives == gu.Lives &&
+		code.Width == gu.BoardTileCount(gu.Difficulty).X &&
+		code.Height == gu.BoardTileCount(gu.Difficulty).Y &&
+		code.MineCount == gu.MineCounts[gu.Difficulty] {
+		return
+	}
+
+	gu.UseCustomBoard = true
+	gu.CustomBoard = code
+
+	gu.TopUI.DifficultySelectUI.IsCustom = true
+}
+
+// starts exactly the same board that code describes
+func (gu *GameUI) StartGameCode(code engine.GameCode) {
+	gu.UseCustomBoard = true
+	gu.CustomBoard = code
+
+	gu.StopLesson()
+	gu.StopPuzzle()
+	gu.StopSurvival()
+
+	gu.SetGameResetParameter()
+	gu.Game.Seed = code.Seed
+	gu.Game.ResetBoardEx(false)
+	SetRedraw()
+}
+
 func (gu *GameUI) Layout(outsideWidth, outsideHeight int) {
 	gu.Game.Layout(outsideWidth, outsideHeight)
 }
 
-func (gu *GameUI) BoardTileCount(difficulty Difficulty) image.Point {
+func (gu *GameUI) BoardTileCount(difficulty engine.Difficulty) image.Point {
 	if ProbablyOnMobile() {
 		return gu.BoardTileCountsMobile[difficulty]
 	} else {
@@ -174,7 +593,10 @@ func (gu *GameUI) BoardTileCount(difficulty Difficulty) image.Point {
 	}
 }
 
-func (gu *GameUI) BoardSizeRatio(difficulty Difficulty) float64 {
+func (gu *GameUI) BoardSizeRatio(difficulty engine.Difficulty) float64 {
+	if gu.UseCustomBoard || gu.LessonUI != nil || gu.PuzzleUI != nil || gu.SurvivalUI != nil {
+		return 1
+	}
 	if ProbablyOnMobile() {
 		return gu.BoardSizeRatiosMobile[difficulty]
 	} else {
@@ -193,9 +615,20 @@ func (gu *GameUI) ButtonSizeRatio() float64 {
 func (gu *GameUI) MaxGameRect() FRectangle {
 	topRect := gu.TopUI.RenderedRect()
 
+	bottom := ScreenHeight
+	if gu.RaceUI != nil {
+		bottom = gu.RaceUIRect().Min.Y
+	} else if gu.LessonUI != nil {
+		bottom = gu.LessonUIRect().Min.Y
+	} else if gu.PuzzleUI != nil {
+		bottom = gu.PuzzleUIRect().Min.Y
+	} else if gu.SurvivalUI != nil {
+		bottom = gu.SurvivalUIRect().Min.Y
+	}
+
 	return FRect(
 		gu.BoardMarginHorizontal, topRect.Max.Y+gu.BoardMarginTop,
-		ScreenWidth-gu.BoardMarginHorizontal, ScreenHeight-gu.BoardMarginBottom,
+		ScreenWidth-gu.BoardMarginHorizontal, bottom-gu.BoardMarginBottom,
 	)
 }
 
@@ -221,6 +654,24 @@ func (gu *GameUI) BoardRect() FRectangle {
 	return boardRect
 }
 
+func (gu *GameUI) RaceUIRect() FRectangle {
+	h := gu.RaceUI.Height()
+	return FRectXYWH(0, ScreenHeight-h, ScreenWidth, h)
+}
+
+// joins a race, board is started by the race from now on
+func (gu *GameUI) JoinRace(client *RaceClient) {
+	gu.RaceUI = NewRaceUI(client)
+
+	gu.RaceUI.OnStartPressed = func() {
+		client.RequestStart(gu.Game.GameCode())
+	}
+	gu.RaceUI.OnRaceStart = func(start RaceStart) {
+		gu.GameCodeUI.Hide()
+		gu.StartGameCode(start.Code)
+	}
+}
+
 func (gu *GameUI) TopUIRect() FRectangle {
 	w := ScreenWidth
 	h := max(ScreenHeight*gu.TopUIHeight, gu.TopUIMinHeight)
@@ -241,13 +692,21 @@ type TopUI struct {
 	FlagUI             *FlagUI
 	DifficultySelectUI *DifficultySelectUI
 	TimerUI            *TimerUI
+	TurnUI             *TurnUI
+	LivesUI            *LivesUI
+
+	// show TurnUI instead of FlagUI
+	ShowTurnUI bool
+	// show LivesUI left of TimerUI
+	ShowLivesUI bool
 
 	UIScale float64
 
 	MuteButtonUIRect       FRectangle
-	FlagUIRect             FRectangle
+	FlagUIRect             FRectangle // also used for TurnUI
 	DifficultySelectUIRect FRectangle
 	TimerUIRect            FRectangle
+	LivesUIRect            FRectangle
 }
 
 func NewTopUI() *TopUI {
@@ -260,10 +719,20 @@ func NewTopUI() *TopUI {
 	tu.FlagUI = NewFlagUI()
 	tu.DifficultySelectUI = NewDifficultySelectUI()
 	tu.TimerUI = NewTimerUI()
+	tu.TurnUI = NewTurnUI()
+	tu.LivesUI = NewLivesUI()
 
 	return tu
 }
 
+// returns either FlagUI or TurnUI
+func (tu *TopUI) flagOrTurnUI() *TopUIElement {
+	if tu.ShowTurnUI {
+		return &tu.TurnUI.TopUIElement
+	}
+	return &tu.FlagUI.TopUIElement
+}
+
 func (tu *TopUI) Update() {
 	var totalIdealWidth float64
 
@@ -271,12 +740,17 @@ func (tu *TopUI) Update() {
 	const idealMuteMargin = 27
 
 	idealMuteW := tu.MuteButtonUI.GetIdealWidth()
-	idealFlagW := tu.FlagUI.GetIdealWidth()
+	idealFlagW := tu.flagOrTurnUI().GetIdealWidth()
 	idealDifficultyW := tu.DifficultySelectUI.GetIdealWidth()
 	idealTimerW := tu.TimerUI.GetIdealWidth()
 
+	idealLivesW := 0.0
+	if tu.ShowLivesUI {
+		idealLivesW = idealMuteMargin + tu.LivesUI.GetIdealWidth() + idealMargin
+	}
+
 	totalIdealWidth = max(
-		idealMargin+idealTimerW+idealMargin+idealDifficultyW*0.5,
+		idealLivesW+idealMargin+idealTimerW+idealMargin+idealDifficultyW*0.5,
 		idealDifficultyW*0.5+idealMargin+idealFlagW+idealMargin+idealMuteW+idealMuteMargin,
 	) * 2
 
@@ -312,6 +786,14 @@ func (tu *TopUI) Update() {
 		difficultyW, uiHeight,
 	)
 	timerMinX := uiRect.Min.X
+	if tu.ShowLivesUI {
+		livesW := tu.LivesUI.GetIdealWidth() * tu.UIScale
+		tu.LivesUIRect = FRectXYWH(
+			uiRect.Min.X+muteMargin, uiRect.Min.Y,
+			livesW, uiHeight,
+		)
+		timerMinX = tu.LivesUIRect.Max.X + margin
+	}
 	timerMaxX := tu.DifficultySelectUIRect.Min.X - timerW
 	tu.TimerUIRect = FRectXYWH(
 		Lerp(timerMinX, timerMaxX, 0.53),
@@ -329,7 +811,10 @@ func (tu *TopUI) Update() {
 	tu.MuteButtonUI.OnUpdate(tu.MuteButtonUIRect, tu.UIScale)
 	tu.TimerUI.OnUpdate(tu.TimerUIRect, tu.UIScale)
 	tu.DifficultySelectUI.OnUpdate(tu.DifficultySelectUIRect, tu.UIScale)
-	tu.FlagUI.OnUpdate(tu.FlagUIRect, tu.UIScale)
+	tu.flagOrTurnUI().OnUpdate(tu.FlagUIRect, tu.UIScale)
+	if tu.ShowLivesUI {
+		tu.LivesUI.OnUpdate(tu.LivesUIRect, tu.UIScale)
+	}
 }
 
 func (tu *TopUI) Draw(dst *eb.Image) {
@@ -342,7 +827,10 @@ func (tu *TopUI) Draw(dst *eb.Image) {
 	tu.MuteButtonUI.OnDraw(dst, tu.MuteButtonUIRect, tu.UIScale)
 	tu.TimerUI.OnDraw(dst, tu.TimerUIRect, tu.UIScale)
 	tu.DifficultySelectUI.OnDraw(dst, tu.DifficultySelectUIRect, tu.UIScale)
-	tu.FlagUI.OnDraw(dst, tu.FlagUIRect, tu.UIScale)
+	tu.flagOrTurnUI().OnDraw(dst, tu.FlagUIRect, tu.UIScale)
+	if tu.ShowLivesUI {
+		tu.LivesUI.OnDraw(dst, tu.LivesUIRect, tu.UIScale)
+	}
 }
 
 // TopUI's display rect might be smaller than
@@ -379,10 +867,18 @@ type DifficultySelectUI struct {
 	DifficultyButtonLeft  *ImageButton
 	DifficultyButtonRight *ImageButton
 
-	Difficulty         Difficulty
-	OnDifficultyChange func(difficulty Difficulty)
+	Difficulty         engine.Difficulty
+	OnDifficultyChange func(difficulty engine.Difficulty)
+
+	// board is not from one of the difficulties
+	// (started from a game code for example)
+	IsCustom bool
+	// shown instead of CustomDifficultyStr if not empty
+	CustomLabel string
 }
 
+const CustomDifficultyStr = "Custom"
+
 func NewDifficultySelectUI() *DifficultySelectUI {
 	ds := new(DifficultySelectUI)
 
@@ -396,7 +892,7 @@ func NewDifficultySelectUI() *DifficultySelectUI {
 		ds.DifficultyButtonLeft.OnPress = func(bool) {
 			prevDifficulty := ds.Difficulty
 			ds.Difficulty = max(ds.Difficulty-1, 0)
-			if ds.OnDifficultyChange != nil && prevDifficulty != ds.Difficulty {
+			if ds.OnDifficultyChange != nil && (prevDifficulty != ds.Difficulty || ds.IsCustom) {
 				ds.OnDifficultyChange(ds.Difficulty)
 			}
 		}
@@ -414,8 +910,8 @@ func NewDifficultySelectUI() *DifficultySelectUI {
 
 		ds.DifficultyButtonRight.OnPress = func(bool) {
 			prevDifficulty := ds.Difficulty
-			ds.Difficulty = min(ds.Difficulty+1, DifficultySize-1)
-			if ds.OnDifficultyChange != nil && prevDifficulty != ds.Difficulty {
+			ds.Difficulty = min(ds.Difficulty+1, engine.DifficultySize-1)
+			if ds.OnDifficultyChange != nil && (prevDifficulty != ds.Difficulty || ds.IsCustom) {
 				ds.OnDifficultyChange(ds.Difficulty)
 			}
 		}
@@ -433,7 +929,7 @@ func NewDifficultySelectUI() *DifficultySelectUI {
 	var idealBtnRectRight FRectangle
 
 	var idealMaxTextWidth float64
-	var idealTextWidths [DifficultySize]float64
+	var idealTextWidths [engine.DifficultySize]float64
 	var idealTextCenterX float64
 
 	const idealFaceSize = 71
@@ -444,8 +940,8 @@ func NewDifficultySelectUI() *DifficultySelectUI {
 	}
 	idealFace.SetVariation(ebt.MustParseTag("wght"), 700)
 
-	for d := Difficulty(0); d < DifficultySize; d++ {
-		str := DifficultyStrs[d]
+	for d := engine.Difficulty(0); d < engine.DifficultySize; d++ {
+		str := engine.DifficultyStrs[d]
 		w, _ := ebt.Measure(
 			str,
 			idealFace,
@@ -454,6 +950,14 @@ func NewDifficultySelectUI() *DifficultySelectUI {
 		idealTextWidths[d] = w
 		idealMaxTextWidth = max(w, idealMaxTextWidth)
 	}
+	{
+		w, _ := ebt.Measure(
+			CustomDifficultyStr,
+			idealFace,
+			FaceLineSpacing(idealFace),
+		)
+		idealMaxTextWidth = max(w, idealMaxTextWidth)
+	}
 
 	const idealMargin = 15
 	var idealBtnSize FPoint = FPt(70, 70)
@@ -495,7 +999,7 @@ func NewDifficultySelectUI() *DifficultySelectUI {
 		ds.DifficultyButtonRight.Update()
 	}
 
-	difficultyTextOffsetsY := [DifficultySize]float64{
+	difficultyTextOffsetsY := [engine.DifficultySize]float64{
 		-1.6,
 		0,
 		1,
@@ -509,7 +1013,15 @@ func NewDifficultySelectUI() *DifficultySelectUI {
 		textCenterY := (actualRect.Min.Y + actualRect.Max.Y) * 0.5
 		textCenterX := idealTextCenterX*scale + actualRect.Min.X
 
-		textCenterY += difficultyTextOffsetsY[ds.Difficulty] * scale
+		text := engine.DifficultyStrs[ds.Difficulty]
+		if ds.IsCustom {
+			text = CustomDifficultyStr
+			if ds.CustomLabel != "" {
+				text = ds.CustomLabel
+			}
+		} else {
+			textCenterY += difficultyTextOffsetsY[ds.Difficulty] * scale
+		}
 
 		faceSize := idealFaceSize * scale
 		face := &ebt.GoTextFace{
@@ -526,7 +1038,7 @@ func NewDifficultySelectUI() *DifficultySelectUI {
 		)
 		op.ColorScale.ScaleWithColor(ColorTopUITitle)
 
-		DrawText(dst, DifficultyStrs[ds.Difficulty], face, op)
+		DrawText(dst, text, face, op)
 	}
 
 	return ds
@@ -608,12 +1120,189 @@ func NewFlagUI() *FlagUI {
 	return fu
 }
 
+// shows claimed mines of each player in flags and whose turn it is,
+// takes FlagUI's place
+type TurnUI struct {
+	TopUIElement
+
+	Claims [engine.FlagsPlayerCount]int
+	Turn   int
+}
+
+func NewTurnUI() *TurnUI {
+	tu := new(TurnUI)
+
+	const idealFaceSize = 62
+	const idealTextY = 50
+	const idealMargin = 18
+
+	var idealScoreWidth float64
+	var idealColonWidth float64
+	{
+		idealFace := &ebt.GoTextFace{
+			Source: FaceSource,
+			Size:   idealFaceSize,
+		}
+		idealFace.SetVariation(ebt.MustParseTag("wght"), 700)
+
+		idealScoreWidth, _ = ebt.Measure("000", idealFace, FaceLineSpacing(idealFace))
+		idealColonWidth, _ = ebt.Measure(":", idealFace, FaceLineSpacing(idealFace))
+	}
+
+	tu.GetIdealWidth = func() float64 {
+		return idealScoreWidth*2 + idealMargin*2 + idealColonWidth
+	}
+
+	tu.OnUpdate = func(actualRect FRectangle, scale float64) {
+		// pass
+	}
+
+	tu.OnDraw = func(dst *eb.Image, actualRect FRectangle, scale float64) {
+		face := &ebt.GoTextFace{
+			Source: FaceSource,
+			Size:   idealFaceSize * scale,
+		}
+		face.SetVariation(ebt.MustParseTag("wght"), 700)
+
+		textCenterY := idealTextY*scale + actualRect.Min.Y
+
+		drawCentered := func(text string, centerX float64, clr color.Color) {
+			op := &DrawTextOptions{}
+			op.PrimaryAlign = ebt.AlignCenter
+			op.GeoM.Translate(centerX, textCenterY-FaceSize(face)*0.5)
+			op.ColorScale.ScaleWithColor(clr)
+
+			DrawText(dst, text, face, op)
+		}
+
+		scoreCenters := [engine.FlagsPlayerCount]float64{
+			actualRect.Min.X + idealScoreWidth*0.5*scale,
+			actualRect.Max.X - idealScoreWidth*0.5*scale,
+		}
+
+		drawCentered(":", actualRect.Min.X+actualRect.Dx()*0.5, ColorTopUITitle)
+
+		for player, centerX := range scoreCenters {
+			clr := ColorTableGetFlagsPlayer(player)
+
+			drawCentered(fmt.Sprintf("%d", tu.Claims[player]), centerX, clr)
+
+			// underline whoever's turn it is
+			if player == tu.Turn {
+				lineW := idealScoreWidth * 0.8 * scale
+				lineY := textCenterY + FaceSize(face)*0.5
+				FillRect(dst, FRectXYWH(centerX-lineW*0.5, lineY, lineW, 6*scale), clr)
+			}
+		}
+	}
+
+	return tu
+}
+
+// shows how many more mines player can hit on a board with lives
+type LivesUI struct {
+	TopUIElement
+
+	Lives int
+}
+
+func NewLivesUI() *LivesUI {
+	lu := new(LivesUI)
+
+	const idealMineSize = 76
+
+	var idealMineRect FRectangle = FRectXYWH(
+		0, TopUIIdealHeight*0.5-idealMineSize*0.5,
+		idealMineSize, idealMineSize,
+	)
+	idealMineRect = idealMineRect.Add(FPt(0, -3))
+
+	const idealFaceSize = 62
+
+	const idealMargin = 6
+
+	var idealTextX float64 = idealMineRect.Dx() + idealMargin
+	const idealTextY = 54
+
+	var idealMaxTextWidth float64
+	{
+		idealFace := &ebt.GoTextFace{
+			Source: FaceSource,
+			Size:   idealFaceSize,
+		}
+		idealFace.SetVariation(ebt.MustParseTag("wght"), 400)
+
+		w, _ := ebt.Measure(
+			"00", idealFace, FaceLineSpacing(idealFace))
+		idealMaxTextWidth = w
+	}
+
+	lu.GetIdealWidth = func() float64 {
+		return idealMineRect.Dx() + idealMargin + idealMaxTextWidth
+	}
+
+	prevLives := 0
+
+	lu.OnUpdate = func(actualRect FRectangle, scale float64) {
+		if prevLives != lu.Lives {
+			SetRedraw()
+			prevLives = lu.Lives
+		}
+	}
+
+	lu.OnDraw = func(dst *eb.Image, actualRect FRectangle, scale float64) {
+		mineRect := FRectScale(idealMineRect, scale).Add(actualRect.Min)
+
+		// draw mine icon, dimmed when there's no life left
+		var mineColor color.Color = ColorMineHit
+		if lu.Lives <= 0 {
+			mineColor = ColorFade(ColorTopUITitle, 0.5)
+		}
+		DrawSubViewInRect(
+			dst, mineRect, 1.0, 0, 0, mineColor, GetMineTile(),
+		)
+
+		textX := idealTextX*scale + actualRect.Min.X
+		textCenterY := idealTextY*scale + actualRect.Min.Y
+
+		text := fmt.Sprintf("%d", lu.Lives)
+
+		faceSize := idealFaceSize * scale
+		face := &ebt.GoTextFace{
+			Source: FaceSource,
+			Size:   faceSize,
+		}
+		face.SetVariation(ebt.MustParseTag("wght"), 400)
+		WidthLimitFace(text, face, idealMaxTextWidth*scale)
+
+		op := &DrawTextOptions{}
+		op.GeoM.Translate(textX, textCenterY-FaceSize(face)*0.5)
+		op.ColorScale.ScaleWithColor(ColorTopUITitle)
+
+		DrawText(dst, text, face, op)
+	}
+
+	return lu
+}
+
 type TimerUI struct {
 	TopUIElement
 
+	// if true, timer shows time left until TimeLimit instead of time passed
+	CountDown bool
+	TimeLimit time.Duration
+
 	ticking       bool
 	startTime     time.Time
 	timeStartFrom time.Duration
+
+	popups []timerPopup
+}
+
+// bonus or penalty floating away from the timer
+type timerPopup struct {
+	Change time.Duration
+	Timer  Timer
 }
 
 func NewTimerUI() *TimerUI {
@@ -663,8 +1352,11 @@ func NewTimerUI() *TimerUI {
 
 	var prevTime time.Duration
 
+	const idealPopupFaceSize = 44
+	const idealPopupMove = 60
+
 	tu.OnUpdate = func(actualRect FRectangle, scale float64) {
-		currentTime := tu.CurrentTime()
+		currentTime := tu.ShownTime()
 
 		hours, minutes, seconds := GetHourMinuteSeconds(currentTime)
 		prevHours, prevMinutes, prevSeconds := GetHourMinuteSeconds(prevTime)
@@ -673,6 +1365,19 @@ func NewTimerUI() *TimerUI {
 			SetRedraw()
 			prevTime = currentTime
 		}
+
+		// update popups
+		popups := tu.popups[:0]
+		for _, p := range tu.popups {
+			p.Timer.TickUp()
+			if p.Timer.Current < p.Timer.Duration {
+				popups = append(popups, p)
+			}
+		}
+		if len(popups) != len(tu.popups) || len(popups) > 0 {
+			SetRedraw()
+		}
+		tu.popups = popups
 	}
 
 	tu.OnDraw = func(dst *eb.Image, actualRect FRectangle, scale float64) {
@@ -686,7 +1391,7 @@ func NewTimerUI() *TimerUI {
 		textX := idealTextX*scale + actualRect.Min.X
 		textCenterY := idealTextY*scale + actualRect.Min.Y
 
-		currentTime := tu.CurrentTime()
+		currentTime := tu.ShownTime()
 
 		hours, minutes, seconds := GetHourMinuteSeconds(currentTime)
 
@@ -716,11 +1421,46 @@ func NewTimerUI() *TimerUI {
 		face.SetVariation(ebt.MustParseTag("wght"), 400)
 		WidthLimitFace(text, face, idealMaxTextWidth*scale)
 
+		// running out of time
+		var textColor color.Color = ColorTopUITitle
+		if tu.CountDown && tu.ticking && currentTime < time.Second*10 {
+			textColor = ColorTimePenalty
+		}
+
 		op := &DrawTextOptions{}
 		op.GeoM.Translate(textX, textCenterY-FaceSize(face)*0.5)
-		op.ColorScale.ScaleWithColor(ColorTopUITitle)
+		op.ColorScale.ScaleWithColor(textColor)
 
 		DrawText(dst, text, face, op)
+
+		// draw popups, they fall down from the text and fade out
+		for _, p := range tu.popups {
+			t := p.Timer.Normalize()
+
+			popupText := fmt.Sprintf("%+ds", int(p.Change/time.Second))
+
+			popupFace := &ebt.GoTextFace{
+				Source: FaceSource,
+				Size:   idealPopupFaceSize * scale,
+			}
+			popupFace.SetVariation(ebt.MustParseTag("wght"), 700)
+
+			var popupColor color.Color = ColorTimeBonus
+			if p.Change < 0 {
+				popupColor = ColorTimePenalty
+			}
+
+			w, _ := ebt.Measure(popupText, popupFace, FaceLineSpacing(popupFace))
+
+			op := &DrawTextOptions{}
+			op.GeoM.Translate(
+				textX+idealMaxTextWidth*scale*0.5-w*0.5,
+				textCenterY-FaceSize(popupFace)*0.5+EaseOutQuint(t)*idealPopupMove*scale,
+			)
+			op.ColorScale.ScaleWithColor(ColorFade(popupColor, 1-EaseInQuint(t)))
+
+			DrawText(dst, popupText, popupFace, op)
+		}
 	}
 
 	return tu
@@ -732,13 +1472,20 @@ func (tu *TimerUI) Start() {
 }
 
 func (tu *TimerUI) Pause() {
+	tu.PauseAt(tu.CurrentTime())
+}
+
+// PauseAt pauses timer showing t
+func (tu *TimerUI) PauseAt(t time.Duration) {
+	tu.timeStartFrom = t
 	tu.ticking = false
-	tu.timeStartFrom = time.Now().Sub(tu.startTime)
+	SetRedraw()
 }
 
 func (tu *TimerUI) Reset() {
 	tu.ticking = false
 	tu.timeStartFrom = 0
+	tu.popups = tu.popups[:0]
 }
 
 func (tu *TimerUI) CurrentTime() time.Duration {
@@ -748,6 +1495,24 @@ func (tu *TimerUI) CurrentTime() time.Duration {
 	return tu.timeStartFrom + time.Now().Sub(tu.startTime)
 }
 
+// time timer shows, time left if CountDown
+func (tu *TimerUI) ShownTime() time.Duration {
+	if tu.CountDown {
+		return max(tu.TimeLimit-tu.CurrentTime(), 0)
+	}
+	return tu.CurrentTime()
+}
+
+// ShowTimeChange shows bonus (positive change) or penalty
+// falling down from the timer
+func (tu *TimerUI) ShowTimeChange(change time.Duration) {
+	tu.popups = append(tu.popups, timerPopup{
+		Change: change,
+		Timer:  Timer{Duration: time.Millisecond * 900},
+	})
+	SetRedraw()
+}
+
 type MuteButtonUI struct {
 	TopUIElement
 
@@ -774,18 +1539,7 @@ func NewMuteButtonUI() *MuteButtonUI {
 		mu.MuteButton.ImageColorOnDown = ColorTopUIButtonOnDown
 
 		mu.MuteButton.OnPress = func(bool) {
-			mu.IsMute = !mu.IsMute
-			if mu.IsMute {
-				mu.MuteButton.Image = SpriteSubView(UISprite, 5)
-				mu.MuteButton.ImageOnHover = SpriteSubView(UISprite, 5)
-				mu.MuteButton.ImageOnDown = SpriteSubView(UISprite, 5)
-				SetGlobalVolume(0)
-			} else {
-				mu.MuteButton.Image = SpriteSubView(UISprite, 6)
-				mu.MuteButton.ImageOnHover = SpriteSubView(UISprite, 6)
-				mu.MuteButton.ImageOnDown = SpriteSubView(UISprite, 6)
-				SetGlobalVolume(1)
-			}
+			mu.SetMute(!mu.IsMute)
 		}
 	}
 
@@ -815,3 +1569,19 @@ func NewMuteButtonUI() *MuteButtonUI {
 
 	return mu
 }
+
+func (mu *MuteButtonUI) SetMute(mute bool) {
+	mu.IsMute = mute
+	if mu.IsMute {
+		mu.MuteButton.Image = SpriteSubView(UISprite, 5)
+		mu.MuteButton.ImageOnHover = SpriteSubView(UISprite, 5)
+		mu.MuteButton.ImageOnDown = SpriteSubView(UISprite, 5)
+		SetGlobalVolume(0)
+	} else {
+		mu.MuteButton.Image = SpriteSubView(UISprite, 6)
+		mu.MuteButton.ImageOnHover = SpriteSubView(UISprite, 6)
+		mu.MuteButton.ImageOnDown = SpriteSubView(UISprite, 6)
+		SetGlobalVolume(1)
+	}
+	SetRedraw()
+}
@@ -3,6 +3,7 @@ module minesweeper
 go 1.23.0
 
 require (
+	github.com/coder/websocket v1.8.15
 	github.com/hajimehoshi/ebiten/v2 v2.8.5
 	github.com/mazznoer/csscolorparser v0.1.5
 	github.com/silbinarywolf/preferdiscretegpu v1.0.0
@@ -21,4 +21,10 @@ const (
 	ResetToSameBoardKey eb.Key = eb.KeyT
 
 	ScreenshotKey eb.Key = eb.KeyP
+
+	GameCodeKey eb.Key = eb.KeyG
+
+	LessonKey   eb.Key = eb.KeyL
+	PuzzleKey   eb.Key = eb.KeyU
+	SurvivalKey eb.Key = eb.KeyV
 )
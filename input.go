@@ -258,6 +258,19 @@ func IsKeyJustPressed(key eb.Key) bool {
 	return ebi.IsKeyJustPressed(key)
 }
 
+// true while user is typing in to a text box (see SetTypingText)
+var typingText bool
+
+func SetTypingText(typing bool) {
+	typingText = typing
+}
+
+// IsHotkeyJustPressed is IsKeyJustPressed for hotkeys,
+// which are ignored while user is typing in to a text box
+func IsHotkeyJustPressed(key eb.Key) bool {
+	return !typingText && IsKeyJustPressed(key)
+}
+
 var keyRepeatMap = make(map[eb.Key]time.Duration)
 
 func HandleKeyRepeat(
@@ -0,0 +1,481 @@
+package minesweeper
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"minesweeper/engine"
+	"minesweeper/lesson"
+	"minesweeper/puzzle"
+	"minesweeper/spectate"
+)
+
+// options for starting the game already configured
+//
+// they are set from command line flags
+// or from url query string on web (?difficulty=hard&mute)
+type LaunchOptions struct {
+	Difficulty string
+
+	// if any of these are set, board becomes a custom board
+	Width  int
+	Height int
+	Mines  int
+
+	Seed string
+	Code string
+
+	// see engine.FirstClickPolicyStrs
+	FirstClick string
+
+	// start a two player flags match
+	Flags bool
+
+	// forgive stepping on a mine when there was nothing but guesses left
+	Forgiving bool
+	// make every guess a mine
+	Evil bool
+
+	// mines player can step on and keep playing
+	Lives int
+
+	// clock counts down and openings add time
+	TimeAttack bool
+
+	// 3BV or rating range of boards, see engine.ParseBoardTarget
+	Target string
+
+	// how mines are placed, see engine.PlacerKind
+	Placer string
+	// file with mines drawn in it, see engine.ParseLayout
+	Layout string
+
+	// name of a builtin lesson or a lesson json file
+	Lesson string
+
+	// start puzzles, from PuzzleFile if it's set
+	Puzzle     bool
+	PuzzleFile string
+
+	// start a survival run, from Code or board options if they are set
+	Survival bool
+
+	Fullscreen bool
+	Mute       bool
+	WindowSize string
+
+	ReplayDir string
+
+	// command line of a bot that plays instead of user
+	Bot      string
+	BotDelay time.Duration
+
+	// websocket url of a co-op room
+	Coop string
+
+	// websocket url of a race room and name shown to other players
+	Race     string
+	RaceName string
+
+	// address to stream the game to spectators at
+	Spectate string
+}
+
+var TheLaunchOptions LaunchOptions
+
+func init() {
+	lo := &TheLaunchOptions
+
+	flag.StringVar(&lo.Difficulty, "difficulty", "", "starting difficulty (easy, medium, hard)")
+
+	flag.IntVar(&lo.Width, "width", 0, "board width, starts a custom board")
+	flag.IntVar(&lo.Height, "height", 0, "board height, starts a custom board")
+	flag.IntVar(&lo.Mines, "mines", 0, "mine count, starts a custom board")
+
+	flag.StringVar(&lo.Seed, "seed", "", "seed of the first board (64 hex digits)")
+	flag.StringVar(&lo.Code, "code", "", "game code of the first board")
+	flag.StringVar(&lo.FirstClick, "first-click", "", "what first click keeps free of mines (safe-area, safe-tile), defaults to safe-area")
+
+	flag.BoolVar(&lo.Flags, "flags", false, "two players take turns claiming mines, first to claim more than half wins")
+	flag.BoolVar(&lo.Forgiving, "forgiving", false, "stepping on a mine is forgiven when there was nothing but guesses left")
+	flag.BoolVar(&lo.Evil, "evil", false, "every guess is a mine, only moves that can be proven safe are safe")
+	flag.IntVar(&lo.Lives, "lives", 0, "practice with lives, stepping on a mine costs a life instead of the game")
+	flag.BoolVar(&lo.TimeAttack, "time-attack", false, "timer counts down, openings add time and game is lost when it runs out")
+	flag.StringVar(&lo.Target, "target", "", "generate boards in a 3BV or rating range (for example 3bv:120..160, guesses:0, rule:single..pair)")
+	flag.StringVar(&lo.Placer, "placer", "", "how mines are placed (uniform, clustered, gradient, pattern, no-guess)")
+	flag.StringVar(&lo.Layout, "layout", "", "play a board drawn in a text file, '*' or 'x' is a mine, desktop only")
+	flag.StringVar(&lo.Lesson, "lesson", "", "start a lesson (1-1, 1-2, 1-2-1, 1-2-2-1, mine-count) or a lesson json file, desktop only for files")
+	flag.BoolVar(&lo.Puzzle, "puzzle", false, "start \"find the safe tile\" puzzles from the first unsolved one")
+	flag.StringVar(&lo.PuzzleFile, "puzzle-file", "", "play puzzles from a json file instead of builtin ones, desktop only")
+	flag.BoolVar(&lo.Survival, "survival", false, "clear boards one after another, each bigger or denser, until the first mine")
+
+	flag.BoolVar(&lo.Fullscreen, "fullscreen", false, "start in fullscreen")
+	flag.BoolVar(&lo.Mute, "mute", false, "start muted")
+	flag.StringVar(&lo.WindowSize, "window-size", "", "window size in WIDTHxHEIGHT (for example 580x620)")
+
+	flag.StringVar(&lo.ReplayDir, "record", "", "save replays of finished games in this directory")
+
+	flag.StringVar(&lo.Bot, "bot", "", "let bot executable play (for example \"python3 my_bot.py\")")
+	flag.DurationVar(&lo.BotDelay, "bot-delay", time.Millisecond*150, "minimum time between bot's moves")
+
+	flag.StringVar(&lo.Coop, "coop", "", "play co-op in a room (for example ws://localhost:6970/coop?room=lunch)")
+
+	flag.StringVar(&lo.Race, "race", "", "race in a room (for example ws://localhost:6970/race?room=lunch)")
+	flag.StringVar(&lo.RaceName, "race-name", "", "name shown to other players in a race")
+
+	flag.StringVar(&lo.Spectate, "spectate", "", "stream the game to spectators at this address (for example localhost:6971), desktop only")
+}
+
+// SetFlagsFromQuery sets registered flags from url query string.
+//
+// Query parameters that are not flags are ignored,
+// since page can be embedded with parameters we don't know about.
+func SetFlagsFromQuery(query string) error {
+	values, err := url.ParseQuery(strings.TrimPrefix(query, "?"))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for name, vs := range values {
+		f := flag.Lookup(name)
+		if f == nil {
+			continue
+		}
+
+		value := ""
+		if len(vs) > 0 {
+			value = vs[len(vs)-1]
+		}
+
+		// treat "?mute" as "?mute=true"
+		if value == "" {
+			if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && boolFlag.IsBoolFlag() {
+				value = "true"
+			}
+		}
+
+		if err := f.Value.Set(value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q for %s: %w", value, name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// parses string like "580x620"
+func ParseWindowSize(str string) (int, int, error) {
+	wStr, hStr, ok := strings.Cut(strings.ToLower(str), "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("window size %q is not in WIDTHxHEIGHT format", str)
+	}
+
+	w, errW := strconv.Atoi(strings.TrimSpace(wStr))
+	h, errH := strconv.Atoi(strings.TrimSpace(hStr))
+
+	if errW != nil || errH != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("window size %q is invalid", str)
+	}
+
+	return w, h, nil
+}
+
+func (lo LaunchOptions) IsCustomBoard() bool {
+	return lo.Width != 0 || lo.Height != 0 || lo.Mines != 0
+}
+
+// ApplyLaunchOptions resets the board according to board related options,
+// starts a lesson, puzzles or a survival run, sets mute, starts the bot, joins the co-op or race room and starts streaming to spectators.
+//
+// Options are checked before anything is applied,
+// so GameUI is unchanged when it returns an error.
+func (gu *GameUI) ApplyLaunchOptions(lo LaunchOptions) error {
+	// ==========================
+	// check options
+	// ==========================
+	difficulty := gu.Difficulty
+	if lo.Difficulty != "" {
+		var err error
+		if difficulty, err = engine.ParseDifficulty(lo.Difficulty); err != nil {
+			return err
+		}
+	}
+
+	if lo.Code != "" {
+		if lo.Seed != "" || lo.IsCustomBoard() || lo.FirstClick != "" || lo.Flags || lo.Forgiving || lo.Evil ||
+			lo.Lives != 0 || lo.TimeAttack || lo.Target != "" || lo.Placer != "" || lo.Layout != "" {
+			return errors.New(
+				"-code can't be used with -seed, -width, -height, -mines, -first-click, -flags, -forgiving, -evil, -lives, " +
+					"-time-attack, -target, -placer or -layout",
+			)
+		}
+	}
+	if lo.Layout != "" && (lo.IsCustomBoard() || lo.Placer != "" || lo.Target != "") {
+		return errors.New("-layout can't be used with -width, -height, -mines, -placer or -target, layout decides the board")
+	}
+	if lo.Placer == engine.PlacerLayout.String() {
+		return errors.New("-placer layout needs a board, use -layout instead")
+	}
+
+	// board of -code, -layout or the other board options,
+	// it's a board of current difficulty when none of them are set
+	opts := engine.BoardOptions{Code: lo.Code}
+	if lo.Code == "" {
+		opts = engine.BoardOptions{
+			Width:      lo.Width,
+			Height:     lo.Height,
+			Mines:      lo.Mines,
+			Seed:       lo.Seed,
+			FirstClick: lo.FirstClick,
+			Flags:      lo.Flags,
+			Forgiving:  lo.Forgiving,
+			Evil:       lo.Evil,
+			TimeAttack: lo.TimeAttack,
+			Lives:      lo.Lives,
+			Target:     lo.Target,
+			Placer:     lo.Placer,
+		}
+
+		if lo.Layout != "" {
+			text, err := os.ReadFile(lo.Layout)
+			if err != nil {
+				return err
+			}
+			opts.Layout = string(text)
+		} else if !lo.Flags {
+			// engine only knows desktop sizes
+			if opts.Width == 0 {
+				opts.Width = gu.BoardTileCount(difficulty).X
+			}
+			if opts.Height == 0 {
+				opts.Height = gu.BoardTileCount(difficulty).Y
+			}
+			if opts.Mines == 0 {
+				opts.Mines = gu.MineCounts[difficulty]
+			}
+		}
+	}
+
+	code, err := opts.GameCode()
+	if err != nil {
+		if lo.Layout != "" {
+			return fmt.Errorf("%s: %w", lo.Layout, err)
+		}
+		return err
+	}
+
+	useCode := lo.Code != "" || lo.Layout != "" || lo.IsCustomBoard() || lo.Flags
+
+	if lo.Coop != "" && (lo.Code != "" || lo.Seed != "") {
+		return errors.New("-coop can't be used with -code or -seed, room decides the board")
+	}
+	if lo.Coop != "" && lo.Race != "" {
+		return errors.New("-coop can't be used with -race")
+	}
+	if lo.Flags && (lo.Coop != "" || lo.Race != "") {
+		return errors.New("-flags is played on one device, it can't be used with -coop or -race")
+	}
+	if (lo.Target != "" || lo.Placer != "" || lo.Layout != "" || lo.Lives != 0) && lo.Coop != "" {
+		return errors.New("-target, -placer, -layout and -lives can't be used with -coop, room decides the board")
+	}
+	// clock is kept by each client, a room can't agree on it
+	if lo.TimeAttack && lo.Coop != "" {
+		return errors.New("-time-attack can't be used with -coop")
+	}
+
+	var lessonUI *LessonUI
+
+	if lo.Lesson != "" {
+		if lo.Code != "" || lo.Layout != "" || lo.IsCustomBoard() || lo.Flags || lo.Coop != "" || lo.Race != "" {
+			return errors.New("-lesson can't be used with -code, -layout, -width, -height, -mines, -flags, -coop or -race")
+		}
+
+		l, ok := lesson.Find(gu.Lessons, lo.Lesson)
+		if !ok {
+			data, err := os.ReadFile(lo.Lesson)
+			if err != nil {
+				return fmt.Errorf("%q is not a builtin lesson or a lesson file: %w", lo.Lesson, err)
+			}
+			name := strings.TrimSuffix(filepath.Base(lo.Lesson), filepath.Ext(lo.Lesson))
+			if l, err = lesson.Parse(name, data); err != nil {
+				return fmt.Errorf("%s: %w", lo.Lesson, err)
+			}
+		}
+
+		var err error
+		if lessonUI, err = NewLessonUI(l); err != nil {
+			return err
+		}
+	}
+
+	var puzzles []puzzle.Puzzle
+
+	if lo.Puzzle || lo.PuzzleFile != "" {
+		if lo.Code != "" || lo.Layout != "" || lo.IsCustomBoard() || lo.Flags || lo.Coop != "" || lo.Race != "" || lo.Lesson != "" {
+			return errors.New("-puzzle can't be used with -code, -layout, -width, -height, -mines, -flags, -coop, -race or -lesson")
+		}
+
+		puzzles = gu.Puzzles
+		if lo.PuzzleFile != "" {
+			data, err := os.ReadFile(lo.PuzzleFile)
+			if err != nil {
+				return err
+			}
+			if puzzles, err = puzzle.Parse(data); err != nil {
+				return fmt.Errorf("%s: %w", lo.PuzzleFile, err)
+			}
+		}
+		if len(puzzles) <= 0 {
+			return errors.New("there are no puzzles to play")
+		}
+	}
+
+	var survivalStart engine.GameCode
+
+	if lo.Survival {
+		if lo.Flags || lo.Lives != 0 || lo.TimeAttack || lo.Layout != "" || lo.Coop != "" || lo.Race != "" ||
+			lo.Lesson != "" || lo.Puzzle || lo.PuzzleFile != "" {
+			return errors.New(
+				"-survival can't be used with -flags, -lives, -time-attack, -layout, -coop, -race, -lesson or -puzzle",
+			)
+		}
+
+		survivalStart = code
+
+		if err := engine.ValidateSurvivalStart(survivalStart); err != nil {
+			return fmt.Errorf("-survival: %w", err)
+		}
+	}
+
+	// ==========================
+	// start bot, race and spectators
+	// ==========================
+	// only after every option is checked,
+	// anything started before a failure is closed again
+	var botSource *BotInputSource
+
+	if lo.Bot != "" {
+		args := strings.Fields(lo.Bot)
+		if len(args) <= 0 {
+			return errors.New("-bot is empty")
+		}
+
+		if botSource, err = NewBotInputSource(args, lo.BotDelay); err != nil {
+			return err
+		}
+	}
+
+	var raceClient *RaceClient
+
+	if lo.Race != "" {
+		if raceClient, err = NewRaceClient(lo.Race, lo.RaceName); err != nil {
+			if botSource != nil {
+				botSource.Close()
+			}
+			return err
+		}
+	}
+
+	// listen last, since listener can't be closed again
+	var spectators *spectate.Server
+
+	if lo.Spectate != "" {
+		if spectators, err = ListenSpectators(lo.Spectate); err != nil {
+			if botSource != nil {
+				botSource.Close()
+			}
+			if raceClient != nil {
+				raceClient.Close()
+			}
+			return err
+		}
+	}
+
+	// ==========================
+	// apply options
+	// ==========================
+	if botSource != nil {
+		gu.Game.InputSource = botSource
+	}
+
+	gu.Difficulty = difficulty
+	gu.TopUI.DifficultySelectUI.Difficulty = difficulty
+
+	// keeps being forgiving, evil or time attack after difficulty is changed
+	if lo.Forgiving {
+		gu.Variants |= engine.GameVariantForgiving
+	}
+	if lo.Evil {
+		gu.Variants |= engine.GameVariantEvil
+	}
+	if lo.TimeAttack {
+		gu.Variants |= engine.GameVariantTimeAttack
+	}
+	// -code and -layout only decide the first board
+	var preset engine.GameCode
+	if lo.Code == "" && lo.Layout == "" {
+		preset = code
+	}
+	gu.FirstClick = preset.FirstClick
+	gu.Target = preset.Target
+	gu.Placer = preset.Placer
+	gu.Lives = lo.Lives
+
+	if lo.Survival {
+		// start was checked above, this doesn't fail
+		if err := gu.StartSurvival(survivalStart); err != nil {
+			return err
+		}
+	} else if useCode {
+		gu.StartGameCode(code)
+	} else if lo.Difficulty != "" || lo.Seed != "" || lo.FirstClick != "" || lo.Forgiving || lo.Evil || lo.Lives != 0 ||
+		lo.TimeAttack || lo.Target != "" || lo.Placer != "" {
+		gu.SetGameResetParameter()
+		if lo.Seed != "" {
+			gu.Game.Seed = code.Seed
+			gu.Game.ResetBoardEx(false)
+		} else {
+			gu.Game.ResetBoard()
+		}
+	}
+
+	if lessonUI != nil {
+		gu.StartLesson(lessonUI)
+	}
+
+	if puzzles != nil {
+		gu.Puzzles = puzzles
+		gu.StartNextPuzzle()
+	}
+
+	if lo.Mute {
+		gu.TopUI.MuteButtonUI.SetMute(true)
+	}
+
+	gu.ReplayDir = lo.ReplayDir
+
+	// set after the board is reset so that
+	// resets above don't reach the room
+	if lo.Coop != "" {
+		gu.Game.Remote = NewCoopClient(lo.Coop)
+	}
+
+	if raceClient != nil {
+		gu.JoinRace(raceClient)
+	}
+
+	if spectators != nil {
+		gu.Spectators = spectators
+		spectators.Reset(gu.Game.GameCode())
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !js
+
+package minesweeper
+
+// on desktop, launch options only come from command line flags
+func ParsePlatformLaunchOptions() error {
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build js
+
+package minesweeper
+
+import (
+	"syscall/js"
+)
+
+// on web, launch options come from url query string
+func ParsePlatformLaunchOptions() error {
+	search := js.Global().Get("location").Get("search")
+	if search.Type() != js.TypeString {
+		return nil
+	}
+	return SetFlagsFromQuery(search.String())
+}
@@ -0,0 +1,312 @@
+// Package lesson has scripted lessons that teach standard patterns
+// on hand made boards, one step at a time.
+//
+// A lesson is a json file with a board drawn like engine.ParseBoard takes
+// and a list of steps. Each step has a prompt, tiles to highlight
+// and tiles player has to reveal or flag before going on to the next step.
+// Builtin lessons are in the lessons directory, played in file name order.
+// File names start with a number for the order (1_1-1.json),
+// which is not part of the lesson name.
+package lesson
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"minesweeper/engine"
+)
+
+type Lesson struct {
+	// file name without extension and order prefix, set when it's loaded
+	Name string `json:"-"`
+
+	Title string `json:"title"`
+
+	// rows of the starting board, see engine.ParseBoard
+	Board []string `json:"board"`
+
+	Steps []Step `json:"steps"`
+
+	// shown after the last step
+	Outro string `json:"outro,omitempty"`
+}
+
+// positions are [x, y]
+type Step struct {
+	Prompt string `json:"prompt"`
+
+	// shown instead of a generic message when player does something else
+	Hint string `json:"hint,omitempty"`
+
+	// tiles to draw attention to, usually numbers the deduction uses
+	Highlight [][2]int `json:"highlight,omitempty"`
+
+	// step is done once all of these are revealed and flagged
+	Safe  [][2]int `json:"safe,omitempty"`
+	Mines [][2]int `json:"mines,omitempty"`
+}
+
+var (
+	// move steps on a mine or chords around a wrong flag
+	ErrMine = errors.New("that's a mine")
+
+	// move reveals or flags a tile current step doesn't ask for
+	ErrWrongMove = errors.New("that's not the move this step is about")
+)
+
+// Position returns the starting board
+func (l Lesson) Position() (engine.Board, error) {
+	return engine.ParseBoard(l.Board)
+}
+
+// Validate checks that board is valid, tiles of steps are where they say
+// and that playing steps in order works without clearing the board too early.
+func (l Lesson) Validate() error {
+	if l.Title == "" {
+		return errors.New("lesson has no title")
+	}
+
+	board, err := l.Position()
+	if err != nil {
+		return err
+	}
+
+	if len(l.Steps) <= 0 {
+		return errors.New("lesson has no steps")
+	}
+
+	progress := NewProgress(l)
+	state := engine.GameStatePlaying
+
+	for i, step := range l.Steps {
+		if step.Prompt == "" {
+			return fmt.Errorf("step %d has no prompt", i+1)
+		}
+		if len(step.Safe)+len(step.Mines) <= 0 {
+			return fmt.Errorf("step %d has nothing to reveal or flag", i+1)
+		}
+
+		for _, p := range step.Highlight {
+			if !board.IsPosInBoard(p[0], p[1]) {
+				return fmt.Errorf("step %d highlights %d, %d outside of board", i+1, p[0], p[1])
+			}
+		}
+		for _, p := range step.Safe {
+			if !board.IsPosInBoard(p[0], p[1]) || board.Mines.Get(p[0], p[1]) {
+				return fmt.Errorf("step %d says %d, %d is safe but it's not", i+1, p[0], p[1])
+			}
+		}
+		for _, p := range step.Mines {
+			if !board.IsPosInBoard(p[0], p[1]) || !board.Mines.Get(p[0], p[1]) {
+				return fmt.Errorf("step %d says %d, %d is a mine but it's not", i+1, p[0], p[1])
+			}
+		}
+
+		if state != engine.GameStatePlaying {
+			return fmt.Errorf("board is cleared before step %d", i+1)
+		}
+
+		// play the step like player would, flags first
+		play := func(interaction engine.BoardInteractionType, x, y int) error {
+			if err := progress.Check(board, interaction, x, y); err != nil {
+				return fmt.Errorf("step %d: %v at %d, %d: %w", i+1, interaction, x, y, err)
+			}
+			state = board.InteractAt(x, y, interaction, state, 0, engine.FirstClickSafeArea, [32]byte{})
+			return nil
+		}
+
+		for _, p := range step.Mines {
+			if !board.Flags.Get(p[0], p[1]) {
+				if err := play(engine.InteractionTypeFlag, p[0], p[1]); err != nil {
+					return err
+				}
+			}
+		}
+		for _, p := range step.Safe {
+			if !board.Revealed.Get(p[0], p[1]) {
+				if err := play(engine.InteractionTypeStep, p[0], p[1]); err != nil {
+					return err
+				}
+			}
+		}
+
+		progress.Advance(board)
+
+		if progress.StepIndex() != i+1 {
+			return fmt.Errorf("step %d is done before it starts", i+2)
+		}
+	}
+
+	return nil
+}
+
+// Progress keeps track of which step of a lesson player is on
+type Progress struct {
+	Lesson Lesson
+
+	step int
+}
+
+func NewProgress(l Lesson) *Progress {
+	return &Progress{Lesson: l}
+}
+
+// Step returns current step, false if lesson is done
+func (p *Progress) Step() (Step, bool) {
+	if p.Done() {
+		return Step{}, false
+	}
+	return p.Lesson.Steps[p.step], true
+}
+
+// StepIndex returns index of current step, len(Lesson.Steps) if lesson is done
+func (p *Progress) StepIndex() int {
+	return p.step
+}
+
+func (p *Progress) Done() bool {
+	return p.step >= len(p.Lesson.Steps)
+}
+
+// Check tells if interaction at x, y on board is what current step asks for.
+//
+// Moves that don't change anything are always fine,
+// and so is everything once lesson is done.
+// board is not modified.
+func (p *Progress) Check(board engine.Board, interaction engine.BoardInteractionType, x, y int) error {
+	step, ok := p.Step()
+	if !ok {
+		return nil
+	}
+
+	after := board.Copy()
+	state := after.InteractAt(x, y, interaction, engine.GameStatePlaying, 0, engine.FirstClickSafeArea, [32]byte{})
+
+	if state == engine.GameStateLost {
+		return ErrMine
+	}
+
+	for i := range after.Mines.Data {
+		pos := [2]int{i % board.Width, i / board.Width}
+
+		if after.Flags.Data[i] != board.Flags.Data[i] {
+			if !after.Flags.Data[i] || !slices.Contains(step.Mines, pos) {
+				return ErrWrongMove
+			}
+		}
+		if after.Revealed.Data[i] && !board.Revealed.Data[i] && !slices.Contains(step.Safe, pos) {
+			return ErrWrongMove
+		}
+	}
+
+	return nil
+}
+
+// Advance moves on to the next step if board has everything current step asks for,
+// returns true if step has changed.
+func (p *Progress) Advance(board engine.Board) bool {
+	advanced := false
+
+	for !p.Done() {
+		step := p.Lesson.Steps[p.step]
+
+		for _, pos := range step.Safe {
+			if !board.Revealed.Get(pos[0], pos[1]) {
+				return advanced
+			}
+		}
+		for _, pos := range step.Mines {
+			if !board.Flags.Get(pos[0], pos[1]) {
+				return advanced
+			}
+		}
+
+		p.step++
+		advanced = true
+	}
+
+	return advanced
+}
+
+// ==========================
+// loading
+// ==========================
+
+//go:embed lessons/*.json
+var builtin embed.FS
+
+// Builtin returns lessons that come with the game
+func Builtin() ([]Lesson, error) {
+	return LoadDir(builtin, "lessons")
+}
+
+// LoadDir loads every json file in dir, sorted by file name
+func LoadDir(fsys fs.FS, dir string) ([]Lesson, error) {
+	paths, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+
+	lessons := make([]Lesson, 0, len(paths))
+	for _, p := range paths {
+		l, err := Load(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		lessons = append(lessons, l)
+	}
+
+	return lessons, nil
+}
+
+// Load loads and validates a single lesson
+func Load(fsys fs.FS, p string) (Lesson, error) {
+	data, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return Lesson{}, err
+	}
+
+	name := strings.TrimSuffix(path.Base(p), path.Ext(p))
+	if prefix, rest, ok := strings.Cut(name, "_"); ok && strings.Trim(prefix, "0123456789") == "" {
+		name = rest
+	}
+
+	l, err := Parse(name, data)
+	if err != nil {
+		return l, fmt.Errorf("%s: %w", p, err)
+	}
+	return l, nil
+}
+
+// Parse parses and validates a lesson, name is usually the file name
+func Parse(name string, data []byte) (Lesson, error) {
+	var l Lesson
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&l); err != nil {
+		return l, err
+	}
+	l.Name = name
+
+	return l, l.Validate()
+}
+
+// Find returns lesson with name, false if there isn't one
+func Find(lessons []Lesson, name string) (Lesson, bool) {
+	for _, l := range lessons {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return Lesson{}, false
+}
@@ -0,0 +1,227 @@
+package minesweeper
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"minesweeper/engine"
+	"minesweeper/lesson"
+
+	eb "github.com/hajimehoshi/ebiten/v2"
+)
+
+// LessonUI shows prompts of a lesson under the board
+// and only lets player make the moves current step asks for
+type LessonUI struct {
+	Rect FRectangle
+
+	Lesson   lesson.Lesson
+	Progress *lesson.Progress
+
+	// board lesson starts from
+	Position engine.Board
+
+	// shown instead of prompt after a wrong move, until the next right one
+	message string
+
+	highlightTimer Timer
+}
+
+func NewLessonUI(l lesson.Lesson) (*LessonUI, error) {
+	lu := new(LessonUI)
+
+	lu.Lesson = l
+
+	var err error
+	if lu.Position, err = l.Position(); err != nil {
+		return nil, fmt.Errorf("lesson %s: %w", l.Name, err)
+	}
+
+	lu.Restart()
+
+	return lu, nil
+}
+
+// starts lesson over from the first step
+func (lu *LessonUI) Restart() {
+	lu.Progress = lesson.NewProgress(lu.Lesson)
+	lu.message = ""
+	lu.highlightTimer = Timer{Duration: time.Millisecond * 300}
+}
+
+// Allow tells if interaction is what current step asks for,
+// and remembers what was wrong if it isn't
+func (lu *LessonUI) Allow(board engine.Board, interaction engine.BoardInteractionType, x, y int) bool {
+	err := lu.Progress.Check(board, interaction, x, y)
+	if err == nil {
+		lu.message = ""
+		return true
+	}
+
+	step, _ := lu.Progress.Step()
+
+	switch {
+	case step.Hint != "":
+		lu.message = step.Hint
+	case errors.Is(err, lesson.ErrMine):
+		lu.message = "Careful, that's a mine! Look at the highlighted numbers again."
+	default:
+		lu.message = "Not that one, look at the highlighted numbers again."
+	}
+
+	PlaySoundBytes(SeButtonClick, 0.5)
+	SetRedraw()
+
+	return false
+}
+
+// moves on to the next step if board has what current step asks for
+func (lu *LessonUI) OnBoardChanged(board engine.Board) {
+	if lu.Progress.Advance(board) {
+		lu.message = ""
+		lu.highlightTimer.Current = 0
+		SetRedraw()
+	}
+}
+
+// ShowsHint tells if step's hint is shown after a wrong move
+func (lu *LessonUI) ShowsHint() bool {
+	step, ok := lu.Progress.Step()
+	return ok && step.Hint != "" && lu.message == step.Hint
+}
+
+func (lu *LessonUI) Text() string {
+	if lu.message != "" {
+		return lu.message
+	}
+	if step, ok := lu.Progress.Step(); ok {
+		return step.Prompt
+	}
+
+	text := lu.Lesson.Outro
+	if text != "" {
+		text += " "
+	}
+	return text + "Press retry or L for the next lesson."
+}
+
+func (lu *LessonUI) Title() string {
+	if lu.Progress.Done() {
+		return fmt.Sprintf("%s : done!", lu.Lesson.Title)
+	}
+	return fmt.Sprintf("%s : step %d of %d", lu.Lesson.Title, lu.Progress.StepIndex()+1, len(lu.Lesson.Steps))
+}
+
+func (lu *LessonUI) Height() float64 {
+	return max(ScreenHeight*0.16, 90)
+}
+
+func (lu *LessonUI) Draw(dst *eb.Image) {
+	DrawTextPanel(dst, lu.Rect, lu.Title(), lu.Text())
+}
+
+// ==========================
+// GameUI
+// ==========================
+
+// StartLesson starts lu on a new board,
+// until difficulty is changed or other board is started
+func (gu *GameUI) StartLesson(lu *LessonUI) {
+	gu.StopPuzzle()
+	gu.StopSurvival()
+	gu.setLesson(lu)
+
+	gu.SetGameResetParameter()
+	gu.Game.ResetBoard()
+	SetRedraw()
+}
+
+// starts next lesson, or goes back to normal boards after the last one
+func (gu *GameUI) StartNextLesson() {
+	gu.advanceLesson()
+
+	gu.SetGameResetParameter()
+	gu.Game.ResetBoard()
+	SetRedraw()
+}
+
+// goes back to boards from Difficulty or CustomBoard,
+// board is not reset
+func (gu *GameUI) StopLesson() {
+	gu.LessonUI = nil
+
+	gu.TopUI.DifficultySelectUI.IsCustom = gu.UseCustomBoard
+	gu.TopUI.DifficultySelectUI.CustomLabel = ""
+}
+
+func (gu *GameUI) setLesson(lu *LessonUI) {
+	gu.LessonUI = lu
+
+	gu.TopUI.DifficultySelectUI.IsCustom = true
+	gu.TopUI.DifficultySelectUI.CustomLabel = "Lesson"
+}
+
+// moves on to lesson after the current one in Lessons (first one if not in a lesson),
+// or stops after the last one. board is not reset
+func (gu *GameUI) advanceLesson() {
+	next := 0
+	if gu.LessonUI != nil {
+		next = len(gu.Lessons)
+		for i, l := range gu.Lessons {
+			if l.Name == gu.LessonUI.Lesson.Name {
+				next = i + 1
+				break
+			}
+		}
+	}
+
+	if next < len(gu.Lessons) {
+		lu, err := NewLessonUI(gu.Lessons[next])
+		if err == nil {
+			gu.setLesson(lu)
+			return
+		}
+		ErrLogger.Printf("failed to start lesson: %v", err)
+	}
+
+	gu.StopLesson()
+}
+
+func (gu *GameUI) LessonUIRect() FRectangle {
+	h := gu.LessonUI.Height()
+	return FRectXYWH(0, ScreenHeight-h, ScreenWidth, h)
+}
+
+// highlights tiles of current step
+func (gu *GameUI) lessonStyleModifier() StyleModifier {
+	return func(
+		prevBoard, board engine.Board,
+		boardRect FRectangle,
+		interaction engine.BoardInteractionType,
+		stateChanged bool,
+		prevGameState, gameState engine.GameState,
+		tileStyles engine.Array2D[TileStyle],
+		gi GameInput,
+	) bool {
+		lu := gu.LessonUI
+		if lu == nil {
+			return false
+		}
+		step, ok := lu.Progress.Step()
+		if !ok {
+			return false
+		}
+
+		lu.highlightTimer.TickUp()
+		t := lu.highlightTimer.Normalize()
+
+		for _, p := range step.Highlight {
+			if board.IsPosInBoard(p[0], p[1]) {
+				tileStyles.Data[p[0]+tileStyles.Width*p[1]].Highlight = t
+			}
+		}
+
+		return lu.highlightTimer.Current < lu.highlightTimer.Duration+UpdateDelta()
+	}
+}
@@ -1,12 +1,10 @@
 package minesweeper
 
 import (
-	"fmt"
 	"golang.org/x/exp/constraints"
 	"image"
 	"math"
 	"math/rand/v2"
-	"strings"
 	"time"
 
 	eb "github.com/hajimehoshi/ebiten/v2"
@@ -472,16 +470,6 @@ func GetSeed() [32]byte {
 	return seed
 }
 
-func SeedToString(seed [32]byte) string {
-	sb := strings.Builder{}
-
-	for i := range 32 {
-		sb.WriteString(fmt.Sprintf("%x", seed[i]))
-	}
-
-	return sb.String()
-}
-
 // ========================
 // bezier curve
 // ========================
@@ -0,0 +1,262 @@
+// Package puzzle has "find the safe tile" puzzles,
+// partly revealed boards where exactly one tile can be proven safe,
+// or a short chain of them where each reveal proves the next one.
+//
+// Puzzles come from a json file (see puzzles.json) and are checked with the solver when loaded.
+// An attempt is graded on the first reveal that isn't the safe tile,
+// and Record keeps how player did on each puzzle. Saving it is up to the caller.
+package puzzle
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"minesweeper/engine"
+)
+
+type Puzzle struct {
+	// also the key in Record, so it shouldn't change once puzzle is out
+	Name string `json:"name"`
+
+	// rows of the board, see engine.ParseBoard
+	Board []string `json:"board"`
+
+	// tiles to reveal in order as [x, y],
+	// each one is the only tile solver can prove safe at that point
+	Solution [][2]int `json:"solution"`
+}
+
+// longest chain of safe tiles a puzzle can ask for
+const MaxSolutionLength = 5
+
+// Position returns the board puzzle starts from
+func (p Puzzle) Position() (engine.Board, error) {
+	return engine.ParseBoard(p.Board)
+}
+
+// Validate checks that solver can prove each tile of Solution safe
+// and nothing else, and that board isn't cleared before the last one.
+func (p Puzzle) Validate() error {
+	if p.Name == "" {
+		return errors.New("puzzle has no name")
+	}
+
+	board, err := p.Position()
+	if err != nil {
+		return err
+	}
+
+	if len(p.Solution) <= 0 || len(p.Solution) > MaxSolutionLength {
+		return fmt.Errorf("solution has %d tiles, it should have 1 to %d", len(p.Solution), MaxSolutionLength)
+	}
+
+	state := engine.GameStatePlaying
+
+	for i, t := range p.Solution {
+		if state != engine.GameStatePlaying {
+			return fmt.Errorf("board is cleared before move %d", i+1)
+		}
+		if !board.IsPosInBoard(t[0], t[1]) || board.Revealed.Get(t[0], t[1]) || board.Mines.Get(t[0], t[1]) {
+			return fmt.Errorf("move %d at %d, %d is not a hidden safe tile", i+1, t[0], t[1])
+		}
+
+		safe := board.SolverSafeTiles()
+		if len(safe) != 1 || safe[0] != t {
+			return fmt.Errorf("move %d: solver proves %v safe, not just %v", i+1, safe, t)
+		}
+
+		state = board.InteractAt(t[0], t[1], engine.InteractionTypeStep, state, 0, engine.FirstClickSafeArea, [32]byte{})
+	}
+
+	return nil
+}
+
+// Parse parses and validates puzzles in format of puzzles.json
+func Parse(data []byte) ([]Puzzle, error) {
+	var file struct {
+		Puzzles []Puzzle `json:"puzzles"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	if len(file.Puzzles) <= 0 {
+		return nil, errors.New("there are no puzzles")
+	}
+
+	names := make(map[string]bool)
+	for _, p := range file.Puzzles {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("puzzle %q: %w", p.Name, err)
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("there are two puzzles named %q", p.Name)
+		}
+		names[p.Name] = true
+	}
+
+	return file.Puzzles, nil
+}
+
+//go:embed puzzles.json
+var builtin []byte
+
+// Builtin returns puzzles that come with the game
+func Builtin() ([]Puzzle, error) {
+	return Parse(builtin)
+}
+
+// ==========================
+// attempt
+// ==========================
+
+// Attempt grades moves of one try at a puzzle
+type Attempt struct {
+	Puzzle Puzzle
+
+	move   int
+	missed bool
+}
+
+func NewAttempt(p Puzzle) *Attempt {
+	return &Attempt{Puzzle: p}
+}
+
+// Check grades interaction at x, y on board and tells if it should be made.
+//
+// Flags and moves that don't reveal anything are not graded and always allowed.
+// Reveal is right only if it reveals the next tile of solution (and what spreads from it),
+// anything else misses the puzzle. Nothing is allowed once attempt is over.
+// board is not modified.
+func (a *Attempt) Check(board engine.Board, interaction engine.BoardInteractionType, x, y int) bool {
+	if a.Over() {
+		return false
+	}
+
+	after := board.Copy()
+	state := after.InteractAt(x, y, interaction, engine.GameStatePlaying, 0, engine.FirstClickSafeArea, [32]byte{})
+
+	revealedAny := false
+	for i := range after.Revealed.Data {
+		if after.Revealed.Data[i] && !board.Revealed.Data[i] {
+			revealedAny = true
+			break
+		}
+	}
+
+	if state != engine.GameStateLost && !revealedAny {
+		return true
+	}
+
+	want := a.Puzzle.Solution[a.move]
+
+	expected := board.Copy()
+	expected.SpreadSafeArea(want[0], want[1])
+
+	right := state != engine.GameStateLost && after.Revealed.Get(want[0], want[1])
+	for i := range after.Revealed.Data {
+		if after.Revealed.Data[i] && !expected.Revealed.Data[i] {
+			right = false
+			break
+		}
+	}
+
+	if !right {
+		a.missed = true
+		return false
+	}
+
+	a.move++
+	return true
+}
+
+// Moves returns number of right moves so far
+func (a *Attempt) Moves() int {
+	return a.move
+}
+
+// NextTile returns tile attempt is waiting for (or the one player missed),
+// false if puzzle is solved
+func (a *Attempt) NextTile() ([2]int, bool) {
+	if a.move >= len(a.Puzzle.Solution) {
+		return [2]int{}, false
+	}
+	return a.Puzzle.Solution[a.move], true
+}
+
+func (a *Attempt) Solved() bool {
+	return !a.missed && a.move >= len(a.Puzzle.Solution)
+}
+
+func (a *Attempt) Missed() bool {
+	return a.missed
+}
+
+func (a *Attempt) Over() bool {
+	return a.Solved() || a.Missed()
+}
+
+// ==========================
+// record
+// ==========================
+
+type Result struct {
+	Attempts int  `json:"attempts"`
+	Solved   bool `json:"solved"`
+
+	// solved on the very first attempt
+	FirstTry bool `json:"first_try"`
+}
+
+// Record keeps results of puzzles by name, it's saved as json
+type Record struct {
+	Results map[string]Result `json:"results"`
+}
+
+func ParseRecord(data []byte) (Record, error) {
+	var r Record
+	err := json.Unmarshal(data, &r)
+	return r, err
+}
+
+// Add adds a finished attempt at puzzle named name
+func (r *Record) Add(name string, solved bool) {
+	if r.Results == nil {
+		r.Results = make(map[string]Result)
+	}
+
+	result := r.Results[name]
+	result.Attempts++
+	if solved {
+		result.Solved = true
+		if result.Attempts == 1 {
+			result.FirstTry = true
+		}
+	}
+
+	r.Results[name] = result
+}
+
+// FirstTryCount returns how many of puzzles were solved on the first try
+func (r *Record) FirstTryCount(puzzles []Puzzle) int {
+	count := 0
+	for _, p := range puzzles {
+		if r.Results[p.Name].FirstTry {
+			count++
+		}
+	}
+	return count
+}
+
+// FirstUnsolved returns index of the first puzzle that was never solved,
+// 0 if every puzzle is solved
+func (r *Record) FirstUnsolved(puzzles []Puzzle) int {
+	for i, p := range puzzles {
+		if !r.Results[p.Name].Solved {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,274 @@
+package minesweeper
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"minesweeper/engine"
+	"minesweeper/puzzle"
+
+	eb "github.com/hajimehoshi/ebiten/v2"
+)
+
+// name of the save data puzzle.Record is kept in
+const PuzzleRecordSaveName = "puzzle_record.json"
+
+// PuzzleUI shows current puzzle and how it went under the board,
+// and grades moves player makes
+type PuzzleUI struct {
+	Rect FRectangle
+
+	Puzzles []puzzle.Puzzle
+	Index   int
+
+	Attempt *puzzle.Attempt
+
+	// board puzzle starts from
+	Position engine.Board
+
+	// gets result of every finished attempt, saved right after
+	Record *puzzle.Record
+
+	highlightTimer Timer
+}
+
+func NewPuzzleUI(puzzles []puzzle.Puzzle, index int, record *puzzle.Record) (*PuzzleUI, error) {
+	if index < 0 || index >= len(puzzles) {
+		return nil, fmt.Errorf("there is no puzzle %d", index+1)
+	}
+
+	pu := new(PuzzleUI)
+
+	pu.Puzzles = puzzles
+	pu.Index = index
+	pu.Record = record
+
+	var err error
+	if pu.Position, err = pu.Puzzle().Position(); err != nil {
+		return nil, fmt.Errorf("puzzle %s: %w", pu.Puzzle().Name, err)
+	}
+
+	pu.Restart()
+
+	return pu, nil
+}
+
+func (pu *PuzzleUI) Puzzle() puzzle.Puzzle {
+	return pu.Puzzles[pu.Index]
+}
+
+// starts a new attempt at the same puzzle
+func (pu *PuzzleUI) Restart() {
+	pu.Attempt = puzzle.NewAttempt(pu.Puzzle())
+	pu.highlightTimer = Timer{Duration: time.Millisecond * 300}
+}
+
+// Allow grades interaction and tells if it should be made,
+// wrong moves are never made so that player can see the board they missed
+func (pu *PuzzleUI) Allow(board engine.Board, interaction engine.BoardInteractionType, x, y int) bool {
+	if pu.Attempt.Over() {
+		return false
+	}
+
+	allow := pu.Attempt.Check(board, interaction, x, y)
+
+	if pu.Attempt.Over() {
+		pu.finish()
+	}
+
+	return allow
+}
+
+func (pu *PuzzleUI) finish() {
+	if pu.Attempt.Solved() {
+		PlaySoundBytes(SeVictory, 0.6)
+	} else {
+		PlaySoundBytes(SeButtonClick, 0.5)
+	}
+
+	pu.highlightTimer.Current = 0
+	SetRedraw()
+
+	if pu.Record == nil {
+		return
+	}
+
+	pu.Record.Add(pu.Puzzle().Name, pu.Attempt.Solved())
+
+	if data, err := json.Marshal(pu.Record); err != nil {
+		ErrLogger.Printf("failed to save puzzle record: %v", err)
+	} else if err = SaveData(PuzzleRecordSaveName, data); err != nil {
+		ErrLogger.Printf("failed to save puzzle record: %v", err)
+	}
+}
+
+func (pu *PuzzleUI) Title() string {
+	title := fmt.Sprintf("Puzzle %d of %d", pu.Index+1, len(pu.Puzzles))
+
+	switch {
+	case pu.Attempt.Solved():
+		return title + " : solved!"
+	case pu.Attempt.Missed():
+		return title + " : missed"
+	case len(pu.Puzzle().Solution) > 1:
+		return fmt.Sprintf("%s : find %d safe tiles in a row", title, len(pu.Puzzle().Solution))
+	default:
+		return title + " : find the safe tile"
+	}
+}
+
+func (pu *PuzzleUI) Text() string {
+	const next = "Tap here or press U for the next puzzle."
+
+	var text string
+
+	switch {
+	case pu.Attempt.Solved():
+		if pu.Record != nil && pu.Record.Results[pu.Puzzle().Name].Attempts == 1 {
+			text = "Solved on the first try! "
+		} else {
+			text = "Solved. "
+		}
+		text += next
+	case pu.Attempt.Missed():
+		text = "The highlighted tile was safe. Press retry to try again, or " +
+			"tap here or press U for the next puzzle."
+	case len(pu.Puzzle().Solution) > 1:
+		text = fmt.Sprintf(
+			"Each reveal proves the next tile safe, you only get one try. Move %d of %d.",
+			pu.Attempt.Moves()+1, len(pu.Puzzle().Solution),
+		)
+	default:
+		text = "Exactly one hidden tile can be proven safe. Reveal it, you only get one try."
+	}
+
+	if pu.Record != nil {
+		text += fmt.Sprintf(" (first try: %d of %d)", pu.Record.FirstTryCount(pu.Puzzles), len(pu.Puzzles))
+	}
+
+	return text
+}
+
+func (pu *PuzzleUI) Height() float64 {
+	return max(ScreenHeight*0.16, 90)
+}
+
+// returns true if player asked for the next puzzle by tapping the panel
+func (pu *PuzzleUI) Update() bool {
+	if !pu.Attempt.Over() {
+		return false
+	}
+
+	if IsMouseButtonJustPressed(eb.MouseButtonLeft) && CursorFPt().In(pu.Rect) {
+		return true
+	}
+	return IsTouchJustPressed(pu.Rect, nil)
+}
+
+func (pu *PuzzleUI) Draw(dst *eb.Image) {
+	DrawTextPanel(dst, pu.Rect, pu.Title(), pu.Text())
+}
+
+// ==========================
+// GameUI
+// ==========================
+
+// StartNextPuzzle starts puzzle after the current one in Puzzles
+// (first unsolved one if not in a puzzle) on a new board,
+// until difficulty is changed or other board is started
+func (gu *GameUI) StartNextPuzzle() {
+	if len(gu.Puzzles) <= 0 {
+		return
+	}
+
+	gu.StopLesson()
+	gu.StopSurvival()
+	gu.advancePuzzle()
+
+	gu.SetGameResetParameter()
+	gu.Game.ResetBoard()
+	SetRedraw()
+}
+
+// goes back to boards from Difficulty or CustomBoard,
+// board is not reset
+func (gu *GameUI) StopPuzzle() {
+	gu.PuzzleUI = nil
+
+	gu.TopUI.DifficultySelectUI.IsCustom = gu.UseCustomBoard
+	gu.TopUI.DifficultySelectUI.CustomLabel = ""
+}
+
+func (gu *GameUI) setPuzzle(pu *PuzzleUI) {
+	gu.PuzzleUI = pu
+
+	gu.TopUI.DifficultySelectUI.IsCustom = true
+	gu.TopUI.DifficultySelectUI.CustomLabel = "Puzzle"
+}
+
+// moves on to puzzle after the current one, wrapping around after the last one.
+// board is not reset
+func (gu *GameUI) advancePuzzle() {
+	next := gu.PuzzleRecord.FirstUnsolved(gu.Puzzles)
+	if gu.PuzzleUI != nil {
+		next = (gu.PuzzleUI.Index + 1) % len(gu.Puzzles)
+	}
+
+	pu, err := NewPuzzleUI(gu.Puzzles, next, &gu.PuzzleRecord)
+	if err != nil {
+		ErrLogger.Printf("failed to start puzzle: %v", err)
+		gu.StopPuzzle()
+		return
+	}
+
+	gu.setPuzzle(pu)
+}
+
+func (gu *GameUI) PuzzleUIRect() FRectangle {
+	h := gu.PuzzleUI.Height()
+	return FRectXYWH(0, ScreenHeight-h, ScreenWidth, h)
+}
+
+// loads PuzzleRecord from save data
+func (gu *GameUI) loadPuzzleRecord() {
+	data, err := LoadData(PuzzleRecordSaveName)
+	if err != nil {
+		ErrLogger.Printf("failed to load puzzle record: %v", err)
+		return
+	}
+	if data == nil {
+		return
+	}
+
+	if gu.PuzzleRecord, err = puzzle.ParseRecord(data); err != nil {
+		ErrLogger.Printf("failed to load puzzle record: %v", err)
+	}
+}
+
+// highlights the safe tile player missed
+func (gu *GameUI) puzzleStyleModifier() StyleModifier {
+	return func(
+		prevBoard, board engine.Board,
+		boardRect FRectangle,
+		interaction engine.BoardInteractionType,
+		stateChanged bool,
+		prevGameState, gameState engine.GameState,
+		tileStyles engine.Array2D[TileStyle],
+		gi GameInput,
+	) bool {
+		pu := gu.PuzzleUI
+		if pu == nil || !pu.Attempt.Missed() {
+			return false
+		}
+		p, ok := pu.Attempt.NextTile()
+		if !ok || !board.IsPosInBoard(p[0], p[1]) {
+			return false
+		}
+
+		pu.highlightTimer.TickUp()
+		tileStyles.Data[p[0]+tileStyles.Width*p[1]].Highlight = pu.highlightTimer.Normalize()
+
+		return pu.highlightTimer.Current < pu.highlightTimer.Duration+UpdateDelta()
+	}
+}
@@ -0,0 +1,218 @@
+// Package race is a relay server for races,
+// where players play the same board and the first to clear it wins.
+//
+// Unlike coop, each player plays their own copy of the board.
+// Server only tells everyone when the race starts and how far others are,
+// and checks replays players send to decide who won.
+package race
+
+import (
+	"fmt"
+
+	"minesweeper/engine"
+)
+
+// ==============================================
+// enums
+// ==============================================
+
+// what happens when a player steps on a mine
+type MineHitRule int
+
+const (
+	// player is out of the race
+	MineHitEliminate MineHitRule = iota
+
+	// player restarts the same board and gets a time penalty
+	MineHitPenalty
+
+	MineHitRuleSize
+)
+
+var MineHitRuleStrs = [MineHitRuleSize]string{
+	"eliminate",
+	"penalty",
+}
+
+func (r MineHitRule) String() string {
+	if r < 0 || r >= MineHitRuleSize {
+		return fmt.Sprintf("MineHitRule(%d)", int(r))
+	}
+	return MineHitRuleStrs[r]
+}
+
+func (r MineHitRule) MarshalText() ([]byte, error) {
+	if r < 0 || r >= MineHitRuleSize {
+		return nil, fmt.Errorf("invalid mine hit rule %d", int(r))
+	}
+	return []byte(MineHitRuleStrs[r]), nil
+}
+
+func (r *MineHitRule) UnmarshalText(text []byte) error {
+	for i := MineHitRule(0); i < MineHitRuleSize; i++ {
+		if string(text) == MineHitRuleStrs[i] {
+			*r = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown mine hit rule %q", string(text))
+}
+
+type PlayerState int
+
+const (
+	// not in the current race (joined after it started, or no race yet)
+	PlayerStateWaiting PlayerState = iota
+	PlayerStateRacing
+	PlayerStateFinished
+	PlayerStateEliminated
+
+	PlayerStateSize
+)
+
+var PlayerStateStrs = [PlayerStateSize]string{
+	"waiting",
+	"racing",
+	"finished",
+	"eliminated",
+}
+
+func (ps PlayerState) String() string {
+	if ps < 0 || ps >= PlayerStateSize {
+		return fmt.Sprintf("PlayerState(%d)", int(ps))
+	}
+	return PlayerStateStrs[ps]
+}
+
+func (ps PlayerState) MarshalText() ([]byte, error) {
+	if ps < 0 || ps >= PlayerStateSize {
+		return nil, fmt.Errorf("invalid player state %d", int(ps))
+	}
+	return []byte(PlayerStateStrs[ps]), nil
+}
+
+func (ps *PlayerState) UnmarshalText(text []byte) error {
+	for i := PlayerState(0); i < PlayerStateSize; i++ {
+		if string(text) == PlayerStateStrs[i] {
+			*ps = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown player state %q", string(text))
+}
+
+type MessageType int
+
+const (
+	// client -> server
+	MessageTypeStart MessageType = iota
+	MessageTypeProgress
+	MessageTypeAttempt
+
+	// server -> client
+	MessageTypeWelcome
+	MessageTypeRace
+	MessageTypeStatus
+	MessageTypeError
+
+	MessageTypeSize
+)
+
+var MessageTypeStrs = [MessageTypeSize]string{
+	"start",
+	"progress",
+	"attempt",
+
+	"welcome",
+	"race",
+	"status",
+	"error",
+}
+
+func (mt MessageType) String() string {
+	if mt < 0 || mt >= MessageTypeSize {
+		return fmt.Sprintf("MessageType(%d)", int(mt))
+	}
+	return MessageTypeStrs[mt]
+}
+
+func (mt MessageType) MarshalText() ([]byte, error) {
+	if mt < 0 || mt >= MessageTypeSize {
+		return nil, fmt.Errorf("invalid message type %d", int(mt))
+	}
+	return []byte(MessageTypeStrs[mt]), nil
+}
+
+func (mt *MessageType) UnmarshalText(text []byte) error {
+	for i := MessageType(0); i < MessageTypeSize; i++ {
+		if string(text) == MessageTypeStrs[i] {
+			*mt = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown message type %q", string(text))
+}
+
+// ==============================================
+// messages
+// ==============================================
+
+// Message is used for both directions, fields that are used depend on Type
+//
+//	start    : Code (board client wants to race on, seed is ignored)
+//	progress : Round, Revealed (safe tiles revealed in current attempt)
+//	attempt  : Round, Replay (finished attempt, won or lost)
+//	welcome  : Player
+//	race     : Round, Code, Rule, PenaltyMs, StartsInMs
+//	status   : Status
+//	error    : Error
+type Message struct {
+	Type MessageType `json:"type"`
+
+	Player int `json:"player,omitempty"`
+
+	Round int    `json:"round,omitempty"`
+	Code  string `json:"code,omitempty"`
+
+	Rule      MineHitRule `json:"rule,omitempty"`
+	PenaltyMs int64       `json:"penalty_ms,omitempty"`
+
+	// race starts this long after the message is sent
+	StartsInMs int64 `json:"starts_in_ms,omitempty"`
+
+	Revealed int            `json:"revealed,omitempty"`
+	Replay   *engine.Replay `json:"replay,omitempty"`
+
+	Status *RoomStatus `json:"status,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+type PlayerStatus struct {
+	ID    int         `json:"id"`
+	Name  string      `json:"name"`
+	State PlayerState `json:"state"`
+
+	// safe tiles revealed in current attempt
+	Revealed int `json:"revealed"`
+
+	Penalties int `json:"penalties"`
+
+	// time from the race start to the finish, with penalties
+	TimeMs int64 `json:"time_ms"`
+
+	// 1 for the winner, set when race is over, 0 if player didn't finish
+	Place int `json:"place"`
+}
+
+type RoomStatus struct {
+	Round int `json:"round"`
+
+	// true while race is going on
+	Racing bool `json:"racing"`
+
+	Code      string `json:"code"`
+	SafeTiles int    `json:"safe_tiles"`
+
+	Players []PlayerStatus `json:"players"`
+}
@@ -0,0 +1,410 @@
+package race
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"minesweeper/engine"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+const (
+	// attempts carry a whole replay
+	maxMessageSize = 1 << 20
+
+	sendQueueSize = 64
+	writeTimeout  = time.Second * 10
+
+	maxNameLen = 24
+
+	// replay can't take longer than the race,
+	// but clocks of client and server don't tick exactly the same
+	durationSlack = time.Second
+)
+
+// ==============================================
+// room
+// ==============================================
+
+type player struct {
+	PlayerStatus
+
+	send chan Message
+	kick func()
+}
+
+type Room struct {
+	mu sync.Mutex
+
+	round   int
+	racing  bool
+	code    engine.GameCode
+	startAt time.Time
+
+	players map[int]*player
+	nextID  int
+}
+
+func newRoom() *Room {
+	return &Room{
+		players: make(map[int]*player),
+		nextID:  1,
+	}
+}
+
+func (r *Room) statusLocked() *RoomStatus {
+	status := &RoomStatus{
+		Round:  r.round,
+		Racing: r.racing,
+	}
+
+	if r.round > 0 {
+		status.Code = r.code.String()
+		status.SafeTiles = r.code.Width*r.code.Height - r.code.MineCount
+	}
+
+	status.Players = make([]PlayerStatus, 0, len(r.players))
+	for _, p := range r.players {
+		status.Players = append(status.Players, p.PlayerStatus)
+	}
+	slices.SortFunc(status.Players, func(a, b PlayerStatus) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return status
+}
+
+func (r *Room) sendLocked(p *player, msg Message) {
+	select {
+	case p.send <- msg:
+	default:
+		// too slow to keep up, client will have to reconnect
+		p.kick()
+	}
+}
+
+func (r *Room) broadcastLocked(msg Message) {
+	for _, p := range r.players {
+		r.sendLocked(p, msg)
+	}
+}
+
+func (r *Room) broadcastStatusLocked() {
+	r.broadcastLocked(Message{Type: MessageTypeStatus, Status: r.statusLocked()})
+}
+
+// ends the race if nobody is racing anymore
+func (r *Room) checkRaceOverLocked() {
+	if !r.racing {
+		return
+	}
+
+	var finished []*player
+
+	for _, p := range r.players {
+		switch p.State {
+		case PlayerStateRacing:
+			return
+		case PlayerStateFinished:
+			finished = append(finished, p)
+		}
+	}
+
+	r.racing = false
+
+	slices.SortFunc(finished, func(a, b *player) int {
+		return cmp.Compare(a.TimeMs, b.TimeMs)
+	})
+	for i, p := range finished {
+		p.Place = i + 1
+	}
+}
+
+func cleanName(name string, id int) string {
+	name = strings.Join(strings.Fields(name), " ")
+
+	if runes := []rune(name); len(runes) > maxNameLen {
+		name = string(runes[:maxNameLen])
+	}
+	if name == "" {
+		name = fmt.Sprintf("player %d", id)
+	}
+
+	return name
+}
+
+func (r *Room) join(name string, kick func()) *player {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := &player{
+		send: make(chan Message, sendQueueSize),
+		kick: kick,
+	}
+	p.ID = r.nextID
+	p.Name = cleanName(name, p.ID)
+	r.nextID++
+
+	r.players[p.ID] = p
+
+	r.sendLocked(p, Message{Type: MessageTypeWelcome, Player: p.ID})
+	r.broadcastStatusLocked()
+
+	return p
+}
+
+// returns true if room is empty after player left
+func (r *Room) leave(p *player) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.players, p.ID)
+
+	r.checkRaceOverLocked()
+	r.broadcastStatusLocked()
+
+	return len(r.players) <= 0
+}
+
+func (r *Room) handle(s *Server, p *player, msg Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch msg.Type {
+	case MessageTypeStart:
+		// someone might have left their game open,
+		// so race can be replaced after MaxRaceTime
+		if r.racing && time.Since(r.startAt) < s.MaxRaceTime {
+			return errors.New("race is already going on")
+		}
+
+		code, err := engine.ParseGameCode(msg.Code)
+		if err != nil {
+			return err
+		}
+		if code.Variants&engine.GameVariantFlags != 0 {
+			return errors.New("can't race on a flags board")
+		}
+		// seed is always picked by the server, so that nobody can pick a board they know
+		rand.Read(code.Seed[:])
+
+		r.round++
+		r.racing = true
+		r.code = code
+		r.startAt = time.Now().Add(s.Countdown)
+
+		for _, other := range r.players {
+			other.PlayerStatus = PlayerStatus{
+				ID:    other.ID,
+				Name:  other.Name,
+				State: PlayerStateRacing,
+			}
+		}
+
+		r.broadcastLocked(Message{
+			Type:       MessageTypeRace,
+			Round:      r.round,
+			Code:       code.String(),
+			Rule:       s.Rule,
+			PenaltyMs:  s.Penalty.Milliseconds(),
+			StartsInMs: s.Countdown.Milliseconds(),
+		})
+		r.broadcastStatusLocked()
+
+	case MessageTypeProgress:
+		if msg.Round != r.round || p.State != PlayerStateRacing {
+			return nil
+		}
+
+		p.Revealed = max(min(msg.Revealed, r.code.Width*r.code.Height-r.code.MineCount), 0)
+		r.broadcastStatusLocked()
+
+	case MessageTypeAttempt:
+		if msg.Round != r.round || p.State != PlayerStateRacing {
+			return nil
+		}
+		if msg.Replay == nil {
+			return errors.New("attempt without a replay")
+		}
+
+		elapsed := time.Since(r.startAt)
+		if elapsed < 0 {
+			return errors.New("race hasn't started yet")
+		}
+
+		report, err := engine.ValidateReplay(*msg.Replay, s.ValidateOptions)
+		if err != nil {
+			return err
+		}
+		if report.Code != r.code {
+			return errors.New("replay is not of this race's board")
+		}
+		if report.Duration > elapsed+durationSlack {
+			return fmt.Errorf("replay took %v but race started %v ago", report.Duration, elapsed)
+		}
+
+		switch report.Result {
+		case engine.GameStateWon:
+			p.State = PlayerStateFinished
+			p.Revealed = r.code.Width*r.code.Height - r.code.MineCount
+			p.TimeMs = elapsed.Milliseconds() + s.Penalty.Milliseconds()*int64(p.Penalties)
+		case engine.GameStateLost:
+			if s.Rule == MineHitEliminate {
+				p.State = PlayerStateEliminated
+			} else {
+				p.Penalties++
+				p.Revealed = 0
+			}
+		default:
+			return errors.New("game is not finished")
+		}
+
+		s.saveReplay(r.round, p, *msg.Replay)
+
+		r.checkRaceOverLocked()
+		r.broadcastStatusLocked()
+	}
+
+	return nil
+}
+
+// ==============================================
+// server
+// ==============================================
+
+type Server struct {
+	Rule MineHitRule
+	// added to the time for every mine hit when Rule is MineHitPenalty
+	Penalty time.Duration
+
+	// time between the start request and the start of the race
+	Countdown time.Duration
+
+	// after this, a new race can be started even if someone is still racing
+	MaxRaceTime time.Duration
+
+	ValidateOptions engine.ReplayValidateOptions
+
+	// if not empty, every attempt is saved here
+	ReplayDir string
+
+	// origins other than the server's own that may connect,
+	// see websocket.AcceptOptions.OriginPatterns
+	OriginPatterns []string
+
+	mu    sync.Mutex
+	rooms map[string]*Room
+}
+
+func NewServer() *Server {
+	return &Server{
+		Rule:            MineHitEliminate,
+		Penalty:         time.Second * 10,
+		Countdown:       time.Second * 3,
+		MaxRaceTime:     time.Minute * 15,
+		ValidateOptions: engine.StrictReplayValidateOptions,
+		rooms:           make(map[string]*Room),
+	}
+}
+
+func (s *Server) saveReplay(round int, p *player, replay engine.Replay) {
+	if s.ReplayDir == "" {
+		return
+	}
+
+	name := fmt.Sprintf("race-%04d-player-%d-%s", round, p.ID, engine.ReplayFileName(replay, time.Now()))
+	path := filepath.Join(s.ReplayDir, name)
+
+	// failing to save doesn't change the result, and there's nobody to tell
+	engine.SaveReplay(path, replay)
+}
+
+func (s *Server) joinRoom(roomName, playerName string, kick func()) (*Room, *player) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.rooms[roomName]
+	if !ok {
+		room = newRoom()
+		s.rooms[roomName] = room
+	}
+
+	return room, room.join(playerName, kick)
+}
+
+func (s *Server) leaveRoom(roomName string, room *Room, p *player) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if room.leave(p) && s.rooms[roomName] == room {
+		delete(s.rooms, roomName)
+	}
+}
+
+// ServeHTTP accepts a websocket connection and joins the room
+// given by "room" query parameter as a player named "name".
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: s.OriginPatterns,
+	})
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+
+	conn.SetReadLimit(maxMessageSize)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	roomName := r.URL.Query().Get("room")
+
+	room, p := s.joinRoom(roomName, r.URL.Query().Get("name"), cancel)
+	defer s.leaveRoom(roomName, room, p)
+
+	// writer
+	go func() {
+		for {
+			select {
+			case msg := <-p.send:
+				writeCtx, writeCancel := context.WithTimeout(ctx, writeTimeout)
+				err := wsjson.Write(writeCtx, conn, msg)
+				writeCancel()
+				if err != nil {
+					cancel()
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// reader
+	for {
+		var msg Message
+		if err := wsjson.Read(ctx, conn, &msg); err != nil {
+			if websocket.CloseStatus(err) != websocket.StatusNormalClosure && !errors.Is(err, context.Canceled) {
+				conn.Close(websocket.StatusPolicyViolation, "bad message")
+			}
+			return
+		}
+
+		if err := room.handle(s, p, msg); err != nil {
+			room.mu.Lock()
+			room.sendLocked(p, Message{Type: MessageTypeError, Error: err.Error()})
+			room.mu.Unlock()
+		}
+	}
+}
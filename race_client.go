@@ -0,0 +1,158 @@
+package minesweeper
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"minesweeper/engine"
+	"minesweeper/race"
+)
+
+// RaceStart is a race server started
+type RaceStart struct {
+	Round int
+	Code  engine.GameCode
+
+	Rule    race.MineHitRule
+	Penalty time.Duration
+
+	// local time when race starts
+	StartAt time.Time
+}
+
+// RaceClient talks to a race.Server.
+//
+// Like CoopClient, connection is handled in separate goroutines.
+type RaceClient struct {
+	URL string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	sends  chan race.Message
+	starts chan RaceStart
+
+	mu       sync.Mutex
+	playerID int
+	status   race.RoomStatus
+}
+
+// NewRaceClient connects to roomURL as a player named name in background.
+//
+// roomURL is a websocket url of a race.Server (for example "ws://localhost:6970/race?room=lunch").
+func NewRaceClient(roomURL string, name string) (*RaceClient, error) {
+	u, err := url.Parse(roomURL)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		query := u.Query()
+		query.Set("name", name)
+		u.RawQuery = query.Encode()
+	}
+
+	c := new(RaceClient)
+
+	c.URL = u.String()
+
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	c.sends = make(chan race.Message, 64)
+	c.starts = make(chan RaceStart, 8)
+
+	go runWebsocket(c.ctx, c.cancel, "race", c.URL, c.sends, c.handle)
+
+	return c, nil
+}
+
+func (c *RaceClient) handle(msg race.Message) error {
+	switch msg.Type {
+	case race.MessageTypeWelcome:
+		c.mu.Lock()
+		c.playerID = msg.Player
+		c.mu.Unlock()
+
+	case race.MessageTypeRace:
+		code, err := engine.ParseGameCode(msg.Code)
+		if err != nil {
+			return err
+		}
+
+		start := RaceStart{
+			Round:   msg.Round,
+			Code:    code,
+			Rule:    msg.Rule,
+			Penalty: time.Duration(msg.PenaltyMs) * time.Millisecond,
+			StartAt: time.Now().Add(time.Duration(msg.StartsInMs) * time.Millisecond),
+		}
+
+		select {
+		case c.starts <- start:
+		case <-c.ctx.Done():
+		}
+
+	case race.MessageTypeStatus:
+		if msg.Status == nil {
+			break
+		}
+		c.mu.Lock()
+		c.status = *msg.Status
+		c.mu.Unlock()
+
+	case race.MessageTypeError:
+		WarnLogger.Printf("race: server: %s", msg.Error)
+	}
+
+	return nil
+}
+
+func (c *RaceClient) send(msg race.Message) {
+	select {
+	case c.sends <- msg:
+	default:
+		WarnLogger.Printf("race: dropping %s message, not connected", msg.Type)
+	}
+}
+
+// RequestStart asks server to start a race on a board like code.
+func (c *RaceClient) RequestStart(code engine.GameCode) {
+	c.send(race.Message{Type: race.MessageTypeStart, Code: code.String()})
+}
+
+func (c *RaceClient) SendProgress(round int, revealed int) {
+	c.send(race.Message{Type: race.MessageTypeProgress, Round: round, Revealed: revealed})
+}
+
+func (c *RaceClient) SendAttempt(round int, replay engine.Replay) {
+	c.send(race.Message{Type: race.MessageTypeAttempt, Round: round, Replay: &replay})
+}
+
+// PollStart returns a race that started since last call.
+func (c *RaceClient) PollStart() (RaceStart, bool) {
+	select {
+	case start := <-c.starts:
+		return start, true
+	default:
+		return RaceStart{}, false
+	}
+}
+
+// Status returns latest status of the room and our player id.
+func (c *RaceClient) Status() (race.RoomStatus, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.status, c.playerID
+}
+
+// Connected returns false once connection is closed.
+func (c *RaceClient) Connected() bool {
+	return c.ctx.Err() == nil
+}
+
+// Close disconnects from the server.
+func (c *RaceClient) Close() {
+	c.cancel()
+}
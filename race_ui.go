@@ -0,0 +1,293 @@
+package minesweeper
+
+import (
+	"fmt"
+	"time"
+
+	"minesweeper/engine"
+	"minesweeper/race"
+
+	eb "github.com/hajimehoshi/ebiten/v2"
+	ebt "github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// how long "go!" stays after the countdown
+const raceGoTextDuration = time.Millisecond * 700
+
+// panel at the bottom of the screen that shows
+// progress of every player in a race
+type RaceUI struct {
+	Client *RaceClient
+
+	// called when a race starts, should start the board
+	OnRaceStart func(start RaceStart)
+
+	// called when user wants to start a race
+	OnStartPressed func()
+
+	StartButton *TextButton
+
+	Rect FRectangle
+
+	current RaceStart
+	// true while we are playing the current race
+	inRace bool
+
+	sentRevealed int
+}
+
+func NewRaceUI(client *RaceClient) *RaceUI {
+	ru := new(RaceUI)
+
+	ru.Client = client
+
+	ru.StartButton = NewTextButton()
+	ru.StartButton.Text = "start race"
+	ru.StartButton.OnPress = func(bool) {
+		if ru.OnStartPressed != nil {
+			ru.OnStartPressed()
+		}
+	}
+
+	return ru
+}
+
+// height of the panel for current screen size
+func (ru *RaceUI) Height() float64 {
+	return max(ScreenHeight*0.1, 56)
+}
+
+// true during the countdown, board should not take input
+func (ru *RaceUI) BlocksInput() bool {
+	return ru.inRace && time.Now().Before(ru.current.StartAt)
+}
+
+func (ru *RaceUI) myStatus(status race.RoomStatus, me int) (race.PlayerStatus, bool) {
+	for _, p := range status.Players {
+		if p.ID == me {
+			return p, true
+		}
+	}
+	return race.PlayerStatus{}, false
+}
+
+// layout returns rectangles for status text, player cells and start button
+func (ru *RaceUI) layout(playerCount int) (FRectangle, []FRectangle, FRectangle) {
+	inner := ru.Rect.Inset(ru.Rect.Dy() * 0.1)
+
+	statusRect := FRectXYWH(inner.Min.X, inner.Min.Y, inner.Dx()*0.2, inner.Dy())
+	buttonRect := FRectXYWH(inner.Max.X-inner.Dx()*0.15, inner.Min.Y, inner.Dx()*0.15, inner.Dy())
+
+	cellArea := FRect(
+		statusRect.Max.X+inner.Dy()*0.2, inner.Min.Y,
+		buttonRect.Min.X-inner.Dy()*0.2, inner.Max.Y,
+	)
+
+	cells := make([]FRectangle, playerCount)
+	if playerCount > 0 {
+		margin := cellArea.Dx() * 0.02
+		cellW := (cellArea.Dx() - margin*f64(playerCount-1)) / f64(playerCount)
+
+		for i := range playerCount {
+			cells[i] = FRectXYWH(cellArea.Min.X+(cellW+margin)*f64(i), cellArea.Min.Y, cellW, cellArea.Dy())
+		}
+	}
+
+	return statusRect, cells, buttonRect
+}
+
+func (ru *RaceUI) Update(game *Game) {
+	if start, ok := ru.Client.PollStart(); ok {
+		ru.current = start
+		ru.inRace = true
+		ru.sentRevealed = 0
+
+		if ru.OnRaceStart != nil {
+			ru.OnRaceStart(start)
+		}
+	}
+
+	status, me := ru.Client.Status()
+
+	if ru.inRace {
+		if myStatus, ok := ru.myStatus(status, me); status.Round == ru.current.Round && ok {
+			ru.inRace = myStatus.State == race.PlayerStateRacing
+		}
+	}
+
+	// after a mine hit, player tries the same board again
+	game.RetrySameBoard = ru.inRace && ru.current.Rule == race.MineHitPenalty
+
+	// ==========================
+	// send progress
+	// ==========================
+	// user could have switched to another board in the middle of a race
+	if ru.inRace && game.GameCode() == ru.current.Code {
+		revealed := game.RevealedSafeTileCount()
+		if revealed != ru.sentRevealed {
+			ru.sentRevealed = revealed
+			ru.Client.SendProgress(ru.current.Round, revealed)
+		}
+	}
+
+	// ==========================
+	// start button
+	// ==========================
+	_, _, buttonRect := ru.layout(len(status.Players))
+
+	ru.StartButton.Rect = buttonRect
+	ru.StartButton.Disabled = status.Racing || !ru.Client.Connected()
+	ru.StartButton.Update()
+
+	// for timer and countdown
+	if status.Racing {
+		SetRedraw()
+	}
+}
+
+// OnGameEnd sends replay of a finished game if it's an attempt at the race
+func (ru *RaceUI) OnGameEnd(result engine.GameResult) {
+	if !ru.inRace || result.Code != ru.current.Code {
+		return
+	}
+	ru.Client.SendAttempt(ru.current.Round, result.Replay)
+}
+
+func (ru *RaceUI) statusText(status race.RoomStatus) string {
+	if !ru.Client.Connected() {
+		return "not connected"
+	}
+	if status.Round <= 0 {
+		return fmt.Sprintf("%d in room", len(status.Players))
+	}
+
+	if status.Racing {
+		if status.Round != ru.current.Round {
+			return "race going on"
+		}
+		elapsed := time.Since(ru.current.StartAt)
+		if elapsed < 0 {
+			return "get ready"
+		}
+		return fmt.Sprintf("%.1fs", elapsed.Seconds())
+	}
+
+	for _, p := range status.Players {
+		if p.Place == 1 {
+			return fmt.Sprintf("%s won", p.Name)
+		}
+	}
+	return "nobody finished"
+}
+
+func raceResultText(p race.PlayerStatus, safeTiles int) string {
+	switch p.State {
+	case race.PlayerStateFinished:
+		text := fmt.Sprintf("%.2fs", f64(p.TimeMs)/1000)
+		if p.Place > 0 {
+			text = fmt.Sprintf("#%d %s", p.Place, text)
+		}
+		return text
+	case race.PlayerStateEliminated:
+		return "out"
+	case race.PlayerStateRacing:
+		text := fmt.Sprintf("%d%%", p.Revealed*100/max(safeTiles, 1))
+		if p.Penalties > 0 {
+			text += fmt.Sprintf(" +%d", p.Penalties)
+		}
+		return text
+	}
+	return ""
+}
+
+func (ru *RaceUI) Draw(dst *eb.Image, boardRect FRectangle) {
+	status, me := ru.Client.Status()
+
+	FillRect(dst, ru.Rect, ColorRaceBg)
+
+	drawLine := func(text string, rect FRectangle, clr ColorTableIndex) {
+		face := &ebt.GoTextFace{Source: FaceSource}
+		face.Size = rect.Dy() * 0.8
+		WidthLimitFace(text, face, rect.Dx())
+
+		op := &DrawTextOptions{}
+		op.GeoM.Translate(rect.Min.X, rect.Min.Y+rect.Dy()*0.5-FaceSize(face)*0.5)
+		op.ColorScale.ScaleWithColor(clr)
+
+		DrawText(dst, text, face, op)
+	}
+
+	statusRect, cells, _ := ru.layout(len(status.Players))
+
+	drawLine(ru.statusText(status), statusRect.Inset(statusRect.Dy()*0.2), ColorRaceText)
+
+	// ==========================
+	// players
+	// ==========================
+	for i, p := range status.Players {
+		cell := cells[i]
+
+		nameRect := FRectXYWH(cell.Min.X, cell.Min.Y, cell.Dx(), cell.Dy()*0.5)
+		barRect := FRectXYWH(cell.Min.X, cell.Min.Y+cell.Dy()*0.6, cell.Dx(), cell.Dy()*0.35)
+
+		name := p.Name
+		if result := raceResultText(p, status.SafeTiles); result != "" {
+			name += "  " + result
+		}
+
+		textColor := ColorRaceText
+		if p.State == race.PlayerStateEliminated {
+			textColor = ColorRaceOut
+		}
+		drawLine(name, nameRect, textColor)
+
+		FillRect(dst, barRect, ColorRaceBarBg)
+
+		barColor := ColorRaceBar
+		if p.ID == me {
+			barColor = ColorRaceBarSelf
+		}
+		if p.State == race.PlayerStateEliminated {
+			barColor = ColorRaceOut
+		}
+
+		progress := Clamp(f64(p.Revealed)/f64(max(status.SafeTiles, 1)), 0, 1)
+		if p.State == race.PlayerStateWaiting {
+			progress = 0
+		}
+		FillRect(dst, FRectXYWH(barRect.Min.X, barRect.Min.Y, barRect.Dx()*progress, barRect.Dy()), barColor)
+	}
+
+	if !ru.StartButton.Disabled {
+		ru.StartButton.Draw(dst)
+	}
+
+	// ==========================
+	// countdown
+	// ==========================
+	if ru.inRace {
+		untilStart := time.Until(ru.current.StartAt)
+
+		text := ""
+		if untilStart > 0 {
+			text = fmt.Sprintf("%d", int(untilStart.Seconds())+1)
+		} else if untilStart > -raceGoTextDuration {
+			text = "go!"
+		}
+
+		if text != "" {
+			face := &ebt.GoTextFace{Source: FaceSource}
+			face.SetVariation(ebt.MustParseTag("wght"), 700)
+			face.Size = min(boardRect.Dx(), boardRect.Dy()) * 0.3
+
+			w, h := ebt.Measure(text, face, FaceLineSpacing(face))
+			center := FRectangleCenter(boardRect)
+
+			op := &DrawTextOptions{}
+			op.GeoM.Translate(center.X-w*0.5, center.Y-h*0.5)
+			op.ColorScale.ScaleWithColor(ColorRaceText)
+
+			DrawText(dst, text, face, op)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+//go:build ignore
+
+// ====================================================
+// program that serves minesweeper games as a json api
+// and co-op and race rooms over websocket
+//
+// usage :
+// 	go run run_server.go
+// 	go run run_server.go -port 8080 -ttl 30m -allow-origin "*"
+//
+// co-op :
+// 	go run main.go -coop "ws://localhost:6970/coop?room=lunch"
+//
+// race :
+// 	go run run_server.go -race-rule penalty -race-penalty 5s -race-replays replays
+// 	go run main.go -race "ws://localhost:6970/race?room=lunch" -race-name bob
+//
+// See "Game server", "Co-op" and "Race" sections of README.md.
+// ====================================================
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"minesweeper/coop"
+	"minesweeper/race"
+	"minesweeper/server"
+)
+
+var (
+	Port        uint
+	TTL         time.Duration
+	MaxSessions int
+	AllowOrigin string
+
+	RaceRule      string
+	RacePenalty   time.Duration
+	RaceCountdown time.Duration
+	RaceReplays   string
+)
+
+func init() {
+	flag.UintVar(&Port, "port", 6970, "port")
+	flag.DurationVar(&TTL, "ttl", time.Hour, "games that weren't accessed for this long are deleted")
+	flag.IntVar(&MaxSessions, "max-games", 10000, "maximum number of games kept in memory, 0 for no limit")
+	flag.StringVar(&AllowOrigin, "allow-origin", "", "value of Access-Control-Allow-Origin header")
+
+	flag.StringVar(&RaceRule, "race-rule", "eliminate", "what happens when a racer hits a mine (eliminate, penalty)")
+	flag.DurationVar(&RacePenalty, "race-penalty", time.Second*10, "time added for every mine hit with -race-rule penalty")
+	flag.DurationVar(&RaceCountdown, "race-countdown", time.Second*3, "countdown before a race starts")
+	flag.StringVar(&RaceReplays, "race-replays", "", "save every race attempt in this directory")
+}
+
+func main() {
+	flag.Parse()
+
+	if Port > math.MaxUint16 {
+		fmt.Fprintf(os.Stderr, "port %v is bigger than max port value\n", Port)
+		os.Exit(1)
+	}
+	if TTL <= 0 {
+		fmt.Fprintf(os.Stderr, "-ttl must be positive\n")
+		os.Exit(1)
+	}
+
+	var raceRule race.MineHitRule
+	if err := raceRule.UnmarshalText([]byte(RaceRule)); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	store := server.NewSessionStore(TTL, MaxSessions)
+
+	srv := server.NewServer(store)
+	srv.AllowOrigin = AllowOrigin
+
+	var originPatterns []string
+	if AllowOrigin != "" {
+		// websocket wants host patterns, not origins
+		pattern := AllowOrigin
+		if u, err := url.Parse(AllowOrigin); err == nil && u.Host != "" {
+			pattern = u.Host
+		}
+		originPatterns = []string{pattern}
+	}
+
+	coopSrv := coop.NewServer()
+	coopSrv.OriginPatterns = originPatterns
+
+	raceSrv := race.NewServer()
+	raceSrv.Rule = raceRule
+	raceSrv.Penalty = RacePenalty
+	raceSrv.Countdown = RaceCountdown
+	raceSrv.ReplayDir = RaceReplays
+	raceSrv.OriginPatterns = originPatterns
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", srv)
+	mux.Handle("/coop", coopSrv)
+	mux.Handle("/race", raceSrv)
+
+	fmt.Printf("listening to http://localhost:%v\n", Port)
+	fmt.Printf("co-op rooms at ws://localhost:%v/coop?room=<name>\n", Port)
+	fmt.Printf("race rooms at ws://localhost:%v/race?room=<name>\n", Port)
+
+	err := http.ListenAndServe(fmt.Sprintf(":%v", Port), mux)
+
+	if err != nil {
+		panic(err)
+	}
+}
@@ -5,6 +5,11 @@
 //
 // usage :
 // 	go run run_web.go
+// 	go run run_web.go -tournament october.json
+//
+// With -tournament, it also serves a tournament at /tournament/.
+// If the tournament file doesn't exist,
+// a new tournament with random rounds is created.
 //
 // To be honest, if you do that, windows keep asking
 // for network permission.
@@ -15,23 +20,42 @@
 package main
 
 import (
+	"crypto/rand"
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"math"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"minesweeper/engine"
+	"minesweeper/tournament"
 )
 
 var (
 	TargetFolder string
 	Port         uint
+
+	TournamentPath   string
+	TournamentDBPath string
+	TournamentRounds int
+	TournamentRankBy string
+	TournamentTarget string
 )
 
 func init() {
 	flag.StringVar(&TargetFolder, "folder", "./web_build", "folder to serve")
 	flag.UintVar(&Port, "port", 6969, "port")
+
+	flag.StringVar(&TournamentPath, "tournament", "", "tournament file, created if it doesn't exist")
+	flag.StringVar(&TournamentDBPath, "tournament-db", "", "file to store tournament results (default is <tournament>-results.json)")
+	flag.IntVar(&TournamentRounds, "tournament-rounds", 5, "rounds per bracket when creating a tournament")
+	flag.StringVar(&TournamentRankBy, "tournament-rank-by", "time", "ranking when creating a tournament (time, 3bvs, score)")
+	flag.StringVar(&TournamentTarget, "tournament-target", "", "3BV or rating range of rounds when creating a tournament (for example guesses:0..1)")
 }
 
 func main() {
@@ -50,14 +74,67 @@ func main() {
 	fmt.Printf("serving %s\n", TargetFolder)
 	fmt.Printf("listening to http://localhost:%v\n", Port)
 
-	fs := http.FileServer(http.Dir(TargetFolder))
-	err := http.ListenAndServe(fmt.Sprintf(":%v", Port), NoCache(fs))
+	mux := http.NewServeMux()
+	mux.Handle("/", NoCache(http.FileServer(http.Dir(TargetFolder))))
+
+	if TournamentPath != "" {
+		db, err := openTournament()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+
+		mux.Handle("/tournament/", NoCache(tournament.NewHandler(db)))
+
+		fmt.Printf("tournament at http://localhost:%v/tournament/\n", Port)
+	}
+
+	err := http.ListenAndServe(fmt.Sprintf(":%v", Port), mux)
 
 	if err != nil {
 		panic(err)
 	}
 }
 
+func openTournament() (*tournament.DB, error) {
+	config, err := tournament.LoadConfig(TournamentPath)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		var rankBy tournament.RankBy
+		if err = rankBy.UnmarshalText([]byte(TournamentRankBy)); err != nil {
+			return nil, err
+		}
+
+		var target engine.BoardTarget
+		if target, err = engine.ParseBoardTarget(TournamentTarget); err != nil {
+			return nil, err
+		}
+		if TournamentRounds <= 0 {
+			return nil, fmt.Errorf("-tournament-rounds must be positive")
+		}
+
+		var seed [32]byte
+		rand.Read(seed[:])
+
+		name := strings.TrimSuffix(filepath.Base(TournamentPath), filepath.Ext(TournamentPath))
+		config = tournament.NewConfig(name, seed, TournamentRounds, rankBy, target)
+
+		if err = tournament.SaveConfig(TournamentPath, config); err != nil {
+			return nil, err
+		}
+		fmt.Printf("created tournament %s\n", TournamentPath)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load tournament: %w", err)
+	}
+
+	dbPath := TournamentDBPath
+	if dbPath == "" {
+		dbPath = strings.TrimSuffix(TournamentPath, filepath.Ext(TournamentPath)) + "-results.json"
+	}
+
+	return tournament.OpenDB(dbPath, config)
+}
+
 // copied from https://stackoverflow.com/questions/33880343/go-webserver-dont-cache-files-using-timestamp
 
 var epoch = time.Unix(0, 0).Format(time.RFC1123)
@@ -0,0 +1,47 @@
+//go:build !js
+
+package minesweeper
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// on desktop, data is saved in user config directory
+
+func saveDataPath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "minesweeper", name), nil
+}
+
+// LoadData returns data saved with SaveData, nil if nothing was saved
+func LoadData(name string) ([]byte, error) {
+	path, err := saveDataPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// SaveData saves data under name, so it stays across sessions
+func SaveData(name string, data []byte) error {
+	path, err := saveDataPath(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,45 @@
+//go:build js
+
+package minesweeper
+
+import (
+	"errors"
+	"syscall/js"
+)
+
+// on web, data is saved in localStorage
+
+const saveDataPrefix = "minesweeper/"
+
+func localStorage() (js.Value, error) {
+	storage := js.Global().Get("localStorage")
+	if storage.IsUndefined() || storage.IsNull() {
+		return storage, errors.New("localStorage is not available")
+	}
+	return storage, nil
+}
+
+// LoadData returns data saved with SaveData, nil if nothing was saved
+func LoadData(name string) ([]byte, error) {
+	storage, err := localStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	value := storage.Call("getItem", saveDataPrefix+name)
+	if value.Type() != js.TypeString {
+		return nil, nil
+	}
+	return []byte(value.String()), nil
+}
+
+// SaveData saves data under name, so it stays across sessions
+func SaveData(name string, data []byte) error {
+	storage, err := localStorage()
+	if err != nil {
+		return err
+	}
+
+	storage.Call("setItem", saveDataPrefix+name, string(data))
+	return nil
+}
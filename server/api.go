@@ -0,0 +1,209 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"minesweeper/bot"
+	"minesweeper/engine"
+)
+
+// ==============================================
+// routes :
+//
+// 	POST   /api/games                create a game
+// 	GET    /api/games/{id}           visible state of a game
+// 	POST   /api/games/{id}/{action}  step, flag or chord at {"x":..,"y":..}
+// 	DELETE /api/games/{id}           delete a game
+//
+// every response is json, errors are {"error":"..."}
+// ==============================================
+
+type Server struct {
+	Store *SessionStore
+
+	// value of Access-Control-Allow-Origin header, empty for none
+	AllowOrigin string
+
+	mux *http.ServeMux
+}
+
+func NewServer(store *SessionStore) *Server {
+	s := &Server{Store: store}
+
+	s.mux = http.NewServeMux()
+
+	s.mux.HandleFunc("POST /api/games", s.handleCreate)
+	s.mux.HandleFunc("GET /api/games/{id}", s.handleGet)
+	s.mux.HandleFunc("POST /api/games/{id}/{action}", s.handleAction)
+	s.mux.HandleFunc("DELETE /api/games/{id}", s.handleDelete)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.AllowOrigin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", s.AllowOrigin)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// ==============================================
+// helpers
+// ==============================================
+
+// max size of request body
+const maxRequestSize = 1 << 16
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// readJSON decodes request body into v, empty body is allowed
+func readJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// ==============================================
+// handlers
+// ==============================================
+
+type CreateRequest struct {
+	// one of easy, medium, hard, defaults to easy
+	Difficulty string `json:"difficulty"`
+
+	// if any of these are set, board becomes a custom board
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	Mines  int `json:"mines"`
+
+	// 64 hex digits
+	Seed string `json:"seed"`
+	// game code, can't be used with other options
+	Code string `json:"code"`
+
+	// safe-area or safe-tile, defaults to safe-area
+	FirstClick string `json:"first_click"`
+}
+
+func (req CreateRequest) GameCode() (engine.GameCode, error) {
+	code, err := engine.BoardOptions{
+		Code:       req.Code,
+		Difficulty: req.Difficulty,
+		Width:      req.Width,
+		Height:     req.Height,
+		Mines:      req.Mines,
+		Seed:       req.Seed,
+		FirstClick: req.FirstClick,
+	}.GameCode()
+	if err == nil && code.Variants&engine.GameVariantFlags != 0 {
+		err = errors.New("flags variant is for two players on one device")
+	}
+	return code, err
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req CreateRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	code, err := req.GameCode()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	session, err := s.Store.Create(code)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, session.View())
+}
+
+func (s *Server) getSession(w http.ResponseWriter, r *http.Request) (*Session, bool) {
+	session, ok := s.Store.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("game not found"))
+	}
+	return session, ok
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.getSession(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, session.View())
+}
+
+type ActionRequest struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func (s *Server) handleAction(w http.ResponseWriter, r *http.Request) {
+	var action bot.Action
+	if err := action.UnmarshalText([]byte(r.PathValue("action"))); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	session, ok := s.getSession(w, r)
+	if !ok {
+		return
+	}
+
+	var req ActionRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := session.Interact(action, req.X, req.Y); err != nil {
+		if errors.Is(err, ErrGameOver) {
+			writeError(w, http.StatusConflict, err)
+		} else {
+			writeError(w, http.StatusBadRequest, err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, session.View())
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if !s.Store.Delete(r.PathValue("id")) {
+		writeError(w, http.StatusNotFound, errors.New("game not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,140 @@
+// Package server serves minesweeper games over http.
+//
+// Board lives on the server and clients only see what player is allowed to see,
+// mine positions (and the game code, since seed gives away mines)
+// are kept secret until the game ends.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"minesweeper/bot"
+	"minesweeper/engine"
+)
+
+var ErrGameOver = errors.New("game is over")
+
+type Session struct {
+	ID string
+
+	mu sync.Mutex
+
+	code engine.GameCode
+
+	board engine.Board
+	state engine.GameState
+
+	moves int
+
+	recorder *engine.ReplayRecorder
+
+	createdAt  time.Time
+	lastAccess time.Time
+
+	// time of first move and end of the game
+	startTime time.Time
+	endTime   time.Time
+}
+
+func newSession(id string, code engine.GameCode, now time.Time) *Session {
+	s := &Session{
+		ID:         id,
+		code:       code,
+		board:      engine.NewBoard(code.Width, code.Height),
+		state:      engine.GameStatePlaying,
+		recorder:   engine.NewReplayRecorder(code),
+		createdAt:  now,
+		lastAccess: now,
+	}
+	return s
+}
+
+// Interact applies action at x, y.
+func (s *Session) Interact(action bot.Action, x, y int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state != engine.GameStatePlaying {
+		return ErrGameOver
+	}
+	if action < 0 || action >= bot.ActionSize {
+		return fmt.Errorf("invalid action %d", int(action))
+	}
+	if !s.board.IsPosInBoard(x, y) {
+		return fmt.Errorf("position %d, %d is out of board", x, y)
+	}
+
+	now := time.Now()
+
+	if s.startTime.IsZero() {
+		s.startTime = now
+	}
+
+	interaction := action.Interaction()
+
+	s.state = s.board.InteractWithCode(x, y, interaction, s.state, s.code, nil)
+	s.recorder.Record(interaction, x, y)
+	s.moves++
+
+	if s.state != engine.GameStatePlaying {
+		s.endTime = now
+		s.recorder.Finish(s.state, s.board)
+	}
+
+	return nil
+}
+
+// SessionView is what client gets to see
+type SessionView struct {
+	ID string `json:"id"`
+
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	Mines  int `json:"mines"`
+
+	State engine.GameState `json:"state"`
+
+	// same format as bot protocol
+	Tiles []string `json:"tiles"`
+
+	Moves     int   `json:"moves"`
+	ElapsedMs int64 `json:"elapsed_ms"`
+
+	// only set when game is over
+	Code   string         `json:"code,omitempty"`
+	Replay *engine.Replay `json:"replay,omitempty"`
+}
+
+func (s *Session) View() SessionView {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	view := SessionView{
+		ID:     s.ID,
+		Width:  s.code.Width,
+		Height: s.code.Height,
+		Mines:  s.code.MineCount,
+		State:  s.state,
+		Tiles:  bot.VisibleTiles(s.board, s.state),
+		Moves:  s.moves,
+	}
+
+	if !s.startTime.IsZero() {
+		end := s.endTime
+		if end.IsZero() {
+			end = time.Now()
+		}
+		view.ElapsedMs = end.Sub(s.startTime).Milliseconds()
+	}
+
+	if s.state != engine.GameStatePlaying {
+		view.Code = s.code.String()
+		replay := s.recorder.Replay
+		view.Replay = &replay
+	}
+
+	return view
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"minesweeper/engine"
+)
+
+var ErrTooManySessions = errors.New("too many sessions")
+
+// SessionStore keeps sessions in memory.
+//
+// Sessions that weren't accessed for TTL are removed.
+// Expired sessions are swept lazily when sessions are created,
+// so store doesn't need a goroutine of it's own.
+type SessionStore struct {
+	TTL time.Duration
+
+	// 0 means no limit
+	MaxSessions int
+
+	mu        sync.Mutex
+	sessions  map[string]*Session
+	lastSweep time.Time
+}
+
+func NewSessionStore(ttl time.Duration, maxSessions int) *SessionStore {
+	return &SessionStore{
+		TTL:         ttl,
+		MaxSessions: maxSessions,
+		sessions:    make(map[string]*Session),
+	}
+}
+
+func newSessionID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func (st *SessionStore) Create(code engine.GameCode) (*Session, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(st.lastSweep) > st.TTL/4 {
+		st.deleteExpiredLocked(now)
+		st.lastSweep = now
+	}
+
+	if st.MaxSessions > 0 && len(st.sessions) >= st.MaxSessions {
+		st.deleteExpiredLocked(now)
+		if len(st.sessions) >= st.MaxSessions {
+			return nil, ErrTooManySessions
+		}
+	}
+
+	session := newSession(newSessionID(), code, now)
+	st.sessions[session.ID] = session
+
+	return session, nil
+}
+
+// Get returns session with id and refreshes it's expiry.
+func (st *SessionStore) Get(id string) (*Session, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	session, ok := st.sessions[id]
+	if !ok {
+		return nil, false
+	}
+
+	now := time.Now()
+
+	if st.isExpired(session, now) {
+		delete(st.sessions, id)
+		return nil, false
+	}
+
+	session.mu.Lock()
+	session.lastAccess = now
+	session.mu.Unlock()
+
+	return session, true
+}
+
+func (st *SessionStore) Delete(id string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	_, ok := st.sessions[id]
+	delete(st.sessions, id)
+
+	return ok
+}
+
+func (st *SessionStore) Len() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return len(st.sessions)
+}
+
+func (st *SessionStore) isExpired(session *Session, now time.Time) bool {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	return now.Sub(session.lastAccess) > st.TTL
+}
+
+func (st *SessionStore) deleteExpiredLocked(now time.Time) {
+	for id, session := range st.sessions {
+		if st.isExpired(session, now) {
+			delete(st.sessions, id)
+		}
+	}
+}
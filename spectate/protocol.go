@@ -0,0 +1,116 @@
+// Package spectate streams a game that is being played to spectators,
+// for things like stream overlays and coaching tools.
+//
+// Game tells Server what happened on its board,
+// Server turns it into events and sends them over websocket or server-sent events.
+// Spectator first gets a snapshot of the whole game and then only what changed.
+package spectate
+
+import (
+	"fmt"
+
+	"minesweeper/coop"
+	"minesweeper/engine"
+)
+
+type EventType int
+
+const (
+	// whole game, first event every spectator gets
+	EventTypeSnapshot EventType = iota
+
+	// new board, nothing revealed yet
+	EventTypeReset
+
+	EventTypeReveal
+	EventTypeFlag
+	EventTypeUnflag
+
+	EventTypeWon
+	EventTypeLost
+
+	// timer went past another second
+	EventTypeTick
+
+	EventTypeSize
+)
+
+var EventTypeStrs = [EventTypeSize]string{
+	"snapshot",
+	"reset",
+	"reveal",
+	"flag",
+	"unflag",
+	"won",
+	"lost",
+	"tick",
+}
+
+func (et EventType) String() string {
+	if et < 0 || et >= EventTypeSize {
+		return fmt.Sprintf("EventType(%d)", int(et))
+	}
+	return EventTypeStrs[et]
+}
+
+func (et EventType) MarshalText() ([]byte, error) {
+	if et < 0 || et >= EventTypeSize {
+		return nil, fmt.Errorf("invalid event type %d", int(et))
+	}
+	return []byte(EventTypeStrs[et]), nil
+}
+
+func (et *EventType) UnmarshalText(text []byte) error {
+	for i := EventType(0); i < EventTypeSize; i++ {
+		if string(text) == EventTypeStrs[i] {
+			*et = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown event type %q", string(text))
+}
+
+// Event is sent to spectators, fields that are used depend on Type
+//
+//	snapshot : Code, State, Board
+//	reset    : Code
+//	reveal   : Interaction, X, Y, Tiles (newly revealed tiles)
+//	flag     : Interaction, X, Y, Tiles (newly flagged tiles)
+//	unflag   : Interaction, X, Y, Tiles (tiles that lost their flag)
+//	won      : Interaction, X, Y, Board
+//	lost     : Interaction, X, Y, Board
+//	tick     : nothing else
+//
+// Every event has Seq and TimeMs.
+// Board only has mines of revealed tiles while game is being played,
+// so that spectators can't tell the player where mines are.
+type Event struct {
+	Type EventType `json:"type"`
+
+	// increases by one for every event,
+	// snapshot has Seq of the last event it includes
+	Seq int `json:"seq"`
+
+	// time on the game's timer
+	TimeMs int64 `json:"time_ms"`
+
+	Code  string           `json:"code,omitempty"`
+	State engine.GameState `json:"state,omitempty"`
+	Board *coop.BoardData  `json:"board,omitempty"`
+
+	// interaction that caused the event
+	Interaction engine.BoardInteractionType `json:"interaction,omitempty"`
+	X           int                         `json:"x,omitempty"`
+	Y           int                         `json:"y,omitempty"`
+
+	Tiles []Tile `json:"tiles,omitempty"`
+}
+
+type Tile struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+
+	// number of mines around the tile, -1 if tile is a mine,
+	// only set in reveal events
+	Number int `json:"number"`
+}
@@ -0,0 +1,308 @@
+package spectate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"minesweeper/coop"
+	"minesweeper/engine"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+const (
+	// spectator whose send queue fills up is disconnected
+	sendQueueSize = 256
+	writeTimeout  = time.Second * 10
+)
+
+type spectator struct {
+	send chan Event
+	// closes the connection
+	kick func()
+}
+
+// Server keeps track of the game and sends what happens to spectators.
+//
+// Reset, Update and Tick are meant to be called by the game,
+// ServeHTTP by spectators.
+type Server struct {
+	// value of Access-Control-Allow-Origin header for server-sent events, empty for none
+	AllowOrigin string
+
+	// origins other than the server's own that may connect with websocket,
+	// see websocket.AcceptOptions.OriginPatterns
+	OriginPatterns []string
+
+	mu sync.Mutex
+
+	code   engine.GameCode
+	board  engine.Board
+	state  engine.GameState
+	timeMs int64
+	seq    int
+
+	spectators map[*spectator]bool
+}
+
+func NewServer() *Server {
+	return &Server{
+		spectators: make(map[*spectator]bool),
+	}
+}
+
+func (s *Server) sendLocked(sp *spectator, event Event) {
+	select {
+	case sp.send <- event:
+	default:
+		// too slow to keep up, spectator will have to reconnect
+		sp.kick()
+	}
+}
+
+func (s *Server) broadcastLocked(event Event) {
+	s.seq++
+	event.Seq = s.seq
+	event.TimeMs = s.timeMs
+
+	for sp := range s.spectators {
+		s.sendLocked(sp, event)
+	}
+}
+
+// board without mines player doesn't know about yet
+func (s *Server) visibleBoardLocked() *coop.BoardData {
+	if s.state != engine.GameStatePlaying {
+		return coop.EncodeBoard(s.board)
+	}
+
+	board := s.board.Copy()
+	for i := range board.Mines.Data {
+		board.Mines.Data[i] = board.Mines.Data[i] && board.Revealed.Data[i]
+	}
+	return coop.EncodeBoard(board)
+}
+
+func (s *Server) snapshotLocked() Event {
+	return Event{
+		Type:   EventTypeSnapshot,
+		Seq:    s.seq,
+		TimeMs: s.timeMs,
+		Code:   s.code.String(),
+		State:  s.state,
+		Board:  s.visibleBoardLocked(),
+	}
+}
+
+// Reset is called when game starts a new board.
+func (s *Server) Reset(code engine.GameCode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.code = code
+	s.board = engine.NewBoard(code.Width, code.Height)
+	s.state = engine.GameStatePlaying
+	s.timeMs = 0
+
+	s.broadcastLocked(Event{Type: EventTypeReset, Code: code.String()})
+}
+
+// Update is called when board or game state has changed,
+// interaction at x, y is what changed it.
+func (s *Server) Update(
+	board engine.Board, state engine.GameState,
+	interaction engine.BoardInteractionType, x, y int,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if board.Width != s.board.Width || board.Height != s.board.Height {
+		// we missed the reset somehow, compare against an empty board
+		s.board = engine.NewBoard(board.Width, board.Height)
+	}
+
+	var revealed, flagged, unflagged []Tile
+
+	iter := engine.NewBoardIterator(0, 0, board.Width-1, board.Height-1)
+	for iter.HasNext() {
+		tx, ty := iter.GetNext()
+
+		if board.Revealed.Get(tx, ty) && !s.board.Revealed.Get(tx, ty) {
+			tile := Tile{X: tx, Y: ty, Number: board.GetNeighborMineCount(tx, ty)}
+			if board.Mines.Get(tx, ty) {
+				tile.Number = -1
+			}
+			revealed = append(revealed, tile)
+		}
+
+		if board.Flags.Get(tx, ty) != s.board.Flags.Get(tx, ty) {
+			if board.Flags.Get(tx, ty) {
+				flagged = append(flagged, Tile{X: tx, Y: ty})
+			} else {
+				unflagged = append(unflagged, Tile{X: tx, Y: ty})
+			}
+		}
+	}
+
+	prevState := s.state
+
+	board.SaveTo(s.board)
+	s.state = state
+
+	event := Event{Interaction: interaction, X: x, Y: y}
+
+	if len(revealed) > 0 {
+		event.Type = EventTypeReveal
+		event.Tiles = revealed
+		s.broadcastLocked(event)
+	}
+	if len(flagged) > 0 {
+		event.Type = EventTypeFlag
+		event.Tiles = flagged
+		s.broadcastLocked(event)
+	}
+	if len(unflagged) > 0 {
+		event.Type = EventTypeUnflag
+		event.Tiles = unflagged
+		s.broadcastLocked(event)
+	}
+
+	if state != prevState && (state == engine.GameStateWon || state == engine.GameStateLost) {
+		event.Type = EventTypeWon
+		if state == engine.GameStateLost {
+			event.Type = EventTypeLost
+		}
+		event.Tiles = nil
+		event.Board = s.visibleBoardLocked()
+		s.broadcastLocked(event)
+	}
+}
+
+// Tick is called with time on the game's timer,
+// spectators get a tick event every time it goes past a second.
+func (s *Server) Tick(elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prevSecond := s.timeMs / 1000
+	s.timeMs = elapsed.Milliseconds()
+
+	if s.timeMs/1000 != prevSecond {
+		s.broadcastLocked(Event{Type: EventTypeTick})
+	}
+}
+
+func (s *Server) join(kick func()) *spectator {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sp := &spectator{
+		send: make(chan Event, sendQueueSize),
+		kick: kick,
+	}
+	s.spectators[sp] = true
+
+	s.sendLocked(sp, s.snapshotLocked())
+
+	return sp
+}
+
+func (s *Server) leave(sp *spectator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.spectators, sp)
+}
+
+// ServeHTTP streams events as websocket messages if request is a websocket handshake,
+// and as server-sent events otherwise.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		s.serveWebsocket(w, r)
+	} else {
+		s.serveEventStream(w, r)
+	}
+}
+
+func (s *Server) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: s.OriginPatterns,
+	})
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+
+	// spectators don't say anything, this just notices when they leave
+	ctx := conn.CloseRead(r.Context())
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sp := s.join(cancel)
+	defer s.leave(sp)
+
+	for {
+		select {
+		case event := <-sp.send:
+			writeCtx, writeCancel := context.WithTimeout(ctx, writeTimeout)
+			err := wsjson.Write(writeCtx, conn, event)
+			writeCancel()
+			if err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Server) serveEventStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	if s.AllowOrigin != "" {
+		header.Set("Access-Control-Allow-Origin", s.AllowOrigin)
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	sp := s.join(cancel)
+	defer s.leave(sp)
+
+	for {
+		select {
+		case event := <-sp.send:
+			data, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			// event name lets browsers use addEventListener("reveal", ...)
+			if _, err := fmt.Fprintf(w, "event: %s\nid: %d\ndata: %s\n\n", event.Type, event.Seq, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package minesweeper
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"minesweeper/spectate"
+)
+
+// ListenSpectators starts serving the game to spectators at addr in background.
+//
+// Spectators are usually overlays running in a browser on the same machine,
+// so any origin is allowed.
+func ListenSpectators(addr string) (*spectate.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := spectate.NewServer()
+	srv.AllowOrigin = "*"
+	srv.OriginPatterns = []string{"*"}
+
+	go func() {
+		err := http.Serve(listener, srv)
+		if err != nil && !errors.Is(err, net.ErrClosed) {
+			ErrLogger.Printf("spectate: %v", err)
+		}
+	}()
+
+	InfoLogger.Printf("spectate: streaming at http://%s (server-sent events) and ws://%s", listener.Addr(), listener.Addr())
+
+	return srv, nil
+}
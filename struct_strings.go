@@ -34,33 +34,57 @@ func _() {
 	_ = x[ColorMineBg1-23]
 	_ = x[ColorMineBg2-24]
 	_ = x[ColorMine-25]
-	_ = x[ColorBgHighLight-26]
-	_ = x[ColorTileHighLight-27]
-	_ = x[ColorFgHighLight-28]
-	_ = x[ColorWater1-29]
-	_ = x[ColorWater2-30]
-	_ = x[ColorWater3-31]
-	_ = x[ColorWater4-32]
-	_ = x[ColorRetryA1-33]
-	_ = x[ColorRetryA2-34]
-	_ = x[ColorRetryA3-35]
-	_ = x[ColorRetryA4-36]
-	_ = x[ColorRetryB1-37]
-	_ = x[ColorRetryB2-38]
-	_ = x[ColorRetryB3-39]
-	_ = x[ColorRetryB4-40]
-	_ = x[ColorRetryWater1-41]
-	_ = x[ColorRetryWater2-42]
-	_ = x[ColorRetryWater3-43]
-	_ = x[ColorRetryWater4-44]
-	_ = x[ColorFlagTutorialFill-45]
-	_ = x[ColorFlagTutorialStroke-46]
-	_ = x[ColorTableSize-47]
+	_ = x[ColorMineHit-26]
+	_ = x[ColorBgHighLight-27]
+	_ = x[ColorTileHighLight-28]
+	_ = x[ColorFgHighLight-29]
+	_ = x[ColorWater1-30]
+	_ = x[ColorWater2-31]
+	_ = x[ColorWater3-32]
+	_ = x[ColorWater4-33]
+	_ = x[ColorRetryA1-34]
+	_ = x[ColorRetryA2-35]
+	_ = x[ColorRetryA3-36]
+	_ = x[ColorRetryA4-37]
+	_ = x[ColorRetryB1-38]
+	_ = x[ColorRetryB2-39]
+	_ = x[ColorRetryB3-40]
+	_ = x[ColorRetryB4-41]
+	_ = x[ColorRetryWater1-42]
+	_ = x[ColorRetryWater2-43]
+	_ = x[ColorRetryWater3-44]
+	_ = x[ColorRetryWater4-45]
+	_ = x[ColorFlagTutorialFill-46]
+	_ = x[ColorFlagTutorialStroke-47]
+	_ = x[ColorPopupDim-48]
+	_ = x[ColorPopupBg-49]
+	_ = x[ColorPopupStroke-50]
+	_ = x[ColorPopupText-51]
+	_ = x[ColorPopupError-52]
+	_ = x[ColorCoopPlayer1-53]
+	_ = x[ColorCoopPlayer2-54]
+	_ = x[ColorCoopPlayer3-55]
+	_ = x[ColorCoopPlayer4-56]
+	_ = x[ColorCoopPlayer5-57]
+	_ = x[ColorCoopPlayer6-58]
+	_ = x[ColorCoopCursorStroke-59]
+	_ = x[ColorRaceBg-60]
+	_ = x[ColorRaceText-61]
+	_ = x[ColorRaceBarBg-62]
+	_ = x[ColorRaceBar-63]
+	_ = x[ColorRaceBarSelf-64]
+	_ = x[ColorRaceOut-65]
+	_ = x[ColorFlagsPlayer1-66]
+	_ = x[ColorFlagsPlayer2-67]
+	_ = x[ColorTimeBonus-68]
+	_ = x[ColorTimePenalty-69]
+	_ = x[ColorTimeUp-70]
+	_ = x[ColorTableSize-71]
 }
 
-const _ColorTableIndex_name = "ColorBgColorTopUIBgColorTopUITitleColorTopUIButtonColorTopUIButtonOnHoverColorTopUIButtonOnDownColorTopUIFlagColorTileNormal1ColorTileNormal2ColorTileNormalStrokeColorTileRevealed1ColorTileRevealed2ColorTileRevealedStrokeColorNumber1ColorNumber2ColorNumber3ColorNumber4ColorNumber5ColorNumber6ColorNumber7ColorNumber8ColorFlagColorElementWonColorMineBg1ColorMineBg2ColorMineColorBgHighLightColorTileHighLightColorFgHighLightColorWater1ColorWater2ColorWater3ColorWater4ColorRetryA1ColorRetryA2ColorRetryA3ColorRetryA4ColorRetryB1ColorRetryB2ColorRetryB3ColorRetryB4ColorRetryWater1ColorRetryWater2ColorRetryWater3ColorRetryWater4ColorFlagTutorialFillColorFlagTutorialStrokeColorTableSize"
+const _ColorTableIndex_name = "ColorBgColorTopUIBgColorTopUITitleColorTopUIButtonColorTopUIButtonOnHoverColorTopUIButtonOnDownColorTopUIFlagColorTileNormal1ColorTileNormal2ColorTileNormalStrokeColorTileRevealed1ColorTileRevealed2ColorTileRevealedStrokeColorNumber1ColorNumber2ColorNumber3ColorNumber4ColorNumber5ColorNumber6ColorNumber7ColorNumber8ColorFlagColorElementWonColorMineBg1ColorMineBg2ColorMineColorMineHitColorBgHighLightColorTileHighLightColorFgHighLightColorWater1ColorWater2ColorWater3ColorWater4ColorRetryA1ColorRetryA2ColorRetryA3ColorRetryA4ColorRetryB1ColorRetryB2ColorRetryB3ColorRetryB4ColorRetryWater1ColorRetryWater2ColorRetryWater3ColorRetryWater4ColorFlagTutorialFillColorFlagTutorialStrokeColorPopupDimColorPopupBgColorPopupStrokeColorPopupTextColorPopupErrorColorCoopPlayer1ColorCoopPlayer2ColorCoopPlayer3ColorCoopPlayer4ColorCoopPlayer5ColorCoopPlayer6ColorCoopCursorStrokeColorRaceBgColorRaceTextColorRaceBarBgColorRaceBarColorRaceBarSelfColorRaceOutColorFlagsPlayer1ColorFlagsPlayer2ColorTimeBonusColorTimePenaltyColorTimeUpColorTableSize"
 
-var _ColorTableIndex_index = [...]uint16{0, 7, 19, 34, 50, 73, 95, 109, 125, 141, 162, 180, 198, 221, 233, 245, 257, 269, 281, 293, 305, 317, 326, 341, 353, 365, 374, 390, 408, 424, 435, 446, 457, 468, 480, 492, 504, 516, 528, 540, 552, 564, 580, 596, 612, 628, 649, 672, 686}
+var _ColorTableIndex_index = [...]uint16{0, 7, 19, 34, 50, 73, 95, 109, 125, 141, 162, 180, 198, 221, 233, 245, 257, 269, 281, 293, 305, 317, 326, 341, 353, 365, 374, 386, 402, 420, 436, 447, 458, 469, 480, 492, 504, 516, 528, 540, 552, 564, 576, 592, 608, 624, 640, 661, 684, 697, 709, 725, 739, 754, 770, 786, 802, 818, 834, 850, 871, 882, 895, 909, 921, 937, 949, 966, 983, 997, 1013, 1024, 1038}
 
 func (i ColorTableIndex) String() string {
 	if i < 0 || i >= ColorTableIndex(len(_ColorTableIndex_index)-1) {
@@ -0,0 +1,154 @@
+package minesweeper
+
+import (
+	"fmt"
+	"time"
+
+	"minesweeper/engine"
+
+	eb "github.com/hajimehoshi/ebiten/v2"
+)
+
+// SurvivalUI shows how a survival run is going under the board
+type SurvivalUI struct {
+	Rect FRectangle
+
+	Run *engine.SurvivalRun
+}
+
+func NewSurvivalUI(start engine.GameCode) (*SurvivalUI, error) {
+	run, err := engine.NewSurvivalRun(start)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SurvivalUI{Run: run}, nil
+}
+
+// starts a new run from a board like the first one of current run
+func (su *SurvivalUI) NewRun() {
+	start := su.Run.Start
+	start.Seed = GetSeed()
+
+	// start was already a valid start
+	su.Run, _ = engine.NewSurvivalRun(start)
+	SetRedraw()
+}
+
+func (su *SurvivalUI) OnGameEnd(result engine.GameResult) {
+	if err := su.Run.Add(result); err != nil {
+		ErrLogger.Printf("failed to add board to survival run: %v", err)
+	}
+	SetRedraw()
+}
+
+func (su *SurvivalUI) Title() string {
+	if su.Run.Over() {
+		return "Survival : run over"
+	}
+	return fmt.Sprintf("Survival : board %d", su.Run.Stage()+1)
+}
+
+func (su *SurvivalUI) Text() string {
+	if su.Run.Over() {
+		return fmt.Sprintf(
+			"Cleared %d boards in %s, score %d. Press retry for a new run. Run code is %s",
+			su.Run.Cleared(), durationText(su.Run.Duration()), su.Run.Score(), su.Run.Start.String(),
+		)
+	}
+	return fmt.Sprintf(
+		"Score %d. Every board is bigger or denser than the last, run ends on the first mine.",
+		su.Run.Score(),
+	)
+}
+
+func (su *SurvivalUI) Height() float64 {
+	return max(ScreenHeight*0.12, 70)
+}
+
+func (su *SurvivalUI) Draw(dst *eb.Image) {
+	DrawTextPanel(dst, su.Rect, su.Title(), su.Text())
+}
+
+// formats d like TimerUI
+func durationText(d time.Duration) string {
+	hours, minutes, seconds := GetHourMinuteSeconds(d)
+	if hours > 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
+
+// ==========================
+// GameUI
+// ==========================
+
+// StartSurvival starts a survival run from start,
+// until difficulty is changed or other board is started
+func (gu *GameUI) StartSurvival(start engine.GameCode) error {
+	su, err := NewSurvivalUI(start)
+	if err != nil {
+		return err
+	}
+
+	gu.StopLesson()
+	gu.StopPuzzle()
+	gu.setSurvival(su)
+
+	gu.SetGameResetParameter()
+	gu.Game.ResetBoardEx(false)
+	SetRedraw()
+
+	return nil
+}
+
+// starts a survival run from a board of current difficulty
+func (gu *GameUI) StartSurvivalFromDifficulty() {
+	start := engine.GameCode{
+		Seed: GetSeed(),
+
+		Width:     gu.BoardTileCount(gu.Difficulty).X,
+		Height:    gu.BoardTileCount(gu.Difficulty).Y,
+		MineCount: gu.MineCounts[gu.Difficulty],
+
+		// timer counts up through the whole run
+		Variants:   gu.Variants &^ engine.GameVariantTimeAttack,
+		FirstClick: gu.FirstClick,
+
+		Target: gu.Target,
+		Placer: gu.Placer,
+	}
+
+	if err := gu.StartSurvival(start); err != nil {
+		ErrLogger.Printf("failed to start survival: %v", err)
+	}
+}
+
+// goes back to boards from Difficulty or CustomBoard,
+// board is not reset
+func (gu *GameUI) StopSurvival() {
+	gu.SurvivalUI = nil
+
+	gu.Game.ContinueAfterWin = false
+	gu.Game.RetrySameBoard = false
+
+	gu.TopUI.DifficultySelectUI.IsCustom = gu.UseCustomBoard
+	gu.TopUI.DifficultySelectUI.CustomLabel = ""
+}
+
+func (gu *GameUI) setSurvival(su *SurvivalUI) {
+	gu.SurvivalUI = su
+
+	// next board comes right after a win,
+	// with a seed from the run (see SetGameResetParameter)
+	gu.Game.ContinueAfterWin = true
+	gu.Game.RetrySameBoard = true
+
+	gu.TopUI.DifficultySelectUI.IsCustom = true
+	gu.TopUI.DifficultySelectUI.CustomLabel = "Survival"
+}
+
+func (gu *GameUI) SurvivalUIRect() FRectangle {
+	h := gu.SurvivalUI.Height()
+	return FRectXYWH(0, ScreenHeight-h, ScreenWidth, h)
+}
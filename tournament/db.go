@@ -0,0 +1,187 @@
+package tournament
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"minesweeper/engine"
+)
+
+var (
+	ErrAlreadySubmitted = errors.New("player already submitted this round")
+	ErrNotARound        = errors.New("replay is not a round of this tournament")
+)
+
+const MaxPlayerNameLen = 32
+
+type Result struct {
+	Player string `json:"player"`
+
+	Bracket int `json:"bracket"`
+	Round   int `json:"round"`
+
+	Won        bool  `json:"won"`
+	DurationMs int64 `json:"duration_ms"`
+	BBBV       int   `json:"3bv"`
+
+	SubmittedAt time.Time `json:"submitted_at"`
+
+	Replay engine.Replay `json:"replay"`
+}
+
+// 3BV per second, 0 if player lost
+func (r Result) BBBVPerSecond() float64 {
+	if !r.Won || r.DurationMs <= 0 {
+		return 0
+	}
+	return float64(r.BBBV) / (float64(r.DurationMs) / 1000)
+}
+
+// DB keeps results in a json file.
+//
+// Whole file is rewritten on every submission,
+// which is fine for an office tournament.
+type DB struct {
+	Path string
+
+	Config Config
+
+	ValidateOptions engine.ReplayValidateOptions
+
+	mu      sync.Mutex
+	results []Result
+}
+
+// OpenDB loads results from path. File is created on first submission.
+func OpenDB(path string, config Config) (*DB, error) {
+	db := &DB{
+		Path:            path,
+		Config:          config,
+		ValidateOptions: engine.StrictReplayValidateOptions,
+	}
+
+	jsonBytes, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(jsonBytes, &db.results); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return db, nil
+}
+
+func (db *DB) saveLocked() error {
+	jsonBytes, err := json.MarshalIndent(db.results, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to temp file first so that a crash doesn't leave a half written file
+	tmp, err := os.CreateTemp(filepath.Dir(db.Path), filepath.Base(db.Path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(jsonBytes); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), db.Path)
+}
+
+func CleanPlayerName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+
+	if name == "" {
+		return "", errors.New("player name is empty")
+	}
+	if len([]rune(name)) > MaxPlayerNameLen {
+		return "", fmt.Errorf("player name is longer than %d characters", MaxPlayerNameLen)
+	}
+
+	return name, nil
+}
+
+// Submit validates replay and records it.
+//
+// Each player gets one attempt per round, since rounds are fixed boards
+// and later attempts would be playing a board they've already seen.
+func (db *DB) Submit(player string, replay engine.Replay) (Result, error) {
+	var result Result
+
+	player, err := CleanPlayerName(player)
+	if err != nil {
+		return result, err
+	}
+
+	report, err := engine.ValidateReplay(replay, db.ValidateOptions)
+	if err != nil {
+		return result, err
+	}
+
+	bracket, round, ok := db.Config.FindRound(report.Code)
+	if !ok {
+		return result, ErrNotARound
+	}
+
+	if report.Result == engine.GameStatePlaying {
+		return result, errors.New("game is not finished")
+	}
+
+	result = Result{
+		Player:      player,
+		Bracket:     bracket,
+		Round:       round,
+		Won:         report.Result == engine.GameStateWon,
+		DurationMs:  report.Duration.Milliseconds(),
+		BBBV:        report.BBBV,
+		SubmittedAt: time.Now().UTC(),
+		Replay:      replay,
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, r := range db.results {
+		if r.Bracket == bracket && r.Round == round && strings.EqualFold(r.Player, player) {
+			return Result{}, ErrAlreadySubmitted
+		}
+	}
+
+	db.results = append(db.results, result)
+
+	if err = db.saveLocked(); err != nil {
+		db.results = db.results[:len(db.results)-1]
+		return Result{}, err
+	}
+
+	return result, nil
+}
+
+// Results returns copy of every result.
+func (db *DB) Results() []Result {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	results := make([]Result, len(db.results))
+	copy(results, db.results)
+
+	return results
+}
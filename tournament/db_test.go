@@ -0,0 +1,79 @@
+package tournament
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"minesweeper/engine"
+)
+
+// wonReplay wins code by stepping on every safe tile,
+// with events interval apart
+func wonReplay(code engine.GameCode, interval int64) engine.Replay {
+	replay := engine.Replay{
+		Version: engine.ReplayVersion,
+		Code:    code.String(),
+	}
+
+	board := engine.NewBoard(code.Width, code.Height)
+	state := engine.GameStatePlaying
+
+	step := func(x, y int) {
+		state = board.InteractWithCode(x, y, engine.InteractionTypeStep, state, code, nil)
+		replay.Events = append(replay.Events, engine.ReplayEvent{
+			TimeMs: interval * int64(len(replay.Events)),
+			Type:   engine.InteractionTypeStep,
+			X:      x,
+			Y:      y,
+		})
+	}
+
+	step(code.Width/2, code.Height/2)
+
+	for y := range code.Height {
+		for x := range code.Width {
+			if state == engine.GameStatePlaying && !board.Revealed.Get(x, y) && !board.Mines.Get(x, y) {
+				step(x, y)
+			}
+		}
+	}
+
+	replay.Result = state
+	replay.DurationMs = replay.Events[len(replay.Events)-1].TimeMs
+	replay.BBBV = board.Get3BV()
+
+	return replay
+}
+
+func TestSubmitRejectsZeroTimeReplay(t *testing.T) {
+	code := engine.GameCode{
+		Width:     engine.DifficultyBoardSizesNormal[engine.DifficultyEasy].X,
+		Height:    engine.DifficultyBoardSizesNormal[engine.DifficultyEasy].Y,
+		MineCount: engine.DifficultyMineCounts[engine.DifficultyEasy],
+	}
+	code.Seed[0] = 1
+
+	config := Config{
+		Name:     "test",
+		Brackets: []Bracket{{Name: "easy", Rounds: []string{code.String()}}},
+	}
+
+	db, err := OpenDB(filepath.Join(t.TempDir(), "results.json"), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged := wonReplay(code, 0)
+	if forged.Result != engine.GameStateWon || len(forged.Events) < 2 {
+		t.Fatalf("test replay didn't win in more than one click")
+	}
+
+	if _, err = db.Submit("forger", forged); !errors.Is(err, engine.ErrReplayInvalid) {
+		t.Fatalf("replay with every event at 0ms was accepted (err: %v)", err)
+	}
+
+	if _, err = db.Submit("player", wonReplay(code, 1000)); err != nil {
+		t.Fatalf("same game played at a click per second was rejected: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package tournament
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"minesweeper/engine"
+)
+
+// ==============================================
+// routes :
+//
+// 	GET  /tournament/                standings page
+// 	GET  /tournament/standings.json  standings as json
+// 	GET  /tournament/rounds.json     game codes of every round
+// 	POST /tournament/submit          submit a replay
+//
+// submit takes either a form (player, replay file)
+// or json {"player":"...","replay":{...}}
+// ==============================================
+
+const maxReplaySize = 1 << 20
+
+type handler struct {
+	db  *DB
+	mux *http.ServeMux
+}
+
+// NewHandler returns handler that serves tournament under /tournament/.
+func NewHandler(db *DB) http.Handler {
+	h := &handler{db: db}
+
+	h.mux = http.NewServeMux()
+
+	h.mux.HandleFunc("GET /tournament/{$}", h.handlePage)
+	h.mux.HandleFunc("GET /tournament/standings.json", h.handleStandings)
+	h.mux.HandleFunc("GET /tournament/rounds.json", h.handleRounds)
+	h.mux.HandleFunc("POST /tournament/submit", h.handleSubmit)
+
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (h *handler) handleStandings(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Standings(h.db.Config, h.db.Results()))
+}
+
+func (h *handler) handleRounds(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.db.Config)
+}
+
+type submitRequest struct {
+	Player string        `json:"player"`
+	Replay engine.Replay `json:"replay"`
+}
+
+func (h *handler) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxReplaySize)
+
+	isForm := !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+
+	var req submitRequest
+	var err error
+
+	if isForm {
+		req.Player = r.FormValue("player")
+
+		file, _, formErr := r.FormFile("replay")
+		if formErr != nil {
+			err = fmt.Errorf("replay file is missing: %w", formErr)
+		} else {
+			defer file.Close()
+			var replayBytes []byte
+			if replayBytes, err = io.ReadAll(file); err == nil {
+				err = json.Unmarshal(replayBytes, &req.Replay)
+			}
+		}
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&req)
+	}
+
+	var result Result
+	if err == nil {
+		result, err = h.db.Submit(req.Player, req.Replay)
+	}
+
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusBadRequest
+		if errors.Is(err, ErrAlreadySubmitted) {
+			status = http.StatusConflict
+		}
+	}
+
+	if isForm {
+		message := ""
+		if err != nil {
+			message = fmt.Sprintf("submission failed: %v", err)
+		} else {
+			message = fmt.Sprintf(
+				"submitted %s round %d for %s",
+				h.db.Config.Brackets[result.Bracket].Name, result.Round+1, result.Player,
+			)
+		}
+		h.renderPage(w, status, message, err != nil)
+		return
+	}
+
+	if err != nil {
+		writeJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, status, result)
+}
+
+func (h *handler) handlePage(w http.ResponseWriter, r *http.Request) {
+	h.renderPage(w, http.StatusOK, "", false)
+}
+
+func (h *handler) renderPage(w http.ResponseWriter, status int, message string, isError bool) {
+	data := struct {
+		Config    Config
+		Brackets  []BracketStandings
+		Message   string
+		IsError   bool
+		UpdatedAt string
+	}{
+		Config:    h.db.Config,
+		Brackets:  Standings(h.db.Config, h.db.Results()),
+		Message:   message,
+		IsError:   isError,
+		UpdatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := pageTemplate.Execute(w, data); err != nil {
+		fmt.Fprintf(w, "failed to render page: %v", err)
+	}
+}
+
+func formatDuration(ms int64) string {
+	return fmt.Sprintf("%.3fs", float64(ms)/1000)
+}
+
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"duration": formatDuration,
+	"inc":      func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Config.Name}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 1100px; padding: 0 1em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: right; }
+th { background: #eee; }
+td.player { text-align: left; font-weight: bold; }
+td.lost { color: #b33; }
+code { font-size: 0.85em; word-break: break-all; }
+.message { padding: 0.6em; background: #e6f4e6; }
+.message.error { background: #f8e0e0; }
+</style>
+</head>
+<body>
+<h1>{{.Config.Name}}</h1>
+<p>ranked by <b>{{.Config.RankBy}}</b>, updated {{.UpdatedAt}}</p>
+
+{{if .Message}}<p class="message{{if .IsError}} error{{end}}">{{.Message}}</p>{{end}}
+
+<h2>Submit a replay</h2>
+<p>Start the game with <code>-record &lt;directory&gt;</code>, press G, enter a round's code and play it. One attempt per round.</p>
+<form method="post" action="/tournament/submit" enctype="multipart/form-data">
+<input name="player" placeholder="your name" maxlength="32" required>
+<input name="replay" type="file" accept=".json" required>
+<button type="submit">submit</button>
+</form>
+
+{{range .Brackets}}
+<h2>{{.Name}}</h2>
+<details>
+<summary>rounds</summary>
+<ol>{{range .Rounds}}<li><code>{{.}}</code></li>{{end}}</ol>
+</details>
+{{if .Standings}}
+<table>
+<tr>
+<th>#</th><th>player</th>
+{{range $i, $_ := .Rounds}}<th>R{{inc $i}}</th>{{end}}
+<th>won</th><th>time</th><th>3BV/s</th><th>points</th>
+</tr>
+{{range .Standings}}
+<tr>
+<td>{{.Rank}}</td><td class="player">{{.Player}}</td>
+{{range .Rounds}}{{if not .Played}}<td>-</td>{{else if .Won}}<td>{{duration .DurationMs}}</td>{{else}}<td class="lost">lost</td>{{end}}{{end}}
+<td>{{.RoundsWon}}</td><td>{{duration .TotalTimeMs}}</td><td>{{printf "%.2f" .AvgBBBVs}}</td><td>{{.Points}}</td>
+</tr>
+{{end}}
+</table>
+{{else}}
+<p>no results yet</p>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,160 @@
+package tournament
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+type RoundResult struct {
+	Played bool `json:"played"`
+
+	Won        bool    `json:"won"`
+	DurationMs int64   `json:"duration_ms"`
+	BBBVs      float64 `json:"3bvs"`
+	Points     int     `json:"points"`
+}
+
+type Standing struct {
+	Rank   int    `json:"rank"`
+	Player string `json:"player"`
+
+	// indexed by round
+	Rounds []RoundResult `json:"rounds"`
+
+	RoundsWon int `json:"rounds_won"`
+	// total time of won rounds
+	TotalTimeMs int64 `json:"total_time_ms"`
+	// average over every round of the bracket, unplayed rounds count as 0
+	AvgBBBVs float64 `json:"avg_3bvs"`
+	Points   int     `json:"points"`
+}
+
+type BracketStandings struct {
+	Name      string     `json:"name"`
+	Rounds    []string   `json:"rounds"`
+	Standings []Standing `json:"standings"`
+}
+
+// Standings ranks players of each bracket by config.RankBy.
+func Standings(config Config, results []Result) []BracketStandings {
+	all := make([]BracketStandings, len(config.Brackets))
+
+	for b, bracket := range config.Brackets {
+		roundCount := len(bracket.Rounds)
+
+		bs := BracketStandings{
+			Name:   bracket.Name,
+			Rounds: bracket.Rounds,
+		}
+
+		// ==========================
+		// collect results by player
+		// ==========================
+		indexOf := make(map[string]int)
+
+		for _, r := range results {
+			if r.Bracket != b || r.Round < 0 || r.Round >= roundCount {
+				continue
+			}
+
+			key := strings.ToLower(r.Player)
+			i, ok := indexOf[key]
+			if !ok {
+				i = len(bs.Standings)
+				indexOf[key] = i
+				bs.Standings = append(bs.Standings, Standing{
+					Player: r.Player,
+					Rounds: make([]RoundResult, roundCount),
+				})
+			}
+
+			bs.Standings[i].Rounds[r.Round] = RoundResult{
+				Played:     true,
+				Won:        r.Won,
+				DurationMs: r.DurationMs,
+				BBBVs:      r.BBBVPerSecond(),
+			}
+		}
+
+		// ==========================
+		// give points for each round
+		// ==========================
+		for round := range roundCount {
+			var winners []int
+			for i := range bs.Standings {
+				if bs.Standings[i].Rounds[round].Won {
+					winners = append(winners, i)
+				}
+			}
+
+			slices.SortStableFunc(winners, func(a, b int) int {
+				return cmp.Compare(
+					bs.Standings[a].Rounds[round].DurationMs,
+					bs.Standings[b].Rounds[round].DurationMs,
+				)
+			})
+
+			for place, i := range winners {
+				bs.Standings[i].Rounds[round].Points = len(winners) - place
+			}
+		}
+
+		// ==========================
+		// totals
+		// ==========================
+		for i := range bs.Standings {
+			s := &bs.Standings[i]
+
+			var bbbvsSum float64
+
+			for _, rr := range s.Rounds {
+				if rr.Won {
+					s.RoundsWon++
+					s.TotalTimeMs += rr.DurationMs
+				}
+				bbbvsSum += rr.BBBVs
+				s.Points += rr.Points
+			}
+
+			s.AvgBBBVs = bbbvsSum / float64(roundCount)
+		}
+
+		// ==========================
+		// rank
+		// ==========================
+		compare := func(a, b Standing) int {
+			switch config.RankBy {
+			case RankBy3BVs:
+				return cmp.Compare(b.AvgBBBVs, a.AvgBBBVs)
+			case RankByScore:
+				return cmp.Compare(b.Points, a.Points)
+			default:
+				if c := cmp.Compare(b.RoundsWon, a.RoundsWon); c != 0 {
+					return c
+				}
+				return cmp.Compare(a.TotalTimeMs, b.TotalTimeMs)
+			}
+		}
+
+		slices.SortStableFunc(bs.Standings, func(a, b Standing) int {
+			if c := compare(a, b); c != 0 {
+				return c
+			}
+			return strings.Compare(strings.ToLower(a.Player), strings.ToLower(b.Player))
+		})
+
+		// tied players share the rank
+		for i := range bs.Standings {
+			if i > 0 && compare(bs.Standings[i-1], bs.Standings[i]) == 0 {
+				bs.Standings[i].Rank = bs.Standings[i-1].Rank
+			} else {
+				bs.Standings[i].Rank = i + 1
+			}
+		}
+
+		all[b] = bs
+	}
+
+	return all
+}
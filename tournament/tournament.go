@@ -0,0 +1,195 @@
+// Package tournament runs minesweeper tournaments.
+//
+// Tournament has brackets (usually Easy, Medium and Hard)
+// and each bracket has fixed rounds, which are game codes.
+// Players play the rounds, submit their replays,
+// replays are validated and players are ranked.
+package tournament
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"minesweeper/engine"
+)
+
+// ==============================================
+// ranking
+// ==============================================
+
+type RankBy int
+
+const (
+	// more rounds won, then less total time of won rounds
+	RankByTime RankBy = iota
+	// higher average 3BV/s, lost rounds count as 0
+	RankBy3BVs
+	// more points, in each round winners get
+	// (number of winners - place + 1) points by their time
+	RankByScore
+	RankBySize
+)
+
+var RankByStrs = [RankBySize]string{
+	"time",
+	"3bvs",
+	"score",
+}
+
+func (rb RankBy) String() string {
+	if rb < 0 || rb >= RankBySize {
+		return fmt.Sprintf("RankBy(%d)", int(rb))
+	}
+	return RankByStrs[rb]
+}
+
+func (rb RankBy) MarshalText() ([]byte, error) {
+	if rb < 0 || rb >= RankBySize {
+		return nil, fmt.Errorf("invalid rank by %d", int(rb))
+	}
+	return []byte(RankByStrs[rb]), nil
+}
+
+func (rb *RankBy) UnmarshalText(text []byte) error {
+	for i := RankBy(0); i < RankBySize; i++ {
+		if string(text) == RankByStrs[i] {
+			*rb = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown rank by %q", string(text))
+}
+
+// ==============================================
+// config
+// ==============================================
+
+type Bracket struct {
+	Name string `json:"name"`
+
+	// game codes
+	Rounds []string `json:"rounds"`
+}
+
+type Config struct {
+	Name string `json:"name"`
+
+	RankBy RankBy `json:"rank_by"`
+
+	Brackets []Bracket `json:"brackets"`
+}
+
+// NewConfig creates a tournament with Easy, Medium and Hard brackets
+// where every round's seed is derived from seed.
+//
+// Every round is generated for target, which can be zero.
+// 3BV differs a lot between brackets, so target is usually a rating range.
+func NewConfig(name string, seed [32]byte, roundCount int, rankBy RankBy, target engine.BoardTarget) Config {
+	config := Config{
+		Name:   name,
+		RankBy: rankBy,
+	}
+
+	for d := engine.Difficulty(0); d < engine.DifficultySize; d++ {
+		bracket := Bracket{Name: engine.DifficultyStrs[d]}
+
+		for round := range roundCount {
+			var buf [48]byte
+			copy(buf[:], seed[:])
+			binary.BigEndian.PutUint64(buf[32:], uint64(d))
+			binary.BigEndian.PutUint64(buf[40:], uint64(round))
+
+			code := engine.GameCode{
+				Seed:      sha256.Sum256(buf[:]),
+				Width:     engine.DifficultyBoardSizesNormal[d].X,
+				Height:    engine.DifficultyBoardSizesNormal[d].Y,
+				MineCount: engine.DifficultyMineCounts[d],
+
+				Target: target,
+			}
+
+			bracket.Rounds = append(bracket.Rounds, code.String())
+		}
+
+		config.Brackets = append(config.Brackets, bracket)
+	}
+
+	return config
+}
+
+func (config Config) Validate() error {
+	if len(config.Brackets) <= 0 {
+		return errors.New("tournament has no brackets")
+	}
+
+	seen := make(map[string]bool)
+
+	for _, bracket := range config.Brackets {
+		if len(bracket.Rounds) <= 0 {
+			return fmt.Errorf("bracket %q has no rounds", bracket.Name)
+		}
+		for i, round := range bracket.Rounds {
+			code, err := engine.ParseGameCode(round)
+			if err != nil {
+				return fmt.Errorf("bracket %q round %d: %w", bracket.Name, i+1, err)
+			}
+			if code.Variants&engine.GameVariantFlags != 0 {
+				return fmt.Errorf("bracket %q round %d is a two player flags board", bracket.Name, i+1)
+			}
+			if seen[code.String()] {
+				return fmt.Errorf("bracket %q round %d is used more than once", bracket.Name, i+1)
+			}
+			seen[code.String()] = true
+		}
+	}
+
+	return nil
+}
+
+// FindRound returns index of bracket and round that has the game code.
+func (config Config) FindRound(code engine.GameCode) (int, int, bool) {
+	for b, bracket := range config.Brackets {
+		for r, round := range bracket.Rounds {
+			roundCode, err := engine.ParseGameCode(round)
+			if err != nil {
+				continue
+			}
+			if roundCode == code {
+				return b, r, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func LoadConfig(path string) (Config, error) {
+	var config Config
+
+	jsonBytes, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+
+	if err = json.Unmarshal(jsonBytes, &config); err != nil {
+		return config, err
+	}
+
+	if err = config.Validate(); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+func SaveConfig(path string, config Config) error {
+	jsonBytes, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, jsonBytes, 0644)
+}
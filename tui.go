@@ -0,0 +1,488 @@
+//go:build ignore
+
+// ====================================================
+// minesweeper that runs in a terminal
+//
+// usage :
+// 	go run tui.go
+// 	go run tui.go -difficulty hard
+// 	go run tui.go -width 30 -height 16 -mines 99 -seed <hex>
+// 	go run tui.go -code MS-...
+// 	go run tui.go -record ./replays
+//
+// keys :
+// 	arrows, hjkl, wasd : move cursor
+// 	space, enter       : step (chord on numbers)
+// 	f                  : flag
+// 	c                  : chord
+// 	r                  : new board
+// 	q, ctrl+c          : quit
+//
+// It uses stty to put terminal in raw mode,
+// so it only works on unix like systems.
+// ====================================================
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"minesweeper/engine"
+)
+
+var (
+	FlagDifficulty string
+	FlagWidth      int
+	FlagHeight     int
+	FlagMines      int
+	FlagSeed       string
+	FlagCode       string
+	FlagFirstClick string
+	FlagReplayDir  string
+	FlagForgiving  bool
+	FlagEvil       bool
+	FlagTarget     string
+	FlagPlacer     string
+)
+
+func init() {
+	// minesweeper package registers it's own flags on flag.CommandLine
+	// so we use our own flag set
+	flags := flag.NewFlagSet("tui", flag.ExitOnError)
+
+	flags.StringVar(&FlagDifficulty, "difficulty", "", "difficulty (easy, medium, hard), defaults to easy")
+	flags.IntVar(&FlagWidth, "width", 0, "board width, starts a custom board")
+	flags.IntVar(&FlagHeight, "height", 0, "board height, starts a custom board")
+	flags.IntVar(&FlagMines, "mines", 0, "mine count, starts a custom board")
+	flags.StringVar(&FlagSeed, "seed", "", "seed of the first board (64 hex digits)")
+	flags.StringVar(&FlagCode, "code", "", "game code of the first board")
+	flags.StringVar(&FlagFirstClick, "first-click", "", "first click policy (safe-area, safe-tile), defaults to safe-area")
+	flags.StringVar(&FlagReplayDir, "record", "", "save replays of finished games in this directory")
+	flags.BoolVar(&FlagForgiving, "forgiving", false, "stepping on a mine is forgiven when there was nothing but guesses left")
+	flags.BoolVar(&FlagEvil, "evil", false, "every guess is a mine, only moves that can be proven safe are safe")
+	flags.StringVar(&FlagTarget, "target", "", "generate boards in a 3BV or rating range (for example 3bv:120..160, guesses:0, rule:single..pair)")
+	flags.StringVar(&FlagPlacer, "placer", "", "how mines are placed (uniform, clustered, gradient, pattern, no-guess), defaults to uniform")
+
+	flags.Parse(os.Args[1:])
+}
+
+func main() {
+	code, err := codeFromFlags()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	term, err := EnterRawMode()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to set up terminal: %v\n", err)
+		os.Exit(1)
+	}
+	defer term.Restore()
+
+	game := NewTuiGame(code)
+
+	keys := make(chan []byte)
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		buf := make([]byte, 64)
+		for {
+			n, err := reader.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- bytes.Clone(buf[:n])
+		}
+	}()
+
+	// for updating timer
+	ticker := time.NewTicker(time.Millisecond * 500)
+	defer ticker.Stop()
+
+	game.Draw(os.Stdout)
+
+	for {
+		select {
+		case input, ok := <-keys:
+			if !ok {
+				return
+			}
+			for _, key := range ParseKeys(input) {
+				if key == KeyQuit {
+					return
+				}
+				game.HandleKey(key)
+			}
+		case <-ticker.C:
+		}
+
+		game.Draw(os.Stdout)
+	}
+}
+
+func codeFromFlags() (engine.GameCode, error) {
+	return engine.BoardOptions{
+		Code:       FlagCode,
+		Difficulty: FlagDifficulty,
+		Width:      FlagWidth,
+		Height:     FlagHeight,
+		Mines:      FlagMines,
+		Seed:       FlagSeed,
+		FirstClick: FlagFirstClick,
+		Forgiving:  FlagForgiving,
+		Evil:       FlagEvil,
+		Target:     FlagTarget,
+		Placer:     FlagPlacer,
+	}.GameCode()
+}
+
+func NewSeed() [32]byte {
+	var seed [32]byte
+	rand.Read(seed[:])
+	return seed
+}
+
+// ====================================================
+// game
+// ====================================================
+
+type TuiGame struct {
+	Code engine.GameCode
+
+	Board     engine.Board
+	GameState engine.GameState
+
+	CursorX int
+	CursorY int
+
+	Recorder *engine.ReplayRecorder
+
+	Message string
+
+	startTime time.Time
+	endTime   time.Time
+}
+
+func NewTuiGame(code engine.GameCode) *TuiGame {
+	g := new(TuiGame)
+	g.Reset(code)
+	return g
+}
+
+func (g *TuiGame) Reset(code engine.GameCode) {
+	g.Code = code
+
+	g.Board = engine.NewBoard(code.Width, code.Height)
+	g.GameState = engine.GameStatePlaying
+
+	g.CursorX = code.Width / 2
+	g.CursorY = code.Height / 2
+
+	g.Recorder = engine.NewReplayRecorder(code)
+
+	g.Message = ""
+
+	g.startTime = time.Time{}
+	g.endTime = time.Time{}
+}
+
+func (g *TuiGame) HandleKey(key Key) {
+	switch key {
+	case KeyUp:
+		g.CursorY = max(g.CursorY-1, 0)
+	case KeyDown:
+		g.CursorY = min(g.CursorY+1, g.Board.Height-1)
+	case KeyLeft:
+		g.CursorX = max(g.CursorX-1, 0)
+	case KeyRight:
+		g.CursorX = min(g.CursorX+1, g.Board.Width-1)
+
+	case KeyStep:
+		if g.Board.Revealed.Get(g.CursorX, g.CursorY) {
+			g.Interact(engine.InteractionTypeCheck)
+		} else {
+			g.Interact(engine.InteractionTypeStep)
+		}
+	case KeyFlag:
+		g.Interact(engine.InteractionTypeFlag)
+	case KeyChord:
+		g.Interact(engine.InteractionTypeCheck)
+
+	case KeyReset:
+		code := g.Code
+		code.Seed = NewSeed()
+		g.Reset(code)
+	}
+}
+
+func (g *TuiGame) Interact(interaction engine.BoardInteractionType) {
+	if g.GameState != engine.GameStatePlaying {
+		return
+	}
+
+	if g.startTime.IsZero() {
+		g.startTime = time.Now()
+	}
+
+	g.GameState = g.Board.InteractWithCode(g.CursorX, g.CursorY, interaction, g.GameState, g.Code, nil)
+	g.Recorder.Record(interaction, g.CursorX, g.CursorY)
+
+	if g.GameState != engine.GameStatePlaying {
+		g.endTime = time.Now()
+		g.Recorder.Finish(g.GameState, g.Board)
+		g.SaveReplay()
+	}
+}
+
+func (g *TuiGame) SaveReplay() {
+	if FlagReplayDir == "" {
+		return
+	}
+
+	g.Recorder.Replay.Rate()
+
+	replay := g.Recorder.Replay
+	path := filepath.Join(FlagReplayDir, engine.ReplayFileName(replay, time.Now()))
+
+	if err := engine.SaveReplay(path, replay); err != nil {
+		g.Message = fmt.Sprintf("failed to save replay: %v", err)
+	} else {
+		g.Message = fmt.Sprintf("saved replay %s", path)
+	}
+}
+
+func (g *TuiGame) Elapsed() time.Duration {
+	if g.startTime.IsZero() {
+		return 0
+	}
+	if !g.endTime.IsZero() {
+		return g.endTime.Sub(g.startTime)
+	}
+	return time.Since(g.startTime)
+}
+
+func (g *TuiGame) FlagCount() int {
+	count := 0
+	for _, flag := range g.Board.Flags.Data {
+		if flag {
+			count++
+		}
+	}
+	return count
+}
+
+// ====================================================
+// drawing
+// ====================================================
+
+const (
+	EscReset   = "\x1b[0m"
+	EscReverse = "\x1b[7m"
+	EscBold    = "\x1b[1m"
+	EscDim     = "\x1b[2m"
+	EscRed     = "\x1b[91m"
+	EscGreen   = "\x1b[92m"
+)
+
+// similar to classic minesweeper colors
+var NumberColors = [9]string{
+	"",
+	"\x1b[94m", // 1 blue
+	"\x1b[32m", // 2 green
+	"\x1b[91m", // 3 red
+	"\x1b[34m", // 4 dark blue
+	"\x1b[31m", // 5 dark red
+	"\x1b[36m", // 6 cyan
+	"\x1b[35m", // 7 magenta
+	"\x1b[90m", // 8 gray
+}
+
+func (g *TuiGame) Draw(out *os.File) {
+	sb := &strings.Builder{}
+
+	// move to top left and clear screen
+	sb.WriteString("\x1b[H\x1b[2J")
+
+	elapsed := g.Elapsed()
+	fmt.Fprintf(
+		sb, "%sMinesweeper%s  %dx%d  mines: %d  flags: %d  time: %02d:%02d\r\n",
+		EscBold, EscReset,
+		g.Board.Width, g.Board.Height,
+		g.Code.MineCount, g.FlagCount(),
+		int(elapsed.Minutes()), int(elapsed.Seconds())%60,
+	)
+	sb.WriteString("\r\n")
+
+	board := g.Board
+	gameOver := g.GameState != engine.GameStatePlaying
+
+	for y := range board.Height {
+		sb.WriteString("  ")
+		for x := range board.Width {
+			var style string
+			var char string
+
+			revealed := board.Revealed.Get(x, y)
+			flagged := board.Flags.Get(x, y)
+			mine := board.Mines.Get(x, y)
+
+			switch {
+			case gameOver && mine && !flagged:
+				style, char = EscRed, "*"
+			case gameOver && flagged && !mine:
+				style, char = EscRed, "X"
+			case flagged:
+				style, char = EscRed, "F"
+			case revealed:
+				count := board.GetNeighborMineCount(x, y)
+				if count > 0 {
+					style, char = NumberColors[count], fmt.Sprintf("%d", count)
+				} else {
+					char = " "
+				}
+			default:
+				style, char = EscDim, "."
+			}
+
+			if x == g.CursorX && y == g.CursorY {
+				style += EscReverse
+			}
+
+			cell := style + char + EscReset
+
+			sb.WriteString(cell)
+			sb.WriteString(" ")
+		}
+		sb.WriteString("\r\n")
+	}
+
+	sb.WriteString("\r\n")
+
+	switch g.GameState {
+	case engine.GameStateWon:
+		sb.WriteString(EscGreen + EscBold + "You won!" + EscReset + "  press r for a new board\r\n")
+	case engine.GameStateLost:
+		sb.WriteString(EscRed + EscBold + "Boom!" + EscReset + "  press r for a new board\r\n")
+	default:
+		sb.WriteString("move: arrows/hjkl/wasd  step: space  flag: f  chord: c  new: r  quit: q\r\n")
+	}
+
+	fmt.Fprintf(sb, "code: %s\r\n", g.Code.String())
+
+	if g.Message != "" {
+		sb.WriteString(g.Message + "\r\n")
+	}
+
+	out.WriteString(sb.String())
+}
+
+// ====================================================
+// input
+// ====================================================
+
+type Key int
+
+const (
+	KeyNone Key = iota
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyStep
+	KeyFlag
+	KeyChord
+	KeyReset
+	KeyQuit
+)
+
+func ParseKeys(input []byte) []Key {
+	var keys []Key
+
+	for len(input) > 0 {
+		// arrow keys
+		if len(input) >= 3 && input[0] == 0x1b && (input[1] == '[' || input[1] == 'O') {
+			switch input[2] {
+			case 'A':
+				keys = append(keys, KeyUp)
+			case 'B':
+				keys = append(keys, KeyDown)
+			case 'C':
+				keys = append(keys, KeyRight)
+			case 'D':
+				keys = append(keys, KeyLeft)
+			}
+			input = input[3:]
+			continue
+		}
+
+		switch input[0] {
+		case 'k', 'w':
+			keys = append(keys, KeyUp)
+		case 'j', 's':
+			keys = append(keys, KeyDown)
+		case 'h', 'a':
+			keys = append(keys, KeyLeft)
+		case 'l', 'd':
+			keys = append(keys, KeyRight)
+		case ' ', '\r', '\n':
+			keys = append(keys, KeyStep)
+		case 'f':
+			keys = append(keys, KeyFlag)
+		case 'c':
+			keys = append(keys, KeyChord)
+		case 'r':
+			keys = append(keys, KeyReset)
+		case 'q', 3: // 3 is ctrl+c
+			keys = append(keys, KeyQuit)
+		}
+		input = input[1:]
+	}
+
+	return keys
+}
+
+// ====================================================
+// terminal
+// ====================================================
+
+type Terminal struct {
+	savedState string
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func EnterRawMode() (*Terminal, error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+
+	// use alternate screen and hide cursor
+	os.Stdout.WriteString("\x1b[?1049h\x1b[?25l")
+
+	return &Terminal{savedState: saved}, nil
+}
+
+func (t *Terminal) Restore() {
+	os.Stdout.WriteString("\x1b[?25h\x1b[?1049l")
+	stty(t.savedState)
+}
@@ -207,6 +207,32 @@ func WidthLimitFace(
 	}
 }
 
+// breaks text into lines at spaces so that each line fits in width,
+// word that is wider than width gets a line of its own
+func WrapText(text string, face ebt.Face, width float64) string {
+	var lines []string
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+		next := word
+		if line != "" {
+			next = line + " " + word
+		}
+
+		if w, _ := ebt.Measure(next, face, FaceLineSpacing(face)); w > width && line != "" {
+			lines = append(lines, line)
+			line = word
+		} else {
+			line = next
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 func FitTextInRect(
 	text string,
 	face ebt.Face,
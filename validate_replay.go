@@ -0,0 +1,129 @@
+//go:build ignore
+
+// ====================================================
+// checks replay files by playing them again
+//
+// usage :
+// 	go run validate_replay.go replay-2024-12-01-15-04-05-won.json ...
+// 	go run validate_replay.go -min-interval 0 ./replays/*.json
+// 	go run validate_replay.go -survival board1.json board2.json board3.json
+//
+// With -survival, replays are boards of one survival run in order.
+//
+// Exits with 1 if any of the replays is invalid.
+// ====================================================
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+
+	"minesweeper/engine"
+)
+
+var MinInterval = flag.Duration(
+	"min-interval",
+	engine.DefaultReplayValidateOptions.MinEventInterval,
+	"reject events closer than this, 0 for no check",
+)
+
+var Strict = flag.Bool("strict", false, "check as tournament and race servers do, overrides -min-interval")
+
+var Survival = flag.Bool("survival", false, "replays are boards of one survival run, in order")
+
+func main() {
+	flag.Parse()
+
+	if flag.NArg() <= 0 {
+		fmt.Fprintf(os.Stderr, "usage: go run validate_replay.go [-min-interval 8ms] [-strict] [-survival] <replay.json>...\n")
+		os.Exit(1)
+	}
+
+	opts := engine.ReplayValidateOptions{
+		MinEventInterval: *MinInterval,
+	}
+	if *Strict {
+		opts = engine.StrictReplayValidateOptions
+	}
+
+	if *Survival {
+		validateSurvival(opts)
+		return
+	}
+
+	anyInvalid := false
+
+	for _, path := range flag.Args() {
+		replay, err := engine.LoadReplay(path)
+		if err != nil {
+			fmt.Printf("%s : INVALID : %v\n", path, err)
+			anyInvalid = true
+			continue
+		}
+
+		report, err := engine.ValidateReplay(replay, opts)
+		if err != nil {
+			fmt.Printf("%s : INVALID : %v\n", path, err)
+			anyInvalid = true
+			continue
+		}
+
+		fmt.Printf(
+			"%s : OK : %v, %dx%d %d mines, %v, 3BV %d, %d events\n",
+			path, report.Result,
+			report.Code.Width, report.Code.Height, report.Code.MineCount,
+			report.Duration, report.BBBV, report.EventCount,
+		)
+
+		if report.Aborted != "" {
+			fmt.Printf("    aborted : %s\n", report.Aborted)
+		}
+
+		if x, y, ok := replay.FirstStep(); ok {
+			if rating := engine.RateBoard(report.Code, x, y); rating.BBBV > 0 {
+				fmt.Printf("    rating : %v\n", rating)
+				if replay.Rating != nil && !reflect.DeepEqual(*replay.Rating, rating) {
+					fmt.Printf("    rating saved with replay is different : %v\n", *replay.Rating)
+				}
+			}
+		}
+
+		if report.Result == engine.GameStateLost {
+			if loss, err := engine.AnalyzeLoss(replay); err == nil {
+				fmt.Printf("    loss   : %v at %d, %d\n", loss, loss.X, loss.Y)
+			}
+		}
+	}
+
+	if anyInvalid {
+		os.Exit(1)
+	}
+}
+
+func validateSurvival(opts engine.ReplayValidateOptions) {
+	var replays []engine.Replay
+
+	for _, path := range flag.Args() {
+		replay, err := engine.LoadReplay(path)
+		if err != nil {
+			fmt.Printf("%s : INVALID : %v\n", path, err)
+			os.Exit(1)
+		}
+		replays = append(replays, replay)
+	}
+
+	report, err := engine.ValidateSurvivalRun(replays, opts)
+	if err != nil {
+		fmt.Printf("survival run : INVALID : %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf(
+		"survival run : OK : cleared %d boards, score %d, %v, over %v\n",
+		report.Cleared, report.Score, report.Duration, report.Over,
+	)
+	fmt.Printf("    run code : %s\n", report.Start.String())
+}
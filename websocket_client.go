@@ -0,0 +1,62 @@
+package minesweeper
+
+import (
+	"context"
+	"errors"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+// runWebsocket connects to url and exchanges json messages
+// until ctx is canceled or connection breaks, then calls cancel.
+//
+// Messages from sends are written as they come,
+// every message read is given to onMessage.
+// name is used for logging.
+func runWebsocket[S, R any](
+	ctx context.Context, cancel context.CancelFunc,
+	name string, url string,
+	sends <-chan S, onMessage func(R) error,
+) {
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		ErrLogger.Printf("%s: failed to connect to %s: %v", name, url, err)
+		return
+	}
+	defer conn.CloseNow()
+
+	InfoLogger.Printf("%s: connected to %s", name, url)
+
+	// writer
+	go func() {
+		for {
+			select {
+			case msg := <-sends:
+				if err := wsjson.Write(ctx, conn, msg); err != nil {
+					cancel()
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// reader
+	for {
+		var msg R
+		if err := wsjson.Read(ctx, conn, &msg); err != nil {
+			if !errors.Is(err, context.Canceled) {
+				ErrLogger.Printf("%s: disconnected: %v", name, err)
+			}
+			return
+		}
+
+		if err := onMessage(msg); err != nil {
+			WarnLogger.Printf("%s: %v", name, err)
+		}
+	}
+}